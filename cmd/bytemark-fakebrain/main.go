@@ -0,0 +1,57 @@
+/*
+Command bytemark-fakebrain runs an in-memory fake of Bytemark's brain, auth,
+bmbilling and SPP APIs, for developing and testing tools against without
+touching real servers. Everything is forgotten when it exits.
+
+It starts off with one user who owns one account, and prints out the flags to
+give the bytemark client to use it. For example:
+
+	bytemark-fakebrain -listen 127.0.0.1:8123 -user alice -password secret
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/fake"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8123", "address to listen on")
+	username := flag.String("user", "test-user", "username of the user to create")
+	password := flag.String("password", "password", "password of the user to create")
+	account := flag.String("account", "", "name of the account to create for the user (default is the username)")
+	admin := flag.Bool("admin", false, "give the user cluster_admin")
+	flag.Parse()
+
+	if *account == "" {
+		*account = *username
+	}
+
+	cluster := fake.New()
+	cluster.AddUser(*username, *password)
+	if _, err := cluster.AddAccount(*account, *username); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *admin {
+		if _, err := cluster.GrantPrivilege(brain.Privilege{Username: *username, Level: brain.ClusterAdminPrivilege}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	urls := fake.URLs("http://" + *listen)
+	fmt.Printf("Listening on %s. Log in as %s with password %s, using:\n\n", *listen, *username, *password)
+	fmt.Printf("  bytemark --insecure --endpoint %s --auth-endpoint %s --billing-endpoint %s --spp-endpoint %s --api-endpoint %s --user %s <command>\n\n",
+		urls.Brain, urls.Auth, urls.Billing, urls.SPP, urls.API, *username)
+
+	if err := http.ListenAndServe(*listen, cluster.Handler()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -74,11 +74,13 @@ func init() {
 				return
 			}),
 		}, {
-			Name:        "ips",
-			Aliases:     []string{"ip"},
-			Usage:       "add extra IP addresses to a server",
-			UsageText:   "add ips [--ipv4 | --ipv6] [--ips <number>] <server name>",
-			Description: `Add an extra IP to the given server. The IP will be chosen by the brain and output to standard out.`,
+			Name:      "ips",
+			Aliases:   []string{"ip"},
+			Usage:     "add extra IP addresses to a server",
+			UsageText: "add ips [--ipv4 | --ipv6] [--ips <number>] <server name>",
+			Description: `Add an extra IP to the given server. The IP will be chosen by the brain and output to standard out.
+
+Extra IPs can be listed with 'bytemark show extra ips', routed to another server with 'bytemark update extra ip' and released with 'bytemark delete ip'.`,
 			Flags: []cli.Flag{
 				cli.BoolFlag{
 					Name:  "ipv4",
@@ -28,6 +28,24 @@ func Optional(args ...string) func(c *app.Context) error {
 	}
 }
 
+// All sets the named flag once for each of the remaining arguments. It's for
+// slice flags, which append each value they're set to.
+func All(flagName string) func(c *app.Context) error {
+	return func(c *app.Context) error {
+		for {
+			value, err := c.NextArg()
+			if err != nil {
+				// no more arguments
+				return nil
+			}
+			err = c.Context.Set(flagName, value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Join is like Optional, but reads up to n arguments joined with spaces and sets the one named flag.
 // if n is not set, reads all the remaining arguments.
 func Join(flagName string, n ...int) func(c *app.Context) error {
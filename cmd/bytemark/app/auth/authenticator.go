@@ -100,8 +100,13 @@ func (a Authenticator) tryCredentials() (err error) {
 		} else {
 			// we have successfully authenticated!
 
-			// TODO(telyn): warn on failure to write to token
-			_ = a.config.SetPersistent("token", a.client.GetSessionToken(), "AUTH")
+			// when replaying a cassette the session's token is the
+			// redacted one that was recorded, which mustn't replace the
+			// user's real token.
+			if a.config.GetIgnoreErr("replay") == "" {
+				// TODO(telyn): warn on failure to write to token
+				_ = a.config.SetPersistent("token", a.client.GetSessionToken(), "AUTH")
+			}
 
 			// Check that the 2fa factor was set if --2fa-otp was specified.
 			// Checking here rather than in checkSession as it is only relevant
@@ -192,8 +197,22 @@ func (a Authenticator) checkSession(shortCircuit bool) error {
 	return nil
 }
 
+// authWithAPIKey authenticates using an API key instead of a session token.
+// There's nothing to prompt for, so any failure is returned straight away.
+func (a Authenticator) authWithAPIKey(apiKey string) error {
+	if a.config.GetIgnoreErr("impersonate") != "" {
+		return errors.New("Impersonation isn't possible when authenticating with an API key")
+	}
+	return a.client.AuthWithToken("apikey." + strings.TrimPrefix(apiKey, "apikey."))
+}
+
 // Authenticate performs authentication, checks and impersonation.
+// If an API key has been set (with --api-key, the api-key config var or
+// 'bytemark login --api-key') it is used instead of the session token.
 func (a Authenticator) Authenticate() error {
+	if apiKey := a.config.GetIgnoreErr("api-key"); apiKey != "" {
+		return a.authWithAPIKey(apiKey)
+	}
 	err := a.tryToken()
 	if err != nil {
 		// check for url.Error cause that indicates something worse than a simple auth fail.
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"runtime/debug"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	auth3 "github.com/BytemarkHosting/auth-client"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/config"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
 	"github.com/BytemarkHosting/bytemark-client/mocks"
 	mock "github.com/maraino/go-mock"
 	"github.com/urfave/cli"
@@ -46,6 +48,7 @@ type authInput struct {
 	yubikey         string
 	impersonate     string
 	token           string
+	apiKey          string
 	promptResponses []string
 }
 
@@ -93,8 +96,12 @@ func stubClientAuth(t *testing.T, state *int, c *mocks.Client, input authInput,
 			t.Fatalf("AuthWithToken should not have been called in state %v", *state)
 		}
 
-		if token != input.token {
-			panic(fmt.Sprintf("token %q != input.token %q", token, input.token))
+		expectedToken := input.token
+		if input.apiKey != "" {
+			expectedToken = "apikey." + input.apiKey
+		}
+		if token != expectedToken {
+			panic(fmt.Sprintf("token %q != expected token %q", token, expectedToken))
 		}
 		nextState("AuthWithToken")
 		return err
@@ -185,6 +192,7 @@ func setupAuthConfig(t *testing.T, input authInput) (conf config.Manager) {
 	conf.Set("pass", input.pass, "TESTING")
 	conf.Set("impersonate", input.impersonate, "TESTING")
 	conf.Set("2fa-otp", input.otp, "TESTING")
+	conf.Set("api-key", input.apiKey, "TESTING")
 	if input.yubikey != "" {
 		conf.Set("yubikey", "true", "TESTING")
 		conf.Set("yubikey-otp", input.yubikey, "INTERACTION")
@@ -247,6 +255,7 @@ func TestAuthenticate(t *testing.T) {
 		// Y - yubikey provided
 		// 2 - 2fa
 		// I - impersonation requested
+		// K - api key
 		name string
 
 		input authInput
@@ -1160,6 +1169,57 @@ func TestAuthenticate(t *testing.T) {
 				},
 			},
 			expectingError: true,
+		}, {
+			name: "K ok when api key valid",
+			input: authInput{
+				token:  "valid-token",
+				apiKey: "valid-api-key",
+			},
+			states: []authState{
+				{ // state 0
+					authWithTokenErr:       nil,
+					authWithCredentialsErr: unexpect{},
+					impersonateErr:         unexpect{},
+				}, {
+					user:                   "service-account",
+					factors:                []string{"apikey"},
+					authWithTokenErr:       unexpect{},
+					authWithCredentialsErr: unexpect{},
+					impersonateErr:         unexpect{},
+				},
+			},
+			expectingError: false,
+		}, {
+			name: "K expired api key doesn't prompt for credentials",
+			input: authInput{
+				apiKey: "expired-api-key",
+			},
+			states: []authState{
+				{ // state 0
+					authWithTokenErr:       lib.APIKeyExpiredError{Label: "ci", ExpiresAt: "2018-01-01T00:00:00+0000"},
+					authWithCredentialsErr: unexpect{},
+					impersonateErr:         unexpect{},
+				}, {
+					authWithTokenErr:       unexpect{},
+					authWithCredentialsErr: unexpect{},
+					impersonateErr:         unexpect{},
+				},
+			},
+			expectingError: true,
+		}, {
+			name: "KI impersonation with an api key is refused",
+			input: authInput{
+				apiKey:      "valid-api-key",
+				impersonate: "identity-theft-michael",
+			},
+			states: []authState{
+				{ // state 0
+					authWithTokenErr:       unexpect{},
+					authWithCredentialsErr: unexpect{},
+					impersonateErr:         unexpect{},
+				},
+			},
+			expectingError: true,
 		}, {
 			name: "GYI impersonation successful",
 			input: authInput{
@@ -1239,3 +1299,52 @@ func TestAuthenticate(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticateWhileReplaying(t *testing.T) {
+	_, client, _ := testutil.BaseTestSetup(t, false, []cli.Command{})
+	input := authInput{
+		user:  "input-user",
+		pass:  "input-pass",
+		token: "real-token",
+	}
+	stateCounter := 0
+	stubClientAuth(t, &stateCounter, client, input, []authState{
+		{
+			authWithTokenErr:       fmt.Errorf("recorded sessions can't be read"),
+			authWithCredentialsErr: unexpect{},
+			impersonateErr:         unexpect{},
+		}, {
+			authWithTokenErr:       unexpect{},
+			authWithCredentialsErr: nil,
+			impersonateErr:         unexpect{},
+		}, {
+			user:                   "input-user",
+			token:                  "REDACTED",
+			factors:                []string{"username", "password"},
+			authWithTokenErr:       unexpect{},
+			authWithCredentialsErr: unexpect{},
+			impersonateErr:         unexpect{},
+		},
+	})
+	config := setupAuthConfig(t, input)
+	defer os.RemoveAll(config.ConfigDir())
+	config.Set("replay", "cassette.json", "FLAG replay")
+
+	prompter := mocks.Prompter{}
+	err := Authenticator{
+		client:       client,
+		config:       config,
+		prompter:     &prompter,
+		passPrompter: &prompter,
+	}.Authenticate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := ioutil.ReadFile(filepath.Join(config.ConfigDir(), "token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(token) != "real-token" {
+		t.Errorf("expected the real token to be kept, but it was replaced with %q", token)
+	}
+}
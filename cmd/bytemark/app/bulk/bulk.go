@@ -0,0 +1,211 @@
+// Package bulk provides support for commands which can operate on many
+// servers at once - selected by name, or by group - running a bounded number
+// of operations in parallel and outputting a table of the results.
+package bulk
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util/filter"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/urfave/cli"
+)
+
+// DefaultParallelism is the default number of servers to operate on at once.
+const DefaultParallelism = 4
+
+// SelectorFlags are the flags used by Servers to select servers by group,
+// plus the --parallel flag used by Run.
+var SelectorFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "group",
+		Usage: "operate on every server in this group",
+	},
+	cli.StringFlag{
+		Name:  "filter",
+		Usage: "only operate on servers matching this expression, as in show servers - e.g. \"hostname ~ '^web'\"",
+	},
+	cli.IntFlag{
+		Name:  "parallel",
+		Usage: "how many servers to operate on at once",
+		Value: DefaultParallelism,
+	},
+}
+
+// Flags are SelectorFlags plus a --server flag which can be specified many
+// times. Use args.All("server") to let servers be specified as arguments too.
+var Flags = append([]cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "server",
+		Usage: "a server to operate on. May be specified multiple times",
+	},
+}, SelectorFlags...)
+
+// Operation is run on each server by Run. It returns a short message
+// describing what happened, or an error.
+type Operation func(c *app.Context, server pathers.VirtualMachineName) (string, error)
+
+// Servers returns all the servers selected by the --server, --group and
+// --filter flags, without duplicates. If no servers were selected an error is
+// returned.
+func Servers(c *app.Context) (servers []pathers.VirtualMachineName, err error) {
+	seen := map[pathers.VirtualMachineName]bool{}
+	// the servers from --group, so that --filter needn't fetch them again
+	fetched := map[pathers.VirtualMachineName]brain.VirtualMachine{}
+	add := func(name pathers.VirtualMachineName) {
+		if !seen[name] {
+			seen[name] = true
+			servers = append(servers, name)
+		}
+	}
+	// commands which only use SelectorFlags may have a --server flag of
+	// another type, so only look at it when it's been set.
+	if c.IsSet("server") {
+		for _, server := range c.StringSlice("server") {
+			name, err := lib.ParseVirtualMachineName(server, c.Config().GetVirtualMachine())
+			if err != nil {
+				return nil, err
+			}
+			add(name)
+		}
+	}
+
+	if group := c.String("group"); group != "" {
+		groupName := lib.ParseGroupName(group, c.Config().GetGroup())
+		err = c.Client().EnsureGroupName(&groupName)
+		if err != nil {
+			return nil, err
+		}
+		g, err := c.Client().GetGroup(groupName)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range g.VirtualMachines {
+			if !vm.Deleted {
+				name := pathers.VirtualMachineName{VirtualMachine: vm.Name, GroupName: groupName}
+				fetched[name] = vm
+				add(name)
+			}
+		}
+	}
+
+	servers, err = filterServers(c, servers, fetched)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		if c.String("group") != "" || c.String("filter") != "" {
+			return nil, fmt.Errorf("No servers matched --group and --filter")
+		}
+		return nil, c.Help("No servers were specified")
+	}
+	return servers, nil
+}
+
+// filterServers returns the servers which match the --filter expression,
+// which works the same as show servers' --filter. Servers which weren't
+// fetched as part of a --group are fetched so that they can be compared. If
+// --filter is blank, all the servers are returned.
+func filterServers(c *app.Context, servers []pathers.VirtualMachineName, fetched map[pathers.VirtualMachineName]brain.VirtualMachine) ([]pathers.VirtualMachineName, error) {
+	expr := c.String("filter")
+	if expr == "" {
+		return servers, nil
+	}
+	f, err := filter.Parse(expr)
+	if err == nil {
+		err = f.Validate(brain.VirtualMachine{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]pathers.VirtualMachineName, 0, len(servers))
+	for _, server := range servers {
+		vm, ok := fetched[server]
+		if !ok {
+			vm, err = c.Client().GetVirtualMachine(server)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ok, err = f.Matches(vm)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, server)
+		}
+	}
+	return matched, nil
+}
+
+// IsBulk returns true if the operation is on more than one server, or servers
+// were selected by --group - in other words, when Run will output a table of
+// results rather than just running the operation.
+func IsBulk(c *app.Context, servers []pathers.VirtualMachineName) bool {
+	return len(servers) != 1 || c.String("group") != ""
+}
+
+// Run runs op on every server.
+//
+// If IsBulk is false, op is simply run on the one server, its message logged
+// and its error returned. Otherwise op is run on up to --parallel servers at
+// a time, then a table of results is output and a
+// util.BulkOperationFailedError returned if op failed for any server.
+// Operations should not output anything themselves in bulk mode, since
+// they'll be running at the same time as each other.
+func Run(c *app.Context, servers []pathers.VirtualMachineName, op Operation) error {
+	if !IsBulk(c, servers) {
+		msg, err := op(c, servers[0])
+		if err != nil {
+			return err
+		}
+		c.Log("%s", msg)
+		return nil
+	}
+
+	parallel := c.Int("parallel")
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make(Results, len(servers))
+	jobs := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < parallel && w < len(servers); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runOne(c, servers[i], op)
+			}
+		}()
+	}
+	for i := range servers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	err := c.OutputInDesiredForm(results, output.Table)
+	if err != nil {
+		return err
+	}
+	if failed := results.Failed(); failed > 0 {
+		return util.BulkOperationFailedError{Failed: failed, Total: len(results)}
+	}
+	return nil
+}
+
+// runOne runs op on server, returning a Result.
+func runOne(c *app.Context, server pathers.VirtualMachineName, op Operation) Result {
+	msg, err := op(c, server)
+	if err != nil {
+		return Result{Server: server.String(), Message: err.Error()}
+	}
+	return Result{Server: server.String(), Success: true, Message: msg}
+}
@@ -0,0 +1,56 @@
+package bulk
+
+import (
+	"io"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+)
+
+// Result is the outcome of running an Operation on a single server.
+type Result struct {
+	Server  string `json:"server"`
+	Success bool   `json:"success"`
+	// Message is the message returned by the Operation, or its error.
+	Message string `json:"message,omitempty"`
+}
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (r Result) DefaultFields(f output.Format) string {
+	return "Server, Success, Message"
+}
+
+// PrettyPrint outputs the result on a single line.
+func (r Result) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "result_sgl" }}{{ if .Success }}✔{{ else }}✘{{ end }} {{ .Server }}: {{ .Message }}{{ end }}
+{{ define "result_medium" }}{{ template "result_sgl" . }}{{ end }}
+{{ define "result_full" }}{{ template "result_sgl" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "result"+string(detail), r)
+}
+
+// Results is the outcome of running an Operation on many servers.
+type Results []Result
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as Result.DefaultFields.
+func (rs Results) DefaultFields(f output.Format) string {
+	return (Result{}).DefaultFields(f)
+}
+
+// PrettyPrint outputs each result on its own line.
+func (rs Results) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "results_sgl" }}{{ len . }} servers{{ end }}
+{{ define "results_medium" }}{{ range . }}{{ prettysprint . "_sgl" }}
+{{ end }}{{ end }}
+{{ define "results_full" }}{{ template "results_medium" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "results"+string(detail), rs)
+}
+
+// Failed returns how many of the results weren't successful.
+func (rs Results) Failed() (failed int) {
+	for _, r := range rs {
+		if !r.Success {
+			failed++
+		}
+	}
+	return
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"io"
+	"time"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/config"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
@@ -127,6 +128,11 @@ func (ctx *Context) Bool(flagname string) bool {
 	return ctx.Context.Bool(flagname)
 }
 
+// Duration returns the value of the named flag as a time.Duration
+func (ctx *Context) Duration(flagname string) time.Duration {
+	return ctx.Context.Duration(flagname)
+}
+
 // Int returns the value of the named flag as an int
 func (ctx *Context) Int(flagname string) int {
 	return ctx.Context.Int(flagname)
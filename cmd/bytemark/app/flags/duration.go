@@ -0,0 +1,55 @@
+package flags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+)
+
+// DurationFlag is a time.Duration which, as well as everything
+// time.ParseDuration accepts, can be given in days or weeks - e.g. 30d or 2w.
+type DurationFlag time.Duration
+
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// Set parses the value as a duration
+func (df *DurationFlag) Set(value string) error {
+	for suffix, unit := range durationUnits {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(value, suffix), 64)
+			if err != nil {
+				return fmt.Errorf("%q isn't a valid duration - try something like 30d, 2w or 12h", value)
+			}
+			*df = DurationFlag(n * float64(unit))
+			return nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%q isn't a valid duration - try something like 30d, 2w or 12h", value)
+	}
+	*df = DurationFlag(d)
+	return nil
+}
+
+func (df *DurationFlag) String() string {
+	if *df == 0 {
+		return ""
+	}
+	return time.Duration(*df).String()
+}
+
+// Duration returns the value of the named DurationFlag
+func Duration(c *app.Context, flagname string) time.Duration {
+	df, ok := c.Context.Generic(flagname).(*DurationFlag)
+	if !ok || df == nil {
+		return 0
+	}
+	return time.Duration(*df)
+}
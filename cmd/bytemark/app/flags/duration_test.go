@@ -0,0 +1,38 @@
+package flags_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+)
+
+func TestDurationFlag(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  time.Duration
+		shouldErr bool
+	}{
+		{input: "30d", expected: 30 * 24 * time.Hour},
+		{input: "2w", expected: 14 * 24 * time.Hour},
+		{input: "1.5d", expected: 36 * time.Hour},
+		{input: "12h", expected: 12 * time.Hour},
+		{input: "90m", expected: 90 * time.Minute},
+		{input: "d", shouldErr: true},
+		{input: "soon", shouldErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			var df flags.DurationFlag
+			err := df.Set(test.input)
+			if err != nil && !test.shouldErr {
+				t.Errorf("Unexpected error: %v", err)
+			} else if err == nil && test.shouldErr {
+				t.Error("Error expected but not returned")
+			}
+			if time.Duration(df) != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, time.Duration(df))
+			}
+		})
+	}
+}
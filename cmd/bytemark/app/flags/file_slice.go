@@ -0,0 +1,40 @@
+package flags
+
+import (
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+)
+
+// FileSliceFlag is used for FileFlags that may be specified more than once.
+// The files are kept in the order they were given.
+type FileSliceFlag []FileFlag
+
+// Set reads the named file and appends it to the slice
+func (sf *FileSliceFlag) Set(name string) error {
+	flag := FileFlag{}
+	err := flag.Set(name)
+	if err != nil {
+		return err
+	}
+	*sf = append(*sf, flag)
+	return nil
+}
+
+// String returns the names of all the files in the slice, comma-delimited
+func (sf *FileSliceFlag) String() string {
+	names := make([]string, len(*sf))
+	for i, file := range *sf {
+		names[i] = file.FileName
+	}
+	return strings.Join(names, ", ")
+}
+
+// FileSlice returns the named flag as a FileSliceFlag, if it was one in the
+// first place.
+func FileSlice(c *app.Context, flagname string) FileSliceFlag {
+	if sf, ok := c.Context.Generic(flagname).(*FileSliceFlag); ok {
+		return *sf
+	}
+	return FileSliceFlag{}
+}
@@ -0,0 +1,45 @@
+package flags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+)
+
+// KeyValueFlag collects key=value pairs from a flag which may be specified
+// more than once. Setting the same key again replaces its value.
+type KeyValueFlag map[string]string
+
+// Set parses value as key=value and adds it to the map
+func (kvf *KeyValueFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("%q should be of the form key=value", value)
+	}
+	if *kvf == nil {
+		*kvf = KeyValueFlag{}
+	}
+	(*kvf)[parts[0]] = parts[1]
+	return nil
+}
+
+// String returns all the pairs as key=value, sorted by key and comma-delimited
+func (kvf *KeyValueFlag) String() string {
+	pairs := make([]string, 0, len(*kvf))
+	for key, value := range *kvf {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// KeyValues returns the value of the named KeyValueFlag. The map is never nil.
+func KeyValues(c *app.Context, flagname string) map[string]string {
+	kvf, ok := c.Context.Generic(flagname).(*KeyValueFlag)
+	if !ok || kvf == nil || *kvf == nil {
+		return map[string]string{}
+	}
+	return *kvf
+}
@@ -0,0 +1,69 @@
+package flags_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+)
+
+func TestKeyValueFlag(t *testing.T) {
+	tests := []struct {
+		name      string
+		inputs    []string
+		expected  flags.KeyValueFlag
+		str       string
+		shouldErr bool
+	}{
+		{
+			name:     "several",
+			inputs:   []string{"role=web", "env=production"},
+			expected: flags.KeyValueFlag{"role": "web", "env": "production"},
+			str:      "env=production, role=web",
+		}, {
+			name:     "value with equals",
+			inputs:   []string{"opts=a=b"},
+			expected: flags.KeyValueFlag{"opts": "a=b"},
+			str:      "opts=a=b",
+		}, {
+			name:     "replaced",
+			inputs:   []string{"role=web", "role=db", "empty="},
+			expected: flags.KeyValueFlag{"role": "db", "empty": ""},
+			str:      "empty=, role=db",
+		}, {
+			name:      "no equals",
+			inputs:    []string{"role"},
+			shouldErr: true,
+		}, {
+			name:      "no key",
+			inputs:    []string{"=web"},
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var kvf flags.KeyValueFlag
+			var err error
+			for _, input := range test.inputs {
+				if err = kvf.Set(input); err != nil {
+					break
+				}
+			}
+			if test.shouldErr {
+				if err == nil {
+					t.Error("Error expected but not returned")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(kvf, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, kvf)
+			}
+			if kvf.String() != test.str {
+				t.Errorf("expected String() to be %q, got %q", test.str, kvf.String())
+			}
+		})
+	}
+}
@@ -106,6 +106,84 @@ func PrepareServerSpec(c *app.Context, authentication bool) (spec brain.VirtualM
 	return
 }
 
+// OverrideServerSpec lays the parts of spec which were set by flags over
+// base, for commands which start from an existing spec (e.g. a VM default)
+// and let flags change individual parts of it. spec should have come from
+// PrepareServerSpec.
+func OverrideServerSpec(c *app.Context, base brain.VirtualMachineSpec, spec brain.VirtualMachineSpec) (brain.VirtualMachineSpec, error) {
+	vm := &spec.VirtualMachine
+	baseVM := base.VirtualMachine
+	if !c.IsSet("cores") && baseVM.Cores != 0 {
+		vm.Cores = baseVM.Cores
+	}
+	if !c.IsSet("memory") && baseVM.Memory != 0 {
+		vm.Memory = baseVM.Memory
+	}
+	if !c.IsSet("zone") {
+		vm.ZoneName = baseVM.ZoneName
+	}
+	if !c.IsSet("hwprofile") {
+		vm.HardwareProfile = baseVM.HardwareProfile
+	}
+	if !c.IsSet("hwprofile-locked") {
+		vm.HardwareProfileLocked = baseVM.HardwareProfileLocked
+	}
+	if !c.IsSet("cdrom") {
+		vm.CdromURL = baseVM.CdromURL
+	}
+
+	if !c.IsSet("disc") && len(base.Discs) > 0 {
+		discs, err := overrideDiscs(c, base.Discs)
+		if err != nil {
+			return spec, err
+		}
+		spec.Discs = discs
+	}
+
+	if spec.Reimage != nil && base.Reimage != nil {
+		if !c.IsSet("image") && base.Reimage.Distribution != "" {
+			spec.Reimage.Distribution = base.Reimage.Distribution
+		}
+		if !c.IsSet("firstboot-script") && !c.IsSet("firstboot-script-file") {
+			spec.Reimage.FirstbootScript = base.Reimage.FirstbootScript
+		}
+	}
+
+	vm.Autoreboot = !c.Bool("stopped") && (spec.Reimage != nil || vm.CdromURL != "")
+	return spec, nil
+}
+
+// overrideDiscs copies base, giving any backup schedules without a start date
+// the default one, and replacing the first disc's backup schedules if --backup
+// was set.
+func overrideDiscs(c *app.Context, base []brain.Disc) ([]brain.Disc, error) {
+	discs := make([]brain.Disc, len(base))
+	copy(discs, base)
+	for i := range discs {
+		var schedules brain.BackupSchedules
+		for _, bs := range discs[i].BackupSchedules {
+			if bs.StartDate == "" {
+				bs.StartDate = defaultBackupSchedule().StartDate
+			}
+			schedules = append(schedules, bs)
+		}
+		discs[i].BackupSchedules = schedules
+	}
+	if c.IsSet("backup") {
+		interval, err := BackupScheduleIntervalFromWords(c.String("backup"))
+		if err != nil {
+			return discs, err
+		}
+		discs[0].BackupSchedules = nil
+		if interval > 0 {
+			bs := defaultBackupSchedule()
+			bs.Interval = interval
+			discs[0].BackupSchedules = brain.BackupSchedules{bs}
+		}
+	}
+	return discs, nil
+}
+
 // prepareDiscs checks to see if discs are valid and sets up a backup schedule (if any).
 func prepareDiscs(backupFrequency string, discs []brain.Disc) ([]brain.Disc, error) {
 	if len(discs) == 0 {
@@ -0,0 +1,109 @@
+package flagsets
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/urfave/cli"
+)
+
+// FirstbootTemplateFlags are for commands which image a particular server, and
+// so can fill in details of the server in the firstboot script. They go
+// alongside ImageInstallFlags, and are read by RenderFirstbootScript.
+var FirstbootTemplateFlags = []cli.Flag{
+	cli.GenericFlag{
+		Name:  "firstboot-template",
+		Usage: "Local file containing a template for part of the firstboot script. Can be specified multiple times - the parts are run in order, after --firstboot-script",
+		Value: new(flags.FileSliceFlag),
+	},
+	cli.GenericFlag{
+		Name:  "var",
+		Usage: "A key=value pair for --firstboot-template, which can use the value as {{ .Vars.key }}. Can be specified multiple times",
+		Value: new(flags.KeyValueFlag),
+	},
+}
+
+// FirstbootTemplateData is what --firstboot-template templates are executed
+// with. Vars is filled in from --var by RenderFirstbootScript.
+type FirstbootTemplateData struct {
+	Name     string
+	Group    string
+	Account  string
+	Hostname string
+	Zone     string
+	Vars     map[string]string
+}
+
+// NewFirstbootTemplateData makes a FirstbootTemplateData for the named
+// server. name should be complete - i.e. have its group and account set.
+func NewFirstbootTemplateData(name pathers.VirtualMachineName, hostname string, zone string) FirstbootTemplateData {
+	return FirstbootTemplateData{
+		Name:     name.VirtualMachine,
+		Group:    name.Group,
+		Account:  string(name.Account),
+		Hostname: hostname,
+		Zone:     zone,
+	}
+}
+
+// NewServerFirstbootTemplateData returns a getData for RenderFirstbootScript
+// for a server which is about to be created. The brain hasn't given the server
+// a hostname yet, so it's worked out from the name and the endpoint in the
+// same way the brain will.
+func NewServerFirstbootTemplateData(c *app.Context, name pathers.VirtualMachineName, zone string) func() (FirstbootTemplateData, error) {
+	return func() (FirstbootTemplateData, error) {
+		hostname := fmt.Sprintf("%s.%s", name, c.Config().EndpointName())
+		return NewFirstbootTemplateData(name, hostname, zone), nil
+	}
+}
+
+// RenderFirstbootScript appends each --firstboot-template to image's
+// firstboot script in turn, then checks that the script isn't too long for
+// the brain. getData is only called if there are templates to execute, so
+// that nothing has to be looked up for servers which don't use them.
+func RenderFirstbootScript(c *app.Context, image *brain.ImageInstall, getData func() (FirstbootTemplateData, error)) error {
+	templates := flags.FileSlice(c, "firstboot-template")
+	if len(templates) > 0 {
+		data, err := getData()
+		if err != nil {
+			return err
+		}
+		data.Vars = flags.KeyValues(c, "var")
+
+		fragments := []string{}
+		if image.FirstbootScript != "" {
+			fragments = append(fragments, image.FirstbootScript)
+		}
+		for _, file := range templates {
+			tmpl, err := template.New(file.FileName).Option("missingkey=error").Parse(file.Value)
+			if err != nil {
+				return err
+			}
+			buf := bytes.Buffer{}
+			err = tmpl.Execute(&buf, data)
+			if err != nil {
+				return err
+			}
+			fragments = append(fragments, buf.String())
+		}
+		script := ""
+		for _, fragment := range fragments {
+			if !strings.HasSuffix(fragment, "\n") {
+				fragment += "\n"
+			}
+			script += fragment
+		}
+		image.FirstbootScript = script
+	}
+
+	if len(image.FirstbootScript) > brain.FirstbootScriptMaxLength {
+		return fmt.Errorf("The firstboot script is %d bytes long, but the longest the brain accepts is %d bytes", len(image.FirstbootScript), brain.FirstbootScriptMaxLength)
+	}
+	return nil
+}
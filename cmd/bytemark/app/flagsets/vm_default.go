@@ -0,0 +1,65 @@
+package flagsets
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/urfave/cli"
+)
+
+// VMDefaultFlags are the flags common to the commands which add VM defaults,
+// alongside ServerSpecFlags and ImageInstallFlags.
+var VMDefaultFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "default-name",
+		Usage: "The name of the VM default to add",
+	},
+	cli.BoolFlag{
+		Name:  "public",
+		Usage: "If the VM default should be made public or not",
+	},
+	cli.GenericFlag{
+		Name:  "from-server",
+		Usage: "Start from the spec of an existing server. Other flags override parts of it",
+		Value: new(flags.VirtualMachineNameFlag),
+	},
+}
+
+// PrepareVMDefaultSpec makes the server settings for a VM default from the
+// flags, leaving out anything which wasn't set so that the panel can fill in
+// its own defaults. If --from-server was set, the server's spec is used as a
+// starting point, and the flags override parts of it.
+func PrepareVMDefaultSpec(c *app.Context) (spec brain.VirtualMachineSpec, err error) {
+	spec, err = PrepareServerSpec(c, false)
+	if err != nil {
+		return
+	}
+
+	// time to unset some stuff that gets auto-set, if we didn't actually specify anything
+	if !c.IsSet("disc") {
+		spec.Discs = nil
+	}
+	if !c.IsSet("image") && spec.Reimage != nil {
+		spec.Reimage.Distribution = ""
+	}
+	if !c.IsSet("cores") {
+		spec.VirtualMachine.Cores = 0
+	}
+	if !c.IsSet("memory") {
+		spec.VirtualMachine.Memory = 0
+	}
+
+	if c.IsSet("from-server") {
+		var vm brain.VirtualMachine
+		vm, err = c.Client().GetVirtualMachine(flags.VirtualMachineName(c, "from-server"))
+		if err != nil {
+			return
+		}
+		spec, err = OverrideServerSpec(c, vm.Spec(), spec)
+		if err != nil {
+			return
+		}
+	}
+	spec.VirtualMachine.Autoreboot = false
+	return
+}
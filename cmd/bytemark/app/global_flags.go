@@ -13,6 +13,10 @@ func GlobalFlags() (flags []cli.Flag) {
 			Name:  "api-endpoint",
 			Usage: "URL where the domains service can be found. Set to blank in environments without a domains service.",
 		},
+		cli.StringFlag{
+			Name:  "api-key",
+			Usage: "an API key to authenticate with, instead of a session token. see bytemark login --help",
+		},
 		cli.StringFlag{
 			Name:  "auth-endpoint",
 			Usage: "URL where the auth service can be found",
@@ -39,7 +43,7 @@ func GlobalFlags() (flags []cli.Flag) {
 		},
 		cli.StringFlag{
 			Name:  "config-dir",
-			Usage: "directory in which bytemark-client's configuration resides. see bytemark help config, bytemark help profiles",
+			Usage: "directory in which bytemark-client's configuration resides. see bytemark help config",
 		},
 		cli.IntFlag{
 			Name:  "debug-level",
@@ -53,12 +57,32 @@ func GlobalFlags() (flags []cli.Flag) {
 			Name:  "output-format",
 			Usage: "The output format to use. Currently defined output formats are human (default for most commands), json (machine readable format), table (human-readable table format)",
 		},
+		cli.StringFlag{
+			Name:  "profile",
+			Usage: "the configuration profile to use. Overrides BYTEMARK_PROFILE and the profile set with bytemark config use-profile. see bytemark help profiles",
+		},
+		cli.StringFlag{
+			Name:  "record",
+			Usage: "record every request made to the APIs, and their responses, to the given cassette file. Authorization headers and other secrets are redacted",
+		},
+		cli.StringFlag{
+			Name:  "replay",
+			Usage: "replay responses from a cassette file made with --record instead of talking to the APIs",
+		},
+		cli.IntFlag{
+			Name:  "retries",
+			Usage: "how many times to retry requests which fail due to network or gateway errors (only applies to requests which only read data)",
+		},
 		cli.IntFlag{
 			Name:  "session-validity",
 			Usage: "seconds until your session is automatically invalidated (max 3600)",
 			Value: config.DefaultSessionValidity,
 			// TODO(telyn): add more defaults to these flags
 		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "how long to wait for each request to the API before giving up, e.g. 30s or 2m. 0 means wait forever. To limit how long a --wait or 'bytemark wait' takes, use --wait-timeout instead",
+		},
 		cli.StringFlag{
 			Name:  "user",
 			Usage: "user you wish to log in as",
@@ -0,0 +1,140 @@
+package wait
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+)
+
+// VMMatches is met once the named server satisfies matches. description
+// should say what's being waited for, such as "test-server to power on".
+func VMMatches(name pathers.VirtualMachineName, description string, matches func(vm brain.VirtualMachine) bool) Condition {
+	return Condition{
+		Description: description,
+		Done: func(client lib.Client) (bool, error) {
+			vm, err := client.GetVirtualMachine(name)
+			if err != nil {
+				return false, err
+			}
+			return matches(vm), nil
+		},
+	}
+}
+
+// VMPoweredOn is met once the named server is powered on.
+func VMPoweredOn(name pathers.VirtualMachineName) Condition {
+	return VMMatches(name, fmt.Sprintf("%s to power on", name), func(vm brain.VirtualMachine) bool {
+		return vm.PowerOn
+	})
+}
+
+// VMPoweredOff is met once the named server is powered off.
+func VMPoweredOff(name pathers.VirtualMachineName) Condition {
+	return VMMatches(name, fmt.Sprintf("%s to power off", name), func(vm brain.VirtualMachine) bool {
+		return !vm.PowerOn
+	})
+}
+
+// VMDeleted is met once the named server has been deleted, or purged
+// entirely.
+func VMDeleted(name pathers.VirtualMachineName) Condition {
+	return Condition{
+		Description: fmt.Sprintf("%s to be deleted", name),
+		Done: func(client lib.Client) (bool, error) {
+			vm, err := client.GetVirtualMachine(name)
+			if _, ok := err.(lib.NotFoundError); ok {
+				return true, nil
+			}
+			return vm.Deleted, err
+		},
+	}
+}
+
+// VMImaged is met once the named server has been imaged and is powered on.
+// A server which was imaged and running before the wait began counts too.
+func VMImaged(name pathers.VirtualMachineName) Condition {
+	return VMImagedWith(name, "")
+}
+
+// VMImagedWith is like VMImaged, but is only met once the server was last
+// imaged with the given distribution. If distribution is blank, any will do.
+func VMImagedWith(name pathers.VirtualMachineName, distribution string) Condition {
+	return VMMatches(name, fmt.Sprintf("%s to be imaged", name), func(vm brain.VirtualMachine) bool {
+		return imagedWith(vm, distribution)
+	})
+}
+
+// VMReimagedWith is like VMImagedWith, but for a server which has just been
+// asked to reimage. Servers are powered off while they're being imaged, so
+// the condition isn't met until the server has been seen powered off and then
+// powered on again - otherwise a server which was running before it was
+// reimaged would count.
+func VMReimagedWith(name pathers.VirtualMachineName, distribution string) Condition {
+	poweredOff := false
+	return VMMatches(name, fmt.Sprintf("%s to be reimaged", name), func(vm brain.VirtualMachine) bool {
+		if !vm.PowerOn {
+			poweredOff = true
+		}
+		return poweredOff && imagedWith(vm, distribution)
+	})
+}
+
+// imagedWith returns true if vm is powered on and was last imaged with
+// distribution, or with anything if distribution is blank.
+func imagedWith(vm brain.VirtualMachine, distribution string) bool {
+	if !vm.PowerOn || vm.LastImagedWith == "" {
+		return false
+	}
+	return distribution == "" || vm.LastImagedWith == distribution
+}
+
+// DiscMigrated is met once the given disc on the named server isn't being
+// migrated to a new storage pool or storage grade.
+func DiscMigrated(server pathers.VirtualMachineName, discLabelOrID string) Condition {
+	return Condition{
+		Description: fmt.Sprintf("disc %s on %s to finish migrating", discLabelOrID, server),
+		Done: func(client lib.Client) (bool, error) {
+			disc, err := client.GetDisc(server, discLabelOrID)
+			if err != nil {
+				return false, err
+			}
+			return disc.NewStoragePool == "" && disc.NewStorageGrade == "", nil
+		},
+	}
+}
+
+// MigrationJobFinished is met once the migration job with the given ID has
+// finished - whether or not all its migrations succeeded.
+func MigrationJobFinished(id int) Condition {
+	return Condition{
+		Description: fmt.Sprintf("migration job %d to finish", id),
+		Done: func(client lib.Client) (bool, error) {
+			job, err := brainRequests.GetMigrationJob(client, id)
+			return job.FinishedAt != "", err
+		},
+	}
+}
+
+// BackupCreated is met once the given backup of the given disc exists and has
+// finished being moved to cold storage.
+func BackupCreated(server pathers.VirtualMachineName, discLabelOrID string, backupLabelOrID string) Condition {
+	return Condition{
+		Description: fmt.Sprintf("backup %s of disc %s on %s to be created", backupLabelOrID, discLabelOrID, server),
+		Done: func(client lib.Client) (bool, error) {
+			backups, err := client.GetBackups(server, discLabelOrID)
+			if err != nil {
+				return false, err
+			}
+			for _, backup := range backups {
+				if backup.Label == backupLabelOrID || strconv.Itoa(backup.ID) == backupLabelOrID {
+					return backup.OnColdStorage(), nil
+				}
+			}
+			return false, nil
+		},
+	}
+}
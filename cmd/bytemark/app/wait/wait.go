@@ -0,0 +1,161 @@
+// Package wait provides functions for waiting until something has happened
+// on the brain - such as a server powering off or a disc finishing its
+// migration - by polling it until it has.
+package wait
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/urfave/cli"
+)
+
+// Condition is something that can be waited for.
+type Condition struct {
+	// Description describes what is being waited for, such as "test-server
+	// to power on". It's used in the error returned when For times out.
+	Description string
+	// Done polls the brain, returning true once the condition has been met.
+	Done func(client lib.Client) (bool, error)
+}
+
+// Options determine how often and for how long For polls.
+type Options struct {
+	// Timeout is how long to wait before giving up. 0 means wait forever.
+	Timeout time.Duration
+	// Interval is how long to wait between the first and second polls.
+	Interval time.Duration
+	// MaxInterval is the longest to wait between polls. The interval
+	// doubles after each poll until it reaches MaxInterval.
+	MaxInterval time.Duration
+	// Quiet stops For from outputting progress dots.
+	Quiet bool
+}
+
+// DefaultOptions returns the Options used when none are specified - poll
+// every 5 seconds at first, backing off to every 30 seconds, forever.
+func DefaultOptions() Options {
+	return Options{
+		Interval:    5 * time.Second,
+		MaxInterval: 30 * time.Second,
+	}
+}
+
+// Flags are the flags used by OptionsFromFlags, to be added to any command
+// which waits for something.
+var Flags = []cli.Flag{
+	cli.DurationFlag{
+		Name:  "wait-timeout",
+		Usage: "how long to wait before giving up, e.g. 10m. Defaults to waiting forever. (Not --timeout, which is the global flag for how long each request to the API may take.)",
+	},
+	cli.DurationFlag{
+		Name:  "poll-interval",
+		Usage: "how long to wait between the first two checks. The interval doubles after every check, up to --max-poll-interval",
+		Value: DefaultOptions().Interval,
+	},
+	cli.DurationFlag{
+		Name:  "max-poll-interval",
+		Usage: "the longest to wait between checks",
+		Value: DefaultOptions().MaxInterval,
+	},
+}
+
+// OptionalFlags are Flags plus a --wait flag, for commands which only wait
+// for their change to take effect if asked to - see IfRequested.
+var OptionalFlags = append([]cli.Flag{
+	cli.BoolFlag{
+		Name:  "wait",
+		Usage: "wait for the change to take effect before exiting",
+	},
+}, Flags...)
+
+// OptionsFromFlags reads Options from the flags in Flags.
+func OptionsFromFlags(c *app.Context) Options {
+	return Options{
+		Timeout:     c.Duration("wait-timeout"),
+		Interval:    c.Duration("poll-interval"),
+		MaxInterval: c.Duration("max-poll-interval"),
+	}
+}
+
+// IfRequested waits for cond using OptionsFromFlags if the --wait flag from
+// OptionalFlags was specified, and returns nil immediately otherwise.
+func IfRequested(c *app.Context, cond Condition) error {
+	if !c.Bool("wait") {
+		return nil
+	}
+	_, _ = fmt.Fprintf(progressWriter(c), "Waiting for %s\n", cond.Description)
+	return For(c, OptionsFromFlags(c), cond)
+}
+
+// nextInterval returns the interval to wait after waiting interval.
+func (opts Options) nextInterval(interval time.Duration) time.Duration {
+	interval *= 2
+	if interval > opts.MaxInterval {
+		return opts.MaxInterval
+	}
+	return interval
+}
+
+// For polls the brain until cond has been met, returning nil once it has. If
+// opts.Timeout passes first, a util.TimedOutError is returned. If any calls
+// fail, the error is returned. A dot is output to the app's ErrWriter after
+// every unsuccessful poll to show that something is happening.
+func For(c *app.Context, opts Options, cond Condition) error {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultOptions().Interval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+	wr := progressWriter(c)
+	if opts.Quiet {
+		wr = ioutil.Discard
+	}
+	start := time.Now()
+	interval := opts.Interval
+	polls := 0
+	defer func() {
+		if polls > 0 {
+			_, _ = fmt.Fprintln(wr)
+		}
+	}()
+
+	for {
+		done, err := cond.Done(c.Client())
+		if err != nil || done {
+			return err
+		}
+		polls++
+		_, _ = fmt.Fprint(wr, ".")
+
+		sleep := interval
+		if opts.Timeout > 0 {
+			remaining := opts.Timeout - time.Since(start)
+			if remaining <= 0 {
+				return util.TimedOutError{What: cond.Description, Timeout: opts.Timeout}
+			}
+			if remaining < sleep {
+				sleep = remaining
+			}
+		}
+		if !c.IsTest() {
+			time.Sleep(sleep)
+		}
+		interval = opts.nextInterval(interval)
+	}
+}
+
+// progressWriter returns the writer to output progress dots to
+func progressWriter(c *app.Context) io.Writer {
+	if wr := c.ErrWriter(); wr != nil {
+		return wr
+	}
+	return os.Stderr
+}
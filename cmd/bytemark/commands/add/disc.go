@@ -1,11 +1,18 @@
 package add
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/BytemarkHosting/bytemark-client/util/log"
 	"github.com/urfave/cli"
 )
@@ -21,6 +28,10 @@ func init() {
 				Value: new(flags.DiscSpecFlag),
 			},
 			flagsets.Force,
+			cli.StringFlag{
+				Name:  "from-backup",
+				Usage: "a backup to copy into the new disc, as server:disc:backup",
+			},
 			cli.GenericFlag{
 				Name:  "server",
 				Usage: "the server to add the disc to",
@@ -28,11 +39,13 @@ func init() {
 			},
 		},
 		Usage:     "add virtual discs attached to one of your cloud servers",
-		UsageText: "add discs [--disc <disc spec>]... <cloud server>",
+		UsageText: "add discs [--disc <disc spec>]... [--from-backup <server:disc:backup>] <cloud server>",
 		Description: `A disc spec looks like the following: label:grade:size
 The label and grade fields are optional. If grade is empty, defaults to sata.
 If there are two fields, they are assumed to be grade and size.
-Multiple --disc flags can be used to add multiple discs`,
+Multiple --disc flags can be used to add multiple discs
+
+If --from-backup is set then a single disc is added, which starts out as a copy of the given backup. The backup can be of a disc on any server in the same account, and is specified by the server, the disc's label and the backup's label or ID - for example web1:disc-1:backup-42. The backups of a disc can be seen with 'bytemark show backups'. The disc is the same size as the backup unless a bigger size is given with --disc.`,
 		Action: app.Action(args.Optional("server", "cores", "memory", "disc"), with.Auth, createDiscs),
 	})
 }
@@ -40,6 +53,12 @@ Multiple --disc flags can be used to add multiple discs`,
 // createDiscs adds the disc(s) to the speicified server
 func createDiscs(c *app.Context) (err error) {
 	discs := flags.Discs(c, "disc")
+	if c.IsSet("from-backup") {
+		discs, err = discFromBackup(c, discs)
+		if err != nil {
+			return err
+		}
+	}
 
 	for i := range discs {
 		d, err := discs[i].Validate()
@@ -62,3 +81,73 @@ func createDiscs(c *app.Context) (err error) {
 	}
 	return
 }
+
+// discFromBackup returns the disc to add for --from-backup - the one disc in
+// discs, or a blank one if there are none - set to be copied from the backup.
+func discFromBackup(c *app.Context, discs []brain.Disc) ([]brain.Disc, error) {
+	if len(discs) > 1 {
+		return nil, fmt.Errorf("--from-backup can only be used to add one disc at a time")
+	}
+	disc := brain.Disc{}
+	if len(discs) == 1 {
+		disc = discs[0]
+	}
+	server, discLabel, backupLabelOrID, err := parseBackupPath(c, c.String("from-backup"))
+	if err != nil {
+		return nil, err
+	}
+	err = c.Client().EnsureVirtualMachineName(&server)
+	if err != nil {
+		return nil, err
+	}
+	vmName := flags.VirtualMachineName(c, "server")
+	err = c.Client().EnsureVirtualMachineName(&vmName)
+	if err != nil {
+		return nil, err
+	}
+	if server.Account != vmName.Account {
+		return nil, fmt.Errorf("Backups can only be copied to servers in the same account - %s isn't in %s", vmName, server.Account)
+	}
+
+	backups, err := c.Client().GetBackups(server, discLabel)
+	if err != nil {
+		return nil, err
+	}
+	backup := findBackup(backups, backupLabelOrID)
+	if backup == nil {
+		return nil, fmt.Errorf("%s has no backup of %s called %s", server, discLabel, backupLabelOrID)
+	}
+	if !backup.OnColdStorage() {
+		return nil, fmt.Errorf("%s is still being taken - try again once it has finished", backup.Label)
+	}
+	if disc.Size == 0 {
+		disc.Size = backup.Size
+	} else if disc.Size < backup.Size {
+		return nil, fmt.Errorf("The disc can't be smaller than the backup, which is %dGiB", backup.Size/1024)
+	}
+	disc.BackupID = backup.ID
+	return []brain.Disc{disc}, nil
+}
+
+// parseBackupPath splits a --from-backup of the form server:disc:backup.
+func parseBackupPath(c *app.Context, path string) (server pathers.VirtualMachineName, disc string, backup string, err error) {
+	bits := strings.Split(path, ":")
+	if len(bits) != 3 || bits[1] == "" || bits[2] == "" {
+		err = fmt.Errorf("--from-backup should be server:disc:backup, not %q", path)
+		return
+	}
+	server, err = lib.ParseVirtualMachineName(bits[0], c.Config().GetVirtualMachine())
+	return server, bits[1], bits[2], err
+}
+
+// findBackup returns the backup in backups with the given label or ID, or
+// nil if there isn't one.
+func findBackup(backups brain.Backups, labelOrID string) *brain.Backup {
+	id, _ := strconv.Atoi(labelOrID)
+	for i, backup := range backups {
+		if backup.Label == labelOrID || (id != 0 && backup.ID == id) {
+			return &backups[i]
+		}
+	}
+	return nil
+}
@@ -31,3 +31,70 @@ func TestCreateDiskCommand(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCreateDiscFromBackup(t *testing.T) {
+	name := pathers.VirtualMachineName{VirtualMachine: "db2", GroupName: testutil.DefGroup}
+	srcName := pathers.VirtualMachineName{VirtualMachine: "db1", GroupName: pathers.GroupName{Group: "live", Account: "default-account"}}
+	backups := brain.Backups{
+		{Disc: brain.Disc{ID: 50, Label: "backup-50", Size: 25600, StorageGrade: brain.ColdStorageGrade}},
+		{Disc: brain.Disc{ID: 51, Label: "backup-51", Size: 25600, StorageGrade: "sata"}},
+	}
+
+	tests := []struct {
+		name      string
+		args      string
+		disc      brain.Disc
+		shouldErr bool
+	}{
+		{
+			name: "by label",
+			args: "--from-backup db1.live:data:backup-50 db2",
+			disc: brain.Disc{Size: 25600, StorageGrade: "sata", BackupID: 50},
+		}, {
+			name: "by ID with a disc spec",
+			args: "--from-backup db1.live:data:50 --disc copy:ssd:50 db2",
+			disc: brain.Disc{Label: "copy", Size: 51200, StorageGrade: "ssd", BackupID: 50},
+		}, {
+			name:      "smaller than the backup",
+			args:      "--from-backup db1.live:data:50 --disc ssd:10 db2",
+			shouldErr: true,
+		}, {
+			name:      "in progress",
+			args:      "--from-backup db1.live:data:backup-51 db2",
+			shouldErr: true,
+		}, {
+			name:      "no such backup",
+			args:      "--from-backup db1.live:data:backup-52 db2",
+			shouldErr: true,
+		}, {
+			name:      "another account",
+			args:      "--from-backup db1.live.other-account:data:50 db2",
+			shouldErr: true,
+		}, {
+			name:      "malformed",
+			args:      "--from-backup db1.live:50 db2",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			c.When("GetBackups", srcName, "data").Return(backups, nil)
+			if !test.shouldErr {
+				c.When("CreateDisc", name, test.disc).Return(nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark add disc --force "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package add
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "firewall rule",
+		Aliases:   []string{"firewall-rule"},
+		Usage:     "allow incoming traffic to a group or server",
+		UsageText: "add firewall rule (--group <group> | --server <server>) [--protocol tcp|udp|icmp] [--port <port[-port]>] [--source <cidr>] [--label <label>]",
+		Description: `Adds a rule which allows incoming traffic to every server in a group, or to a single server. Once a server has any firewall rules - its own or its group's - incoming traffic which isn't allowed by one of them is dropped.
+
+--port can be a single port or a range like 8000-8080, and allows all ports if it isn't set. --source is the IP or CIDR the traffic must come from, and allows traffic from anywhere if it isn't set. icmp rules can't have a port.
+
+The rules for a group or server can be seen with 'bytemark show firewall', and removed with 'bytemark delete firewall rule'.
+
+EXAMPLES
+
+Allow ssh from the office, and https from anywhere, to every server in the web group:
+    bytemark add firewall rule --group web --port 22 --source 192.0.2.0/24 --label office
+    bytemark add firewall rule --group web --port 443`,
+		Flags: append(app.OutputFlags("firewall rule", "object"),
+			cli.GenericFlag{
+				Name:  "group",
+				Usage: "the group to add the rule to",
+				Value: new(flags.GroupNameFlag),
+			},
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to add the rule to",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+			cli.StringFlag{
+				Name:  "protocol",
+				Usage: "the protocol to allow - tcp, udp or icmp",
+				Value: "tcp",
+			},
+			cli.StringFlag{
+				Name:  "port",
+				Usage: "the port or range of ports to allow, e.g. 22 or 8000-8080. Defaults to all ports",
+			},
+			cli.StringFlag{
+				Name:  "source",
+				Usage: "the IP or CIDR to allow traffic from, e.g. 192.0.2.0/24. Defaults to anywhere",
+			},
+			cli.StringFlag{
+				Name:  "label",
+				Usage: "a label to remind you what the rule is for",
+			},
+		),
+		Action: app.Action(with.Auth, func(c *app.Context) error {
+			if c.IsSet("group") == c.IsSet("server") {
+				return c.Help("Exactly one of --group and --server must be specified")
+			}
+			rule, err := brain.FirewallRule{
+				Protocol: c.String("protocol"),
+				Ports:    c.String("port"),
+				Source:   c.String("source"),
+				Label:    c.String("label"),
+			}.Validate()
+			if err != nil {
+				return err
+			}
+			var created brain.FirewallRule
+			if c.IsSet("group") {
+				created, err = c.Client().CreateGroupFirewallRule(flags.GroupName(c, "group"), *rule)
+			} else {
+				created, err = c.Client().CreateServerFirewallRule(flags.VirtualMachineName(c, "server"), *rule)
+			}
+			if err != nil {
+				return err
+			}
+			return c.OutputInDesiredForm(created)
+		}),
+	})
+}
@@ -0,0 +1,83 @@
+package add_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func TestAddFirewallRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		group     pathers.GroupName
+		server    pathers.VirtualMachineName
+		expected  brain.FirewallRule
+		shouldErr bool
+	}{
+		{
+			name:  "group rule",
+			args:  "--group web --port 22 --source 192.0.2.0/24 --label office",
+			group: pathers.GroupName{Group: "web", Account: "default-account"},
+			expected: brain.FirewallRule{
+				Protocol: "tcp",
+				Ports:    "22",
+				Source:   "192.0.2.0/24",
+				Label:    "office",
+			},
+		}, {
+			name:   "server rule",
+			args:   "--server web1 --protocol UDP --port 8000-8080 --source 192.0.2.1",
+			server: pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: testutil.DefGroup},
+			expected: brain.FirewallRule{
+				Protocol: "udp",
+				Ports:    "8000-8080",
+				Source:   "192.0.2.1/32",
+			},
+		}, {
+			name:      "neither group nor server",
+			args:      "--port 22",
+			shouldErr: true,
+		}, {
+			name:      "both group and server",
+			args:      "--group web --server web1 --port 22",
+			shouldErr: true,
+		}, {
+			name:      "icmp with a port",
+			args:      "--group web --protocol icmp --port 22",
+			shouldErr: true,
+		}, {
+			name:      "bad port",
+			args:      "--group web --port 70000",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			config.When("GetGroup").Return(testutil.DefGroup)
+
+			created := brain.FirewallRule{ID: 12, Protocol: "tcp", Ports: "22"}
+			if test.group.Group != "" {
+				c.When("CreateGroupFirewallRule", test.group, test.expected).Return(created, nil).Times(1)
+			} else if test.server.VirtualMachine != "" {
+				c.When("CreateServerFirewallRule", test.server, test.expected).Return(created, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark add firewall rule "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
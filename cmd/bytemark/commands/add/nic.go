@@ -0,0 +1,53 @@
+package add
+
+import (
+	"fmt"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "nic",
+		Aliases:   []string{"nics", "network interface"},
+		Usage:     "add a network interface to a server, attached to a private VLAN",
+		UsageText: "add nic --server <server> --vlan-group <group>",
+		Description: `Adds a network interface to the server, attached to the private VLAN of the given group. Servers with network interfaces on the same private VLAN can talk to each other without going over the public internet - for example, web servers and the database server behind them.
+
+The group must be in the same account as the server, but the server doesn't have to be in it. Groups with private VLANs are set up by Bytemark support - contact them to get one.
+
+No IPs are given to the new interface, so you'll need to configure addresses for it on each server yourself. The server may need to be restarted before it sees the new interface. The server's network interfaces can be listed with 'bytemark show nics'.`,
+		Flags: append(app.OutputFlags("network interface", "object"),
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to add the network interface to",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+			cli.GenericFlag{
+				Name:  "vlan-group",
+				Usage: "the group whose private VLAN the network interface will be attached to",
+				Value: new(flags.GroupNameFlag),
+			},
+		),
+		Action: app.Action(args.Optional("server", "vlan-group"), with.RequiredFlags("server", "vlan-group"), with.Auth, func(c *app.Context) error {
+			groupName := flags.GroupName(c, "vlan-group")
+			group, err := c.Client().GetGroup(groupName)
+			if err != nil {
+				return err
+			}
+			if group.VlanNum == 0 {
+				return fmt.Errorf("%s doesn't have a private VLAN. Contact Bytemark support to set one up", groupName)
+			}
+			nic, err := brainRequests.CreateNIC(c.Client(), flags.VirtualMachineName(c, "server"), group.ID)
+			if err != nil {
+				return err
+			}
+			return c.OutputInDesiredForm(nic)
+		}),
+	})
+}
@@ -0,0 +1,65 @@
+package add_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestAddNIC(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		group     brain.Group
+		shouldErr bool
+	}{
+		{
+			name:  "private group",
+			args:  "--server db1 --vlan-group private",
+			group: brain.Group{ID: 33, Name: "private", VlanNum: 1042},
+		}, {
+			name:  "as arguments",
+			args:  "db1 private",
+			group: brain.Group{ID: 33, Name: "private", VlanNum: 1042},
+		}, {
+			name:      "group without a VLAN",
+			args:      "--server db1 --vlan-group private",
+			group:     brain.Group{ID: 33, Name: "private"},
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			config.When("GetGroup").Return(testutil.DefGroup)
+
+			c.When("GetGroup", pathers.GroupName{Group: "private", Account: "default-account"}).Return(test.group, nil).Times(1)
+			if !test.shouldErr {
+				req := mocks.Request{
+					T:              t,
+					StatusCode:     200,
+					ResponseObject: brain.NetworkInterface{ID: 12, Label: "eth1", VlanNum: 1042},
+				}
+				c.When("BuildRequest", "POST", lib.BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/nics", []string{"default-account", "default", "db1"}).Return(&req, nil).Times(1)
+				defer req.AssertRequestObjectEqual(map[string]int{"vlan_group_id": 33})
+			}
+
+			err := app.Run(strings.Split("bytemark add nic "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
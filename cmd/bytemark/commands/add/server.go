@@ -8,12 +8,15 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/output"
 	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
 	"github.com/BytemarkHosting/bytemark-client/util/log"
 	"github.com/urfave/cli"
 )
@@ -23,7 +26,7 @@ func init() {
 	createServerCmd := cli.Command{
 		Name:      "server",
 		Usage:     `add a new server with bytemark`,
-		UsageText: "add server [flags] <name> [<cores> [<memory [<disc specs>]...]]",
+		UsageText: "add server [flags] <name> [<cores> [<memory [<disc specs>]...]]\n   add server --interactive [flags] [<name>]",
 		Description: `Adds a Cloud Server with the given specification, defaulting to a basic server with Symbiosis installed and weekly backups of the first disc.
 
 The server name can be used to specify which group and account the server should be created in, for example myserver.group1.myaccount.
@@ -39,22 +42,38 @@ it's below a certain threshold of size. By default, a backup is taken every week
 This may cost money if your first disk is larger than the default.
 See the price list for more details at http://www.bytemark.co.uk/prices
 
-If --hwprofile-locked is set then the cloud server's virtual hardware won't be changed over time.`,
+If --from-default is set then the server starts from the spec of that VM default - its cores, memory, zone, hardware profile, discs, backup schedules, image and firstboot script - and any other flags override parts of it. VM defaults can be made from existing servers with 'bytemark add vm default --from-server'.
+
+If --hwprofile-locked is set then the cloud server's virtual hardware won't be changed over time.
+
+If --firstboot-template is set then the file is read as a Go text/template and added to the end of the firstboot script, so that one script can be shared between many servers. Templates can use {{ .Name }}, {{ .Group }}, {{ .Account }}, {{ .Hostname }} and {{ .Zone }} for the new server's details (.Zone is blank unless --zone is set), and {{ .Vars.key }} for values given as --var key=value. --firstboot-template can be given several times, and the parts run in the order given. The whole script can't be longer than 65535 bytes.
+
+If --wait is set then bytemark will wait until the server has been imaged and started (or just started, if --no-image is set) before exiting.
+
+If --interactive is set then bytemark asks about the name, zone, hardware profile, cores, memory, discs, image, SSH keys, backups and IPs in turn, skipping anything already set by other flags. The keys offered are the public keys in ~/.ssh. At the end it shows the equivalent command line, so that the same server can be made again without the questions, and then a summary of the server to confirm before it's created.`,
 		Flags: cliutil.ConcatFlags(app.OutputFlags("server", "object"),
-			flagsets.ServerSpecFlags, flagsets.ImageInstallFlags, flagsets.ImageInstallAuthFlags,
+			flagsets.ServerSpecFlags, flagsets.ImageInstallFlags, flagsets.ImageInstallAuthFlags, flagsets.FirstbootTemplateFlags, wait.OptionalFlags,
 			[]cli.Flag{
 				cli.GenericFlag{
 					Name:  "name",
 					Usage: "The new server's name",
 					Value: new(flags.VirtualMachineNameFlag),
 				},
+				cli.StringFlag{
+					Name:  "from-default",
+					Usage: "Start from the spec of a VM default (see `bytemark show vm defaults`). Other flags override parts of it",
+				},
+				cli.BoolFlag{
+					Name:  "interactive",
+					Usage: "Ask about each part of the server in turn, rather than reading flags",
+				},
 				cli.GenericFlag{
 					Name:  "ip",
 					Value: new(flags.IPFlag),
 					Usage: "Specify an IPv4 or IPv6 address to use. This will only be useful if you are creating the machine in a private VLAN.",
 				},
 			}),
-		Action: app.Action(args.Optional("name", "cores", "memory", "disc"), with.RequiredFlags("name"), with.Auth, createServer),
+		Action: app.Action(args.Optional("name", "cores", "memory", "disc"), with.Auth, interactiveServerSpec, with.RequiredFlags("name"), createServer),
 	}
 	Commands = append(Commands, createServerCmd)
 }
@@ -66,6 +85,17 @@ func createServer(c *app.Context) (err error) {
 	if err != nil {
 		return
 	}
+	if c.IsSet("from-default") {
+		var vmd brain.VirtualMachineDefault
+		vmd, err = brainRequests.GetVMDefault(c.Client(), c.String("from-default"))
+		if err != nil {
+			return
+		}
+		spec, err = flagsets.OverrideServerSpec(c, vmd.ServerSettings, spec)
+		if err != nil {
+			return
+		}
+	}
 	spec.VirtualMachine.Name = name.VirtualMachine
 	// add a fake Hostname so that FullName() works for the prompt below
 	spec.VirtualMachine.Hostname = name.String()
@@ -81,6 +111,13 @@ func createServer(c *app.Context) (err error) {
 	if err != nil {
 		return
 	}
+	if spec.Reimage != nil {
+		fullName := pathers.VirtualMachineName{VirtualMachine: name.VirtualMachine, GroupName: groupName}
+		err = flagsets.RenderFirstbootScript(c, spec.Reimage, flagsets.NewServerFirstbootTemplateData(c, fullName, spec.VirtualMachine.ZoneName))
+		if err != nil {
+			return
+		}
+	}
 
 	log.Logf("The following server will be created in %s:\r\n", groupName)
 	err = spec.PrettyPrint(c.App().Writer, prettyprint.Full)
@@ -101,6 +138,10 @@ func createServer(c *app.Context) (err error) {
 	if err != nil {
 		return err
 	}
+	err = waitForCreatedServer(c, name, spec)
+	if err != nil {
+		return err
+	}
 	vm, err := c.Client().GetVirtualMachine(name)
 	if err != nil {
 		return
@@ -108,6 +149,18 @@ func createServer(c *app.Context) (err error) {
 	return c.OutputInDesiredForm(CreatedVirtualMachine{Spec: spec, VirtualMachine: vm})
 }
 
+// waitForCreatedServer waits for the server to be imaged or started if --wait
+// was specified. Servers which won't be started aren't waited for.
+func waitForCreatedServer(c *app.Context, name pathers.VirtualMachineName, spec brain.VirtualMachineSpec) error {
+	if !spec.VirtualMachine.Autoreboot {
+		return nil
+	}
+	if spec.Reimage != nil {
+		return wait.IfRequested(c, wait.VMImagedWith(name, spec.Reimage.Distribution))
+	}
+	return wait.IfRequested(c, wait.VMPoweredOn(name))
+}
+
 // createServerReadIPs reads the IP flags and creates an IPSpec
 func createServerReadIPs(c *app.Context) (ipspec *brain.IPSpec, err error) {
 	ips := flags.IPs(c, "ip")
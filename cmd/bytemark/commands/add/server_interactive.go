@@ -0,0 +1,392 @@
+package add
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util/sizespec"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/urfave/cli"
+)
+
+// maxInvalidAnswers is how many invalid answers in a row the wizard will
+// accept to one question before giving up - so that it doesn't loop forever
+// when stdin isn't a terminal.
+const maxInvalidAnswers = 5
+
+// serverWizard asks the user about each part of a new server in turn, and
+// sets the flags of add server from their answers. Flags which were already
+// set on the command line aren't asked about.
+type serverWizard struct {
+	c    *app.Context
+	defs lib.Definitions
+}
+
+// interactiveServerSpec is a provider for add server which runs the wizard if
+// --interactive was set. It must run before anything which reads the flags.
+func interactiveServerSpec(c *app.Context) error {
+	if !c.Bool("interactive") {
+		return nil
+	}
+	if err := with.Definitions(c); err != nil {
+		return err
+	}
+	w := serverWizard{c: c, defs: *c.Definitions}
+	steps := []func() error{
+		w.askName,
+		w.askZone,
+		w.askHardwareProfile,
+		w.askCoresAndMemory,
+		w.askDiscs,
+		w.askImage,
+		w.askSSHKeys,
+		w.askBackups,
+		w.askIPs,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	c.LogErr("\r\nTo make the same server again without the questions, run:\r\n  %s\r\n", addServerCommandLine(c))
+	return nil
+}
+
+// ask prompts with the question until valid accepts the answer. A blank
+// answer is replaced with def, unless def is blank too. The answer is
+// returned with surrounding whitespace removed.
+func (w serverWizard) ask(question, def string, valid func(string) error) (string, error) {
+	prompt := question + ": "
+	if def != "" {
+		prompt = fmt.Sprintf("%s [%s]: ", question, def)
+	}
+	for i := 0; i < maxInvalidAnswers; i++ {
+		answer := strings.TrimSpace(w.c.Prompter().Prompt(prompt))
+		if answer == "" {
+			answer = def
+		}
+		err := valid(answer)
+		if err == nil {
+			return answer, nil
+		}
+		w.c.LogErr("%s", err)
+	}
+	return "", fmt.Errorf("Gave up after %d invalid answers", maxInvalidAnswers)
+}
+
+// set sets the named flag as though it had been given on the command line.
+func (w serverWizard) set(name, value string) error {
+	return w.c.Context.Set(name, value)
+}
+
+// oneOf returns a validation function for ask which accepts any of choices,
+// as well as a blank answer if blankOK is true.
+func oneOf(choices []string, blankOK bool) func(string) error {
+	return func(answer string) error {
+		if answer == "" && blankOK {
+			return nil
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("'%s' isn't one of the choices - pick from %s", answer, strings.Join(choices, ", "))
+	}
+}
+
+func (w serverWizard) askName() error {
+	if w.c.IsSet("name") {
+		return nil
+	}
+	name, err := w.ask("Name for the server (e.g. web1, or web1.group.account for a different group)", "", func(answer string) error {
+		vmName, err := lib.ParseVirtualMachineName(answer, w.c.Config().GetVirtualMachine())
+		if err == nil && vmName.VirtualMachine == "" {
+			err = errors.New("The server needs a name")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return w.set("name", name)
+}
+
+func (w serverWizard) askZone() error {
+	if w.c.IsSet("zone") || len(w.defs.ZoneNames) == 0 {
+		return nil
+	}
+	zone, err := w.ask(fmt.Sprintf("Zone (%s - blank to let Bytemark choose)", strings.Join(w.defs.ZoneNames, ", ")), "", oneOf(w.defs.ZoneNames, true))
+	if err != nil || zone == "" {
+		return err
+	}
+	return w.set("zone", zone)
+}
+
+func (w serverWizard) askHardwareProfile() error {
+	if w.c.IsSet("hwprofile") || len(w.defs.HardwareProfiles) == 0 {
+		return nil
+	}
+	profile, err := w.ask(fmt.Sprintf("Hardware profile (%s - blank for the current default)", strings.Join(w.defs.HardwareProfiles, ", ")), "", oneOf(w.defs.HardwareProfiles, true))
+	if err != nil || profile == "" {
+		return err
+	}
+	if err = w.set("hwprofile", profile); err != nil {
+		return err
+	}
+	if !w.c.IsSet("hwprofile-locked") && util.PromptYesNo(w.c.Prompter(), "Lock the hardware profile, so that it isn't upgraded when Bytemark's profiles are?") {
+		return w.set("hwprofile-locked", "true")
+	}
+	return nil
+}
+
+func (w serverWizard) askCoresAndMemory() error {
+	if !w.c.IsSet("cores") {
+		cores, err := w.ask("Number of CPU cores", "1", func(answer string) error {
+			n, err := strconv.Atoi(answer)
+			if err != nil || n < 1 {
+				return fmt.Errorf("'%s' isn't a number of cores", answer)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err = w.set("cores", cores); err != nil {
+			return err
+		}
+	}
+	if !w.c.IsSet("memory") {
+		memory, err := w.ask("Memory (in GiB, or with a GiB/MiB unit)", "1GiB", func(answer string) error {
+			_, err := sizespec.Parse(answer)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return w.set("memory", memory)
+	}
+	return nil
+}
+
+func (w serverWizard) askDiscs() error {
+	if w.c.IsSet("disc") {
+		return nil
+	}
+	w.c.LogErr("Storage grades:")
+	for _, grade := range w.defs.StorageGrades {
+		w.c.LogErr("  %s: %s", grade, w.defs.StorageGradeDescriptions[grade])
+	}
+	for i := 1; ; i++ {
+		grade, err := w.ask(fmt.Sprintf("Storage grade for disc %d", i), "sata", oneOf(w.defs.StorageGrades, false))
+		if err != nil {
+			return err
+		}
+		size, err := w.ask(fmt.Sprintf("Size of disc %d (in GiB, or with a GiB/MiB unit)", i), "25GiB", func(answer string) error {
+			_, err := sizespec.Parse(answer)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err = w.set("disc", grade+":"+strings.Replace(size, " ", "", -1)); err != nil {
+			return err
+		}
+		if !util.PromptYesNo(w.c.Prompter(), "Add another disc?") {
+			return nil
+		}
+	}
+}
+
+func (w serverWizard) askImage() error {
+	if w.c.IsSet("image") || w.c.IsSet("no-image") {
+		return nil
+	}
+	w.c.LogErr("Images:")
+	dists := w.defs.DistributionDefinitions()
+	sort.Slice(dists, func(i, j int) bool { return dists[i].Name < dists[j].Name })
+	for _, dist := range dists {
+		w.c.LogErr("  %s: %s", dist.Name, dist.Description)
+	}
+	choices := append([]string{"none"}, w.defs.Distributions...)
+	image, err := w.ask("Image to install ('none' to leave the server blank)", "symbiosis", oneOf(choices, false))
+	if err != nil {
+		return err
+	}
+	if image == "none" {
+		return w.set("no-image", "true")
+	}
+	return w.set("image", image)
+}
+
+// sshPublicKeyFiles returns the paths of the public keys in ~/.ssh
+func sshPublicKeyFiles() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(os.Getenv("HOME"), ".ssh", "*.pub"))
+	sort.Strings(paths)
+	return paths, err
+}
+
+func (w serverWizard) askSSHKeys() error {
+	if w.c.Bool("no-image") || w.c.IsSet("authorized-keys") || w.c.IsSet("authorized-keys-file") {
+		return nil
+	}
+	paths, err := sshPublicKeyFiles()
+	if err != nil {
+		return err
+	}
+	chosen := []string{}
+	keys := []string{}
+	for _, path := range paths {
+		if !util.PromptYesNo(w.c.Prompter(), fmt.Sprintf("Allow the key in %s to log in as root?", path)) {
+			continue
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		chosen = append(chosen, path)
+		keys = append(keys, strings.TrimSpace(string(contents)))
+	}
+	switch len(chosen) {
+	case 0:
+		return nil
+	case 1:
+		return w.set("authorized-keys-file", chosen[0])
+	}
+	return w.set("authorized-keys", strings.Join(keys, "\n"))
+}
+
+func (w serverWizard) askBackups() error {
+	if w.c.IsSet("backup") {
+		return nil
+	}
+	backup, err := w.ask("How often should the first disc be backed up (daily, weekly or never)", "weekly", func(answer string) error {
+		_, err := flagsets.BackupScheduleIntervalFromWords(answer)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return w.set("backup", backup)
+}
+
+func (w serverWizard) askIPs() error {
+	if w.c.IsSet("ip") {
+		return nil
+	}
+	ips, err := w.ask("IP addresses to use, separated by spaces. Only useful in a private VLAN - leave blank to have them assigned automatically", "", func(answer string) error {
+		v4, v6 := 0, 0
+		for _, ip := range strings.Fields(answer) {
+			parsed := net.ParseIP(ip)
+			if parsed == nil {
+				return fmt.Errorf("'%s' isn't an IP address", ip)
+			}
+			if parsed.To4() != nil {
+				v4++
+			} else {
+				v6++
+			}
+		}
+		if v4 > 1 || v6 > 1 {
+			return errors.New("A maximum of one IPv4 and one IPv6 address may be specified")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, ip := range strings.Fields(ips) {
+		if err = w.set("ip", ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addServerCommandLine returns an add server command line which would make
+// the same server as the flags currently set on c. --root-password is left
+// out so that the password isn't printed, which means a random root password
+// will be generated again.
+func addServerCommandLine(c *app.Context) string {
+	args := []string{"bytemark", "add", "server"}
+	for _, flag := range c.Command().Flags {
+		name := flag.GetName()
+		if name == "interactive" || name == "name" || name == "root-password" || !c.IsSet(name) {
+			continue
+		}
+		switch realFlag := flag.(type) {
+		case cli.BoolFlag:
+			args = append(args, "--"+name)
+		case cli.IntFlag:
+			args = append(args, "--"+name, strconv.Itoa(c.Int(name)))
+		case cli.GenericFlag:
+			switch value := realFlag.Value.(type) {
+			case *flags.DiscSpecFlag:
+				for _, disc := range *value {
+					spec := disc.StorageGrade + ":" + sizeSpecString(disc.Size)
+					if disc.Label != "" {
+						spec = disc.Label + ":" + spec
+					}
+					args = append(args, "--"+name, spec)
+				}
+			case *flags.SizeSpecFlag:
+				args = append(args, "--"+name, sizeSpecString(int(*value)))
+			case *flags.FileFlag:
+				args = append(args, "--"+name, shellQuote(value.FileName))
+			case *flags.FileSliceFlag:
+				for _, file := range *value {
+					args = append(args, "--"+name, shellQuote(file.FileName))
+				}
+			case *flags.KeyValueFlag:
+				keys := make([]string, 0, len(*value))
+				for key := range *value {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					args = append(args, "--"+name, shellQuote(key+"="+(*value)[key]))
+				}
+			case *flags.IPFlag:
+				for _, ip := range *value {
+					args = append(args, "--"+name, ip.String())
+				}
+			default:
+				args = append(args, "--"+name, shellQuote(realFlag.Value.String()))
+			}
+		default:
+			args = append(args, "--"+name, shellQuote(c.String(name)))
+		}
+	}
+	if nameFlag, ok := c.Context.Generic("name").(*flags.VirtualMachineNameFlag); ok {
+		args = append(args, shellQuote(nameFlag.Value))
+	}
+	return strings.Join(args, " ")
+}
+
+// sizeSpecString formats a size in MiB so that sizespec.Parse can read it.
+func sizeSpecString(mib int) string {
+	if mib%1024 == 0 {
+		return fmt.Sprintf("%dGiB", mib/1024)
+	}
+	return fmt.Sprintf("%dMiB", mib)
+}
+
+// shellQuote quotes s for a POSIX shell, if it needs quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -1,15 +1,23 @@
 package add_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
+	appPkg "github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands/add"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	mock "github.com/maraino/go-mock"
 	"github.com/urfave/cli"
 )
 
@@ -243,3 +251,298 @@ func TestCreateServer(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateServerInteractive(t *testing.T) {
+	home, err := ioutil.TempDir("", "bytemark-client-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err = os.Mkdir(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(home, ".ssh", "id_ed25519.pub")
+	if err = ioutil.WriteFile(keyFile, []byte("ssh-ed25519 AAAAtest test@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	oldHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", oldHome)
+	_ = os.Setenv("HOME", home)
+
+	tomorrow := time.Now().Add(24 * time.Hour)
+	y, m, d := tomorrow.Date()
+	midnightTonight := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+
+	spec := brain.VirtualMachineSpec{
+		VirtualMachine: brain.VirtualMachine{
+			Name:                  "test-server",
+			Autoreboot:            true,
+			Cores:                 2,
+			Memory:                4096,
+			ZoneName:              "york",
+			HardwareProfile:       "virtio2018",
+			HardwareProfileLocked: true,
+		},
+		Discs: []brain.Disc{{
+			Size:         50 * 1024,
+			StorageGrade: "ssd",
+			BackupSchedules: brain.BackupSchedules{{
+				StartDate: midnightTonight.Format("2006-01-02 15:04:05 MST"),
+				Interval:  86400,
+				Capacity:  1,
+			}},
+		}, {
+			Size:         500 * 1024,
+			StorageGrade: "archive",
+		}},
+		Reimage: &brain.ImageInstall{
+			Distribution: "stretch",
+			RootPassword: "test-password",
+			PublicKeys:   "ssh-ed25519 AAAAtest test@example.com\n",
+		},
+		IPs: &brain.IPSpec{
+			IPv4: "192.0.2.1",
+			IPv6: "2001:db8::1",
+		},
+	}
+
+	// the answers are given in the order the questions are expected, and
+	// each question must be asked once
+	answers := []struct {
+		question string
+		answer   string
+	}{
+		{"Name for the server (e.g. web1, or web1.group.account for a different group): ", "test-server"},
+		{"Zone (york, manchester - blank to let Bytemark choose): ", "york"},
+		{"Hardware profile (virtio2013, virtio2018 - blank for the current default): ", "virtio2018"},
+		{"Lock the hardware profile, so that it isn't upgraded when Bytemark's profiles are? (y/N) ", "y"},
+		{"Number of CPU cores [1]: ", "2"},
+		{"Memory (in GiB, or with a GiB/MiB unit) [1GiB]: ", "4"},
+		{"Storage grade for disc 1 [sata]: ", "ssd"},
+		{"Size of disc 1 (in GiB, or with a GiB/MiB unit) [25GiB]: ", "50"},
+		{"Add another disc? (y/N) ", "y"},
+		{"Storage grade for disc 2 [sata]: ", "archive"},
+		{"Size of disc 2 (in GiB, or with a GiB/MiB unit) [25GiB]: ", "500GiB"},
+		{"Add another disc? (y/N) ", ""},
+		{"Image to install ('none' to leave the server blank) [symbiosis]: ", "stretch"},
+		{"Allow the key in " + keyFile + " to log in as root? (y/N) ", "y"},
+		{"How often should the first disc be backed up (daily, weekly or never) [weekly]: ", "daily"},
+		{"IP addresses to use, separated by spaces. Only useful in a private VLAN - leave blank to have them assigned automatically: ", "192.0.2.1 192.0.2.2"},
+		{"IP addresses to use, separated by spaces. Only useful in a private VLAN - leave blank to have them assigned automatically: ", "192.0.2.1 2001:db8::1"},
+		{"Are you certain you wish to continue? (y/N) ", "y"},
+	}
+	prompter := mocks.Prompter{}
+	asked := 0
+	prompter.When("Prompt", mock.Any).Call(func(question string) string {
+		if asked >= len(answers) {
+			t.Fatalf("Unexpected question %q", question)
+		}
+		expected := answers[asked]
+		asked++
+		if question != expected.question {
+			t.Fatalf("Question %d was %q, expected %q", asked, question, expected.question)
+		}
+		return expected.answer
+	})
+
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	appPkg.SetPrompter(app, &prompter)
+	config.When("GetVirtualMachine").Return(testutil.DefVM)
+	c.When("ReadDefinitions").Return(lib.Definitions{
+		Distributions:            []string{"symbiosis", "stretch"},
+		DistributionDescriptions: map[string]string{"symbiosis": "Symbiosis", "stretch": "Debian 9"},
+		StorageGrades:            []string{"sata", "ssd", "archive"},
+		ZoneNames:                []string{"york", "manchester"},
+		HardwareProfiles:         []string{"virtio2013", "virtio2018"},
+	}, nil)
+
+	groupName := pathers.GroupName{Group: "default", Account: "default-account"}
+	c.When("CreateVirtualMachine", groupName, spec).Return(spec.VirtualMachine, nil).Times(1)
+	getvm := spec.VirtualMachine
+	getvm.Discs = spec.Discs
+	getvm.Hostname = "test-server.default.default-account.tld"
+	c.When("GetVirtualMachine", pathers.VirtualMachineName{VirtualMachine: "test-server", GroupName: groupName}).Return(getvm, nil).Times(1)
+
+	err = app.Run([]string{"bytemark", "add", "server", "--interactive", "--root-password", "test-password"})
+	if err != nil {
+		t.Error(err)
+	}
+	if asked != len(answers) {
+		t.Errorf("Only %d of %d questions were asked", asked, len(answers))
+	}
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+
+	expectedCommand := "bytemark add server --cores 2 --disc ssd:50GiB --disc archive:500GiB --hwprofile virtio2018 --hwprofile-locked --memory 4GiB --backup daily --zone york --image stretch --authorized-keys-file " + keyFile + " --ip 192.0.2.1 --ip 2001:db8::1 test-server"
+	buf, err := testutil.GetBuf(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), expectedCommand) {
+		t.Errorf("Output didn't contain the equivalent command line %q:\n%s", expectedCommand, buf.String())
+	}
+	if strings.Contains(buf.String(), "--root-password") {
+		t.Errorf("The equivalent command line contained the root password:\n%s", buf.String())
+	}
+}
+
+func TestCreateServerFromDefault(t *testing.T) {
+	tomorrow := time.Now().Add(24 * time.Hour)
+	y, m, d := tomorrow.Date()
+	defaultStartDate := time.Date(y, m, d, 0, 0, 0, 0, time.Local).Format("2006-01-02 15:04:05 MST")
+
+	vmds := brain.VirtualMachineDefaults{{
+		ID:   4,
+		Name: "web",
+		ServerSettings: brain.VirtualMachineSpec{
+			VirtualMachine: brain.VirtualMachine{
+				Cores:           2,
+				Memory:          2048,
+				ZoneName:        "york",
+				HardwareProfile: "virtio2018",
+			},
+			Discs: []brain.Disc{{
+				StorageGrade: "ssd",
+				Size:         51200,
+				BackupSchedules: brain.BackupSchedules{{
+					Interval: 86400,
+					Capacity: 1,
+				}},
+			}},
+			Reimage: &brain.ImageInstall{
+				Distribution:    "stretch",
+				FirstbootScript: "apt-get install nginx",
+			},
+		},
+	}}
+
+	tests := []struct {
+		name string
+		args []string
+		spec brain.VirtualMachineSpec
+	}{
+		{
+			name: "just the default",
+			args: []string{"bytemark", "add", "server", "--force", "--root-password", "test-password", "--from-default", "web", "test-server"},
+			spec: brain.VirtualMachineSpec{
+				VirtualMachine: brain.VirtualMachine{
+					Name:            "test-server",
+					Autoreboot:      true,
+					Cores:           2,
+					Memory:          2048,
+					ZoneName:        "york",
+					HardwareProfile: "virtio2018",
+				},
+				Discs: []brain.Disc{{
+					StorageGrade: "ssd",
+					Size:         51200,
+					BackupSchedules: brain.BackupSchedules{{
+						StartDate: defaultStartDate,
+						Interval:  86400,
+						Capacity:  1,
+					}},
+				}},
+				Reimage: &brain.ImageInstall{
+					Distribution:    "stretch",
+					FirstbootScript: "apt-get install nginx",
+					RootPassword:    "test-password",
+				},
+			},
+		}, {
+			name: "overridden by flags",
+			args: []string{"bytemark", "add", "server", "--force", "--root-password", "test-password", "--from-default", "4", "--zone", "manchester", "--backup", "never", "--image", "buster", "test-server", "4"},
+			spec: brain.VirtualMachineSpec{
+				VirtualMachine: brain.VirtualMachine{
+					Name:            "test-server",
+					Autoreboot:      true,
+					Cores:           4,
+					Memory:          2048,
+					ZoneName:        "manchester",
+					HardwareProfile: "virtio2018",
+				},
+				Discs: []brain.Disc{{
+					StorageGrade: "ssd",
+					Size:         51200,
+				}},
+				Reimage: &brain.ImageInstall{
+					Distribution:    "buster",
+					FirstbootScript: "apt-get install nginx",
+					RootPassword:    "test-password",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			getRequest := mocks.Request{T: t, StatusCode: 200, ResponseObject: vmds}
+			c.When("BuildRequest", "GET", lib.BrainEndpoint, "/vm_defaults", []string(nil)).Return(&getRequest, nil).Times(1)
+
+			groupName := pathers.GroupName{Group: "default", Account: "default-account"}
+			getvm := test.spec.VirtualMachine
+			getvm.Hostname = "test-server.default.default-account.tld"
+			c.When("CreateVirtualMachine", groupName, test.spec).Return(test.spec.VirtualMachine, nil).Times(1)
+			c.When("GetVirtualMachine", pathers.VirtualMachineName{VirtualMachine: "test-server", GroupName: groupName}).Return(getvm, nil).Times(1)
+
+			err := app.Run(test.args)
+			if err != nil {
+				t.Error(err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCreateServerFirstbootTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "firstboot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := filepath.Join(dir, "firstboot.tmpl")
+	err = ioutil.WriteFile(tmpl, []byte("echo '{{ .Name }} {{ .Group }} {{ .Account }} {{ .Hostname }} {{ .Zone }} {{ .Vars.role }}' > /etc/motd\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	config.When("GetVirtualMachine").Return(testutil.DefVM)
+	config.When("EndpointName").Return("uk0.bigv.io")
+
+	groupName := pathers.GroupName{Group: "default", Account: "default-account"}
+	spec := brain.VirtualMachineSpec{
+		VirtualMachine: brain.VirtualMachine{
+			Name:       "test-server",
+			Autoreboot: true,
+			Cores:      1,
+			Memory:     1024,
+			ZoneName:   "york",
+		},
+		Discs: []brain.Disc{{
+			Size:         25600,
+			StorageGrade: "sata",
+		}},
+		Reimage: &brain.ImageInstall{
+			Distribution:    "stretch",
+			FirstbootScript: "echo 'test-server default default-account test-server.default.default-account.uk0.bigv.io york web' > /etc/motd\n",
+			RootPassword:    "test-password",
+		},
+	}
+	getvm := spec.VirtualMachine
+	getvm.Hostname = "test-server.default.default-account.uk0.bigv.io"
+	c.When("CreateVirtualMachine", groupName, spec).Return(spec.VirtualMachine, nil).Times(1)
+	c.When("GetVirtualMachine", mock.Any).Return(getvm, nil).Times(1)
+
+	err = app.Run([]string{"bytemark", "add", "server", "--force", "--backup", "never", "--root-password", "test-password", "--image", "stretch", "--zone", "york", "--firstboot-template", tmpl, "--var", "role=web", "test-server"})
+	if err != nil {
+		t.Error(err)
+	}
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,67 @@
+package add
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "vm default",
+		Aliases:   []string{"vmdefault"},
+		Usage:     "save a server spec as a VM default, to make more servers like it",
+		UsageText: "add vm default [--from-server <server>] [flags] <default name>",
+		Description: `Adds a VM default - a template for servers - to one of your accounts. VM defaults can be used with 'bytemark add server --from-default', and by the panel to fill in the new server form.
+
+--default-name (and the <default name> positional argument) is an identifier for the default, not a default name for servers created based upon it.
+
+If --from-server is set then the VM default starts from that server's cores, memory, zone, hardware profile, discs, backup schedules and image, and any other flags override parts of it. Otherwise the spec is made from the flags, as for 'bytemark add server', leaving out anything which isn't set.
+
+The VM default is added to --account if it's set, or the --from-server server's account, or your default account otherwise. If --public is set everyone will be able to see and use it.`,
+		Flags: cliutil.ConcatFlags(app.OutputFlags("vm default", "object"),
+			flagsets.ImageInstallFlags, flagsets.ServerSpecFlags, flagsets.VMDefaultFlags,
+			[]cli.Flag{
+				cli.GenericFlag{
+					Name:  "account",
+					Usage: "the account to add the default to",
+					Value: new(flags.AccountNameFlag),
+				},
+			}),
+		Action: app.Action(args.Optional("default-name"), with.RequiredFlags("default-name"), with.Auth, func(c *app.Context) (err error) {
+			accountName := flags.AccountName(c, "account").AccountName
+			if !c.IsSet("account") && c.IsSet("from-server") {
+				accountName = string(flags.VirtualMachineName(c, "from-server").Account)
+			}
+			account, err := c.Client().GetAccount(accountName)
+			if err != nil {
+				return
+			}
+			spec, err := flagsets.PrepareVMDefaultSpec(c)
+			if err != nil {
+				return
+			}
+
+			vmd := brain.VirtualMachineDefault{
+				AccountID:      account.BrainID,
+				Name:           c.String("default-name"),
+				Public:         c.Bool("public"),
+				ServerSettings: spec,
+			}
+
+			vmd, err = brainRequests.CreateVMDefault(c.Client(), vmd)
+			if err != nil {
+				return
+			}
+			c.Log("Successfully created virtual machine default:")
+			return vmd.PrettyPrint(c.Writer(), prettyprint.Full)
+		}),
+	})
+}
@@ -0,0 +1,141 @@
+package add_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestCreateVMDefaultFromServer(t *testing.T) {
+	tomorrow := time.Now().Add(24 * time.Hour)
+	y, m, d := tomorrow.Date()
+	defaultStartDate := time.Date(y, m, d, 0, 0, 0, 0, time.Local).Format("2006-01-02 15:04:05 MST")
+
+	vmName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: pathers.GroupName{Group: "default", Account: "tomatoes"}}
+	vm := brain.VirtualMachine{
+		ID:         12,
+		Name:       "web1",
+		Hostname:   "web1.default.tomatoes.uk0.bigv.io",
+		Autoreboot: true,
+		PowerOn:    true,
+		Cores:      2,
+		Memory:     2048,
+		ZoneName:   "york",
+		Discs: brain.Discs{{
+			ID:           33,
+			Label:        "disc-1",
+			StorageGrade: "sata",
+			Size:         25600,
+			BackupSchedules: brain.BackupSchedules{{
+				ID:        2,
+				StartDate: "2018-01-01 00:00:00",
+				Interval:  604800,
+				Capacity:  1,
+			}},
+		}, {
+			ID:           34,
+			Label:        "disc-2",
+			StorageGrade: "archive",
+			Size:         512000,
+		}},
+		LastImagedWith: "stretch",
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected brain.VirtualMachineDefault
+	}{
+		{
+			name: "from server",
+			args: []string{"bytemark", "add", "vm", "default", "--from-server", "web1.default.tomatoes", "web"},
+			expected: brain.VirtualMachineDefault{
+				AccountID: 26580,
+				Name:      "web",
+				ServerSettings: brain.VirtualMachineSpec{
+					VirtualMachine: brain.VirtualMachine{
+						Cores:    2,
+						Memory:   2048,
+						ZoneName: "york",
+					},
+					Discs: []brain.Disc{{
+						Label:        "disc-1",
+						StorageGrade: "sata",
+						Size:         25600,
+						BackupSchedules: brain.BackupSchedules{{
+							StartDate: defaultStartDate,
+							Interval:  604800,
+							Capacity:  1,
+						}},
+					}, {
+						Label:        "disc-2",
+						StorageGrade: "archive",
+						Size:         512000,
+					}},
+					Reimage: &brain.ImageInstall{
+						Distribution: "stretch",
+					},
+				},
+			},
+		}, {
+			name: "from server with overrides",
+			args: []string{"bytemark", "add", "vm", "default", "--from-server", "web1.default.tomatoes", "--cores", "4", "--backup", "never", "--public", "big-web"},
+			expected: brain.VirtualMachineDefault{
+				AccountID: 26580,
+				Name:      "big-web",
+				Public:    true,
+				ServerSettings: brain.VirtualMachineSpec{
+					VirtualMachine: brain.VirtualMachine{
+						Cores:    4,
+						Memory:   2048,
+						ZoneName: "york",
+					},
+					Discs: []brain.Disc{{
+						Label:        "disc-1",
+						StorageGrade: "sata",
+						Size:         25600,
+					}, {
+						Label:        "disc-2",
+						StorageGrade: "archive",
+						Size:         512000,
+					}},
+					Reimage: &brain.ImageInstall{
+						Distribution: "stretch",
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			config.When("GetIgnoreErr", "account").Return("default-account")
+
+			c.When("GetAccount", "tomatoes").Return(lib.Account{BrainID: 26580}).Times(1)
+			c.When("GetVirtualMachine", vmName).Return(vm, nil).Times(1)
+			c.When("ReadDefinitions").Return(lib.Definitions{Distributions: []string{"symbiosis", "stretch"}}, nil)
+
+			request := mocks.Request{
+				T:          t,
+				StatusCode: 200,
+			}
+			c.When("BuildRequest", "POST", lib.BrainEndpoint, "/vm_defaults", []string(nil)).Return(&request).Times(1)
+
+			err := app.Run(test.args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			request.AssertRequestObjectEqual(test.expected)
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
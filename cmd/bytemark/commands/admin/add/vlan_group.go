@@ -17,7 +17,7 @@ func init() {
 		UsageText: "--admin add vlan group <group> [vlan-num]",
 		Description: `Add a group in the specified account, with an optional VLAN specified.
 
-Used when setting up a private VLAN for a customer.`,
+Used when setting up a private VLAN for a customer. The customer can then attach servers in any group of the account to the VLAN with 'bytemark add nic --vlan-group <group>'.`,
 		Flags: []cli.Flag{
 			cli.GenericFlag{
 				Name:  "group",
@@ -28,18 +28,12 @@ A disc spec looks like the following: grade:size. The grade field is optional an
 Multiple --disc flags can be used to add multiple discs to the VM Default
 
 If --backup is set then a backup of the first disk will be taken at the
-frequency specified - never, daily, weekly or monthly. If not specified the backup will default to weekly.`,
+frequency specified - never, daily, weekly or monthly. If not specified the backup will default to weekly.
+
+If --from-server is set then the VM default starts from that server's cores, memory, zone, hardware profile, discs, backup schedules and image, and any other flags override parts of it.`,
 		Flags: cliutil.ConcatFlags(app.OutputFlags("vm default", "object"),
-			flagsets.ImageInstallFlags, flagsets.ServerSpecFlags,
+			flagsets.ImageInstallFlags, flagsets.ServerSpecFlags, flagsets.VMDefaultFlags,
 			[]cli.Flag{
-				cli.StringFlag{
-					Name:  "default-name",
-					Usage: "The name of the VM default to add",
-				},
-				cli.BoolFlag{
-					Name:  "public",
-					Usage: "If the VM default should be made public or not",
-				},
 				cli.GenericFlag{
 					Name:  "account",
 					Usage: "the account to add the default to (will use 'bytemark' if unset)",
@@ -55,26 +49,11 @@ frequency specified - never, daily, weekly or monthly. If not specified the back
 			if err != nil {
 				return
 			}
-			spec, err := flagsets.PrepareServerSpec(c, false)
+			spec, err := flagsets.PrepareVMDefaultSpec(c)
 			if err != nil {
 				return
 			}
 
-			// time to unset some stuff that gets auto-set, if we didn't actually specify anything
-			if !c.IsSet("disc") {
-				spec.Discs = nil
-			}
-			if !c.IsSet("image") {
-				spec.Reimage.Distribution = ""
-			}
-			if !c.IsSet("cores") {
-				spec.VirtualMachine.Cores = 0
-			}
-			if !c.IsSet("memory") {
-				spec.VirtualMachine.Memory = 0
-			}
-			spec.VirtualMachine.Autoreboot = false
-
 			vmd := brain.VirtualMachineDefault{
 				AccountID:      account.BrainID,
 				Name:           c.String("default-name"),
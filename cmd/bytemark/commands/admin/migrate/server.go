@@ -1,10 +1,15 @@
 package migrate
 
 import (
+	"fmt"
+
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/urfave/cli"
 )
 
@@ -14,8 +19,8 @@ func init() {
 		Aliases:     []string{"vm"},
 		Usage:       "migrate a server to a new head",
 		UsageText:   "--admin migrate server <name> [new-head]",
-		Description: `This command migrates a server to a new head. If a new head isn't supplied, a new one is picked automatically.`,
-		Flags: []cli.Flag{
+		Description: `This command migrates a server to a new head. If a new head isn't supplied, a new one is picked automatically. If --wait is set, bytemark will wait until the server is on its new head before exiting.`,
+		Flags: cliutil.ConcatFlags(wait.OptionalFlags, []cli.Flag{
 			cli.GenericFlag{
 				Name:  "server",
 				Usage: "the server to migrate",
@@ -25,7 +30,7 @@ func init() {
 				Name:  "new-head",
 				Usage: "the head to move the server to",
 			},
-		},
+		}),
 		Action: app.Action(args.Optional("server", "new-head"), with.RequiredFlags("server"), with.Auth, func(ctx *app.Context) (err error) {
 			vmName := flags.VirtualMachineName(ctx, "server")
 			head := ctx.String("new-head")
@@ -40,7 +45,9 @@ func init() {
 			}
 
 			ctx.Log("Migration for server %s initiated", vm.Hostname)
-			return
+			return wait.IfRequested(ctx, wait.VMMatches(vmName, fmt.Sprintf("%s to move to a new head", vm.Hostname), func(newVM brain.VirtualMachine) bool {
+				return newVM.Head != vm.Head
+			}))
 		}),
 	})
 }
@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib/manifest"
+	"github.com/urfave/cli"
+)
+
+const manifestText = `MANIFESTS
+
+A manifest is a YAML or JSON document describing groups, the servers within them and the privileges granted on each. Servers are described using the same fields as the brain's vm_create API, with privileges alongside. Memory and disc sizes are in MiB, backup schedule intervals are in seconds.
+
+account: my-account  # optional - defaults to your default account
+groups:
+- name: web
+  privileges:
+  - username: alice
+    level: group_admin
+  servers:
+  - virtual_machine:
+      name: web1
+      cores: 2
+      memory: 2048
+      hardware_profile: virtio2018
+    discs:
+    - label: root
+      storage_grade: sata
+      size: 25600
+      backup_schedules:
+      - start_at: "2018-01-01 00:00:00"
+        interval_seconds: 86400
+        capacity: 1
+    reimage:
+      distribution: stretch
+      root_password: hunter2
+    privileges:
+    - username: bob
+      level: vm_console`
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "apply",
+		Usage:     "create or update groups, servers, discs, backup schedules and privileges to match a manifest",
+		UsageText: "apply [--dry-run] [--force] [--json] --file <manifest>",
+		Description: `Reads the manifest, compares it to the groups and servers that already exist, then outputs a plan of the changes needed to make them match.
+Unless --dry-run is set, it will then ask for confirmation and make the changes.
+
+apply never deletes anything - groups, servers, discs, backup schedules and privileges that are not in the manifest are left alone. Changes which can't be made safely, like shrinking a disc or changing its grade, cause an error before any changes are made.
+
+` + manifestText,
+		Flags: append(app.OutputFlags("plan", "array"),
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Output the plan without making any changes",
+			},
+			cli.StringFlag{
+				Name:  "file, f",
+				Usage: "The manifest file to apply",
+			},
+			flagsets.Force,
+		),
+		Action: app.Action(args.Optional("file"), with.Auth, func(c *app.Context) error {
+			m, err := readManifest(c.String("file"))
+			if err != nil {
+				return err
+			}
+			plan, err := manifest.MakePlan(c.Client(), m)
+			if err != nil {
+				return err
+			}
+			err = c.OutputInDesiredForm(plan)
+			if err != nil || len(plan) == 0 || c.Bool("dry-run") {
+				return err
+			}
+
+			if !flagsets.Forced(c) && !util.PromptYesNo(c.Prompter(), "Are you sure you wish to make these changes?") {
+				return util.UserRequestedExit{}
+			}
+			err = plan.Apply(c.Client(), func(action manifest.Action) {
+				c.LogErr("%s %s", action.Type, action.Target)
+			})
+			if err != nil {
+				return err
+			}
+			c.LogErr("Applied %s successfully", c.String("file"))
+			return nil
+		}),
+	})
+}
+
+// readManifest reads and parses the named manifest file. --file is a plain
+// StringFlag rather than a FileFlag because urfave/cli re-Sets aliased flags
+// with the value's String(), which for a FileFlag is the file's contents.
+func readManifest(filename string) (m manifest.Manifest, err error) {
+	if filename == "" {
+		return m, errors.New("--file not set (or should not be blank/zero)")
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+	return manifest.Parse(data)
+}
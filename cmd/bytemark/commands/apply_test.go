@@ -0,0 +1,110 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+const testManifest = `account: test-account
+groups:
+- name: test-group
+  servers:
+  - virtual_machine:
+      name: test-server
+      cores: 2
+`
+
+func writeTestManifest(t *testing.T) string {
+	f, err := ioutil.TempFile("", "bytemark-client-test-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(testManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func TestApply(t *testing.T) {
+	filename := writeTestManifest(t)
+	defer os.Remove(filename)
+
+	group := pathers.GroupName{Group: "test-group", Account: "test-account"}
+	vmName := pathers.VirtualMachineName{GroupName: group, VirtualMachine: "test-server"}
+
+	existingServer := func(c *mocks.Client) {
+		c.When("GetGroup", group).Return(brain.Group{ID: 1}, nil).Times(1)
+		c.When("GetPrivilegesForGroup", group).Return(brain.Privileges{}, nil).Times(1)
+		c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{ID: 2, Cores: 1}, nil).Times(1)
+		c.When("GetPrivilegesForVirtualMachine", vmName).Return(brain.Privileges{}, nil).Times(1)
+	}
+
+	tests := []struct {
+		testutil.CommandT
+		setup func(c *mocks.Client)
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name:            "dry run",
+				Args:            "apply --dry-run --file " + filename,
+				OutputMustMatch: []*regexp.Regexp{regexp.MustCompile("set cores test-server.test-group.test-account \\(1 -> 2\\)")},
+			},
+			setup: existingServer,
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "forced",
+				Args: "apply --force -f " + filename,
+			},
+			setup: func(c *mocks.Client) {
+				existingServer(c)
+				c.When("SetVirtualMachineCores", vmName, 2).Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "new group",
+				Args: "apply --force " + filename,
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(brain.Group{}, lib.NotFoundError{}).Times(1)
+				c.When("CreateGroup", group).Return(nil).Times(1)
+				c.When("CreateVirtualMachine", group, brain.VirtualMachineSpec{
+					VirtualMachine: brain.VirtualMachine{
+						Name:  "test-server",
+						Cores: 2,
+					},
+				}).Return(brain.VirtualMachine{}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:            "nothing to do",
+				Args:            "apply --force " + filename,
+				OutputMustMatch: []*regexp.Regexp{regexp.MustCompile("No changes to make")},
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(brain.Group{ID: 1}, nil).Times(1)
+				c.When("GetPrivilegesForGroup", group).Return(brain.Privileges{}, nil).Times(1)
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{ID: 2, Cores: 2}, nil).Times(1)
+				c.When("GetPrivilegesForVirtualMachine", vmName).Return(brain.Privileges{}, nil).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		test.Auth = true
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, config *mocks.Config, client *mocks.Client, app *cli.App) {
+			test.setup(client)
+		})
+	}
+}
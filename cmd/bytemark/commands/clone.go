@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands/add"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "clone",
+		Usage:     "make copies of things",
+		UsageText: "clone server <server> <new name>",
+		Action:    cli.ShowSubcommandHelp,
+		Subcommands: []cli.Command{{
+			Name:      "server",
+			Usage:     "make a new server with the same spec as an existing one",
+			UsageText: "clone server [flags] <server> <new name>",
+			Description: `Makes a new server with the same cores, memory, zone, hardware profile, CD-ROM and discs as <server>. Each disc gets the same label, storage grade, size and backup schedules as the original.
+
+Any of the flags that add server takes can be used to override part of the copy - for example --memory 4GiB, or --disc to replace the discs entirely.
+
+By default the new server is imaged with the distribution <server> was last imaged with, or --image if it is set. If <server> was never imaged then neither is the new server, unless --image is set.
+
+If --from-backups is set then the new server isn't imaged at all - instead each disc starts as a copy of the latest backup of the matching disc on <server>. Every disc on <server> needs a finished backup for this to work, which can be taken with 'bytemark backup disc'. --from-backups can't be used with --image, --no-image or --disc.
+
+The new server's firstboot script can be templated with --firstboot-template and --var, as in add server.
+
+If --wait is set then bytemark will wait until the server has been imaged and started (or just started, if it isn't being imaged) before exiting.`,
+			Flags: cliutil.ConcatFlags(app.OutputFlags("server", "object"),
+				flagsets.ServerSpecFlags, flagsets.ImageInstallFlags, flagsets.ImageInstallAuthFlags, flagsets.FirstbootTemplateFlags, wait.OptionalFlags,
+				[]cli.Flag{
+					cli.GenericFlag{
+						Name:  "server",
+						Usage: "the server to copy",
+						Value: new(flags.VirtualMachineNameFlag),
+					},
+					cli.GenericFlag{
+						Name:  "name",
+						Usage: "the new server's name",
+						Value: new(flags.VirtualMachineNameFlag),
+					},
+					cli.BoolFlag{
+						Name:  "from-backups",
+						Usage: "copy the contents of each disc from its latest backup, rather than imaging the new server",
+					},
+				}),
+			Action: app.Action(args.Optional("server", "name"), with.RequiredFlags("server", "name"), with.Auth, cloneServer),
+		}},
+	})
+}
+
+func cloneServer(c *app.Context) error {
+	if c.Bool("from-backups") {
+		for _, flag := range []string{"image", "no-image", "disc"} {
+			if c.IsSet(flag) {
+				return fmt.Errorf("--from-backups can't be used with --%s", flag)
+			}
+		}
+	}
+	srcName := flags.VirtualMachineName(c, "server")
+	name := flags.VirtualMachineName(c, "name")
+
+	src, err := c.Client().GetVirtualMachine(srcName)
+	if err != nil {
+		return err
+	}
+	base := src.Spec()
+
+	spec, err := flagsets.PrepareServerSpec(c, true)
+	if err != nil {
+		return err
+	}
+	if base.Reimage == nil && !c.IsSet("image") {
+		spec.Reimage = nil
+	}
+	spec, err = flagsets.OverrideServerSpec(c, base, spec)
+	if err != nil {
+		return err
+	}
+	if c.Bool("from-backups") {
+		err = seedDiscsFromBackups(c, srcName, spec.Discs)
+		if err != nil {
+			return err
+		}
+		spec.Reimage = nil
+		spec.VirtualMachine.Autoreboot = !c.Bool("stopped")
+	}
+	spec.VirtualMachine.Name = name.VirtualMachine
+	// add a fake Hostname so that FullName() works for the prompt below
+	spec.VirtualMachine.Hostname = name.String()
+
+	groupName := name.GroupName
+	err = c.Client().EnsureGroupName(&groupName)
+	if err != nil {
+		return err
+	}
+	if spec.Reimage != nil {
+		fullName := pathers.VirtualMachineName{VirtualMachine: name.VirtualMachine, GroupName: groupName}
+		err = flagsets.RenderFirstbootScript(c, spec.Reimage, flagsets.NewServerFirstbootTemplateData(c, fullName, spec.VirtualMachine.ZoneName))
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Logf("The following server will be created in %s:\r\n", groupName)
+	err = spec.PrettyPrint(c.App().Writer, prettyprint.Full)
+	if err != nil {
+		return err
+	}
+	if !c.Bool("force") && !util.PromptYesNo(c.Prompter(), "Are you certain you wish to continue?") {
+		log.Error("Exiting.")
+		return util.UserRequestedExit{}
+	}
+	spec.VirtualMachine.Hostname = ""
+
+	_, err = c.Client().CreateVirtualMachine(groupName, spec)
+	if err != nil {
+		return err
+	}
+	if spec.VirtualMachine.Autoreboot {
+		cond := wait.VMPoweredOn(name)
+		if spec.Reimage != nil {
+			cond = wait.VMImagedWith(name, spec.Reimage.Distribution)
+		}
+		err = wait.IfRequested(c, cond)
+		if err != nil {
+			return err
+		}
+	}
+	vm, err := c.Client().GetVirtualMachine(name)
+	if err != nil {
+		return err
+	}
+	return c.OutputInDesiredForm(add.CreatedVirtualMachine{Spec: spec, VirtualMachine: vm})
+}
+
+// seedDiscsFromBackups sets each of discs to be copied from the latest
+// finished backup of the disc with the same label on the server called src.
+func seedDiscsFromBackups(c *app.Context, src pathers.VirtualMachineName, discs []brain.Disc) error {
+	for i := range discs {
+		backups, err := c.Client().GetBackups(src, discs[i].Label)
+		if err != nil {
+			return err
+		}
+		latest := latestBackup(backups)
+		if latest == nil {
+			return fmt.Errorf("%s has no finished backups of %s to copy. Take one with `bytemark backup disc %s %s` first", src, discs[i].Label, src, discs[i].Label)
+		}
+		discs[i].BackupID = latest.ID
+	}
+	return nil
+}
+
+// latestBackup returns the most recent of backups which has finished being
+// taken, or nil if none have.
+func latestBackup(backups brain.Backups) (latest *brain.Backup) {
+	for i, backup := range backups {
+		if backup.OnColdStorage() && (latest == nil || backup.ID > latest.ID) {
+			latest = &backups[i]
+		}
+	}
+	return
+}
@@ -0,0 +1,174 @@
+package commands_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func TestCloneServer(t *testing.T) {
+	tomorrow := time.Now().Add(24 * time.Hour)
+	y, m, d := tomorrow.Date()
+	defaultStartDate := time.Date(y, m, d, 0, 0, 0, 0, time.Local).Format("2006-01-02 15:04:05 MST")
+
+	groupName := pathers.GroupName{Group: "default", Account: "default-account"}
+	srcName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: groupName}
+	newName := pathers.VirtualMachineName{VirtualMachine: "web2", GroupName: groupName}
+	src := brain.VirtualMachine{
+		ID:              40,
+		Name:            "web1",
+		Hostname:        "web1.default.default-account.tld",
+		Cores:           2,
+		Memory:          4096,
+		ZoneName:        "york",
+		HardwareProfile: "virtio2018",
+		LastImagedWith:  "stretch",
+		Discs: []brain.Disc{{
+			ID:           41,
+			Label:        "disc-1",
+			StorageGrade: "ssd",
+			Size:         51200,
+			BackupSchedules: brain.BackupSchedules{{
+				ID:        42,
+				StartDate: "2018-01-01 00:00:00",
+				Interval:  86400,
+				Capacity:  3,
+			}},
+		}, {
+			ID:           43,
+			Label:        "data",
+			StorageGrade: "archive",
+			Size:         102400,
+		}},
+	}
+	discs := func(backupIDs ...int) []brain.Disc {
+		discs := []brain.Disc{{
+			Label:        "disc-1",
+			StorageGrade: "ssd",
+			Size:         51200,
+			BackupSchedules: brain.BackupSchedules{{
+				StartDate: defaultStartDate,
+				Interval:  86400,
+				Capacity:  3,
+			}},
+		}, {
+			Label:        "data",
+			StorageGrade: "archive",
+			Size:         102400,
+		}}
+		for i, id := range backupIDs {
+			discs[i].BackupID = id
+		}
+		return discs
+	}
+	finished := func(id int) brain.Backup {
+		return brain.Backup{Disc: brain.Disc{ID: id, StorageGrade: brain.ColdStorageGrade}, ParentDiscID: 41}
+	}
+	inProgress := brain.Backup{Disc: brain.Disc{ID: 99, StorageGrade: "ssd"}, ParentDiscID: 41}
+
+	tests := []struct {
+		name      string
+		args      []string
+		backups   map[string]brain.Backups
+		spec      brain.VirtualMachineSpec
+		shouldErr bool
+	}{
+		{
+			name: "reimaged",
+			args: []string{"bytemark", "clone", "server", "--force", "--root-password", "test-password", "web1", "web2"},
+			spec: brain.VirtualMachineSpec{
+				VirtualMachine: brain.VirtualMachine{
+					Name:            "web2",
+					Autoreboot:      true,
+					Cores:           2,
+					Memory:          4096,
+					ZoneName:        "york",
+					HardwareProfile: "virtio2018",
+				},
+				Discs: discs(),
+				Reimage: &brain.ImageInstall{
+					Distribution: "stretch",
+					RootPassword: "test-password",
+				},
+			},
+		}, {
+			name: "overridden by flags",
+			args: []string{"bytemark", "clone", "server", "--force", "--root-password", "test-password", "--memory", "8GiB", "--image", "buster", "--stopped", "web1", "web2"},
+			spec: brain.VirtualMachineSpec{
+				VirtualMachine: brain.VirtualMachine{
+					Name:            "web2",
+					Cores:           2,
+					Memory:          8192,
+					ZoneName:        "york",
+					HardwareProfile: "virtio2018",
+				},
+				Discs: discs(),
+				Reimage: &brain.ImageInstall{
+					Distribution: "buster",
+					RootPassword: "test-password",
+				},
+			},
+		}, {
+			name: "from backups",
+			args: []string{"bytemark", "clone", "server", "--force", "--from-backups", "web1", "web2"},
+			backups: map[string]brain.Backups{
+				"disc-1": {finished(50), finished(57), inProgress},
+				"data":   {finished(53)},
+			},
+			spec: brain.VirtualMachineSpec{
+				VirtualMachine: brain.VirtualMachine{
+					Name:            "web2",
+					Autoreboot:      true,
+					Cores:           2,
+					Memory:          4096,
+					ZoneName:        "york",
+					HardwareProfile: "virtio2018",
+				},
+				Discs: discs(57, 53),
+			},
+		}, {
+			name: "from backups without a finished backup",
+			args: []string{"bytemark", "clone", "server", "--force", "--from-backups", "web1", "web2"},
+			backups: map[string]brain.Backups{
+				"disc-1": {finished(50)},
+				"data":   {inProgress},
+			},
+			shouldErr: true,
+		}, {
+			name:      "from backups with an image",
+			args:      []string{"bytemark", "clone", "server", "--force", "--from-backups", "--image", "buster", "web1", "web2"},
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			c.When("GetVirtualMachine", srcName).Return(src, nil)
+			for label, backups := range test.backups {
+				c.When("GetBackups", srcName, label).Return(backups, nil)
+			}
+			if !test.shouldErr {
+				created := test.spec.VirtualMachine
+				created.Hostname = "web2.default.default-account.tld"
+				c.When("CreateVirtualMachine", groupName, test.spec).Return(test.spec.VirtualMachine, nil).Times(1)
+				c.When("GetVirtualMachine", newName).Return(created, nil).Times(1)
+			}
+
+			err := app.Run(test.args)
+			if test.shouldErr && err == nil {
+				t.Error("expected an error")
+			} else if !test.shouldErr && err != nil {
+				t.Error(err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
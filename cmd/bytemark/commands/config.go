@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "config",
+		Usage:       "manage bytemark client configuration profiles - see `bytemark help profiles`",
+		UsageText:   "config use-profile|copy-profile|migrate-secrets",
+		Description: "switch between and copy configuration profiles, and move secrets into the secret store. To see or change the configuration in a profile, see `bytemark show config` and `bytemark update config`.",
+		Action:      cli.ShowSubcommandHelp,
+		Subcommands: []cli.Command{
+			{
+				Name:      "use-profile",
+				Usage:     "choose the profile to use by default",
+				UsageText: "config use-profile <profile>",
+				Description: `Makes bytemark use the named profile whenever neither the --profile flag nor the BYTEMARK_PROFILE environment variable are set.
+
+The profile must already exist - profiles are created by setting some config in them, e.g. 'bytemark --profile staging update config --endpoint https://staging.bigv.io'. Use 'default' to go back to the configuration in the top level of the config directory.`,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "name",
+						Usage: "the name of the profile to use",
+					},
+				},
+				Action: app.Action(args.Optional("name"), with.RequiredFlags("name"), func(c *app.Context) error {
+					err := c.Config().UseProfile(c.String("name"))
+					if err != nil {
+						return err
+					}
+					log.Logf("Now using the %s profile\r\n", c.String("name"))
+					return nil
+				}),
+			}, {
+				Name:      "copy-profile",
+				Usage:     "make a new profile with the same configuration as another",
+				UsageText: "config copy-profile <from> <to>",
+				Description: `Creates a new profile with the same endpoints, default account and group, and other configuration as an existing one.
+
+The cached token and API key aren't copied, so you'll be asked to log in the first time you use the new profile.`,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "from",
+						Usage: "the name of the profile to copy",
+					},
+					cli.StringFlag{
+						Name:  "to",
+						Usage: "the name of the new profile",
+					},
+				},
+				Action: app.Action(args.Optional("from", "to"), with.RequiredFlags("from", "to"), func(c *app.Context) error {
+					err := c.Config().CopyProfile(c.String("from"), c.String("to"))
+					if err != nil {
+						return err
+					}
+					log.Logf("Copied the %s profile to %s\r\n", c.String("from"), c.String("to"))
+					return nil
+				}),
+			}, {
+				Name:      "migrate-secrets",
+				Usage:     "move secrets out of plain files and into the secret store",
+				UsageText: "config migrate-secrets",
+				Description: `Moves the token and API key of every profile out of the plain files they're stored in and into the secret store chosen with the secret-store config var, then removes the plain files.
+
+Set secret-store first, e.g. 'bytemark update config --secret-store secret-service'. See 'bytemark update config --help' for the available secret stores.`,
+				Action: app.Action(func(c *app.Context) error {
+					migrated, err := c.Config().MigrateSecrets()
+					for _, name := range migrated {
+						log.Logf("Moved %s into the %s secret store\r\n", name, c.Config().GetIgnoreErr("secret-store"))
+					}
+					if err != nil {
+						return err
+					}
+					if len(migrated) == 0 {
+						log.Log("There were no plaintext secrets to move")
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
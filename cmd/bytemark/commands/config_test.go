@@ -0,0 +1,86 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/config"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestConfigProfiles(t *testing.T) {
+	tests := []struct {
+		testutil.CommandT
+		setup func(conf *mocks.Config)
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "use-profile",
+				Args: "config use-profile staging",
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("UseProfile", "staging").Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "use-profile nonexistent",
+				Args:      "config use-profile nonexistent",
+				ShouldErr: true,
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("UseProfile", "nonexistent").Return(config.ProfileNotFoundError{Name: "nonexistent"}).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "use-profile without a name",
+				Args:      "config use-profile",
+				ShouldErr: true,
+			},
+			setup: func(conf *mocks.Config) {},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "copy-profile",
+				Args: "config copy-profile staging staging2",
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("CopyProfile", "staging", "staging2").Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "copy-profile over an existing profile",
+				Args:      "config copy-profile staging default",
+				ShouldErr: true,
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("CopyProfile", "staging", "default").Return(config.ProfileExistsError{Name: "default"}).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "migrate-secrets",
+				Args: "config migrate-secrets",
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("MigrateSecrets").Return([]string{"default/token", "staging/token"}, nil).Times(1)
+				conf.When("GetIgnoreErr", "secret-store").Return("pass")
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "migrate-secrets to plaintext",
+				Args:      "config migrate-secrets",
+				ShouldErr: true,
+			},
+			setup: func(conf *mocks.Config) {
+				conf.When("MigrateSecrets").Return(nil, errors.New("secret-store is set to plaintext")).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, conf *mocks.Config, c *mocks.Client, app *cli.App) {
+			test.setup(conf)
+		})
+	}
+}
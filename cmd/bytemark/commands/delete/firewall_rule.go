@@ -0,0 +1,45 @@
+package delete
+
+import (
+	"fmt"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "firewall rule",
+		Aliases:     []string{"firewall-rule"},
+		Usage:       "delete a firewall rule",
+		UsageText:   "delete firewall rule [--force] <id>",
+		Description: `Deletes the firewall rule with the given ID, whether it's attached to a group or a server. Rules and their IDs can be seen with 'bytemark show firewall'.`,
+		Flags: []cli.Flag{
+			flagsets.Force,
+			cli.IntFlag{
+				Name:  "id",
+				Usage: "the ID of the firewall rule to delete",
+			},
+		},
+		Action: app.Action(args.Optional("id"), with.RequiredFlags("id"), with.Auth, func(c *app.Context) error {
+			rule, err := c.Client().GetFirewallRule(c.Int("id"))
+			if err != nil {
+				return err
+			}
+			if !c.Bool("force") && !util.PromptYesNo(c.Prompter(), fmt.Sprintf("Are you sure you wish to delete firewall rule %s?", rule)) {
+				return util.UserRequestedExit{}
+			}
+			err = c.Client().DeleteFirewallRule(rule.ID)
+			if err != nil {
+				return err
+			}
+			log.Logf("Firewall rule #%d deleted\r\n", rule.ID)
+			return nil
+		}),
+	})
+}
@@ -0,0 +1,48 @@
+package delete
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "ip",
+		Usage:     "release an extra IP",
+		UsageText: "delete ip [--force] <ip>",
+		Description: `Releases an extra IP, so that it's no longer routed to any of your servers. Once released, the IP may be given to someone else and can't be got back.
+
+Only extra IPs (those added with 'bytemark add ips') can be released - a server's own IPs are released when the server is deleted. Extra IPs can be listed with 'bytemark show extra ips'.`,
+		Flags: []cli.Flag{
+			flagsets.Force,
+			cli.StringFlag{
+				Name:  "ip",
+				Usage: "the extra IP to release",
+			},
+		},
+		Action: app.Action(args.Optional("ip"), with.RequiredFlags("ip"), with.Auth, func(c *app.Context) error {
+			ip := net.ParseIP(c.String("ip"))
+			if ip == nil {
+				return fmt.Errorf("%q isn't an IP address", c.String("ip"))
+			}
+			if !c.Bool("force") && !util.PromptYesNo(c.Prompter(), fmt.Sprintf("Are you sure you wish to release %s? It may be given to someone else and can't be got back.", ip)) {
+				return util.UserRequestedExit{}
+			}
+			err := brainRequests.DeleteIP(c.Client(), ip)
+			if err != nil {
+				return err
+			}
+			log.Logf("Released %s\r\n", ip)
+			return nil
+		}),
+	})
+}
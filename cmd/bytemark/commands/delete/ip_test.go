@@ -0,0 +1,76 @@
+package delete_test
+
+import (
+	"strings"
+	"testing"
+
+	appPkg "github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	mock "github.com/maraino/go-mock"
+)
+
+func TestDeleteIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		command        string
+		shouldPrompt   bool
+		promptResponse string
+		shouldCall     bool
+		shouldErr      bool
+	}{{
+		name:           "no force Y",
+		command:        "delete ip 192.168.1.50",
+		shouldPrompt:   true,
+		promptResponse: "y",
+		shouldCall:     true,
+	}, {
+		name:           "no force N",
+		command:        "delete ip 192.168.1.50",
+		shouldPrompt:   true,
+		promptResponse: "n",
+		shouldErr:      true,
+	}, {
+		name:       "force",
+		command:    "delete ip --force --ip 192.168.1.50",
+		shouldCall: true,
+	}, {
+		name:      "invalid IP",
+		command:   "delete ip --force 192.168.1",
+		shouldErr: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testPrompter := mocks.Prompter{}
+			if test.shouldPrompt {
+				testPrompter.When("Prompt", mock.Any).Return(test.promptResponse).Times(1)
+			}
+
+			_, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			appPkg.SetPrompter(app, &testPrompter)
+
+			if test.shouldCall {
+				req := mocks.Request{
+					T:          t,
+					StatusCode: 200,
+				}
+				client.When("BuildRequest", "DELETE", lib.BrainEndpoint, "/ips/%s", []string{"192.168.1.50"}).Return(&req, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark "+test.command, " "))
+			if err != nil && !test.shouldErr {
+				t.Errorf("Unexpected error from app.Run: %s", err)
+			} else if err == nil && test.shouldErr {
+				t.Error("Expected error but did not get one")
+			}
+			if ok, err := client.Verify(); !ok {
+				t.Fatal(err)
+			}
+			if ok, err := testPrompter.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
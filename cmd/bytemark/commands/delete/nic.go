@@ -0,0 +1,69 @@
+package delete
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "nic",
+		Aliases:   []string{"network interface"},
+		Usage:     "remove a network interface from a server",
+		UsageText: "delete nic [--force] --server <server> --nic <label | id>",
+		Description: `Removes a network interface from the server, detaching it from its private VLAN. The network interface can be given by its label (e.g. eth1) or ID, as shown by 'bytemark show nics'.
+
+Network interfaces with IPs - like the server's public interface - can't be removed.`,
+		Flags: []cli.Flag{
+			flagsets.Force,
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to remove the network interface from",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+			cli.StringFlag{
+				Name:  "nic",
+				Usage: "the label or ID of the network interface to remove",
+			},
+		},
+		Action: app.Action(args.Optional("server", "nic"), with.RequiredFlags("server", "nic"), with.VirtualMachine("server"), func(c *app.Context) error {
+			nic, err := findNIC(*c.VirtualMachine, c.String("nic"))
+			if err != nil {
+				return err
+			}
+			if len(nic.IPs) > 0 || len(nic.ExtraIPs) > 0 {
+				return fmt.Errorf("%s can't be removed from %s because it has IPs", nic.Label, c.VirtualMachine.Name)
+			}
+			if !c.Bool("force") && !util.PromptYesNo(c.Prompter(), fmt.Sprintf("Are you sure you wish to remove %s (VLAN %d) from %s?", nic.Label, nic.VlanNum, c.VirtualMachine.Name)) {
+				return util.UserRequestedExit{}
+			}
+			err = brainRequests.DeleteNIC(c.Client(), flags.VirtualMachineName(c, "server"), nic.ID)
+			if err != nil {
+				return err
+			}
+			log.Logf("Removed %s from %s\r\n", nic.Label, c.VirtualMachine.Name)
+			return nil
+		}),
+	})
+}
+
+// findNIC finds the network interface on vm with the given label or ID.
+func findNIC(vm brain.VirtualMachine, labelOrID string) (brain.NetworkInterface, error) {
+	for _, nic := range vm.NetworkInterfaces {
+		if nic.Label == labelOrID || strconv.Itoa(nic.ID) == labelOrID {
+			return nic, nil
+		}
+	}
+	return brain.NetworkInterface{}, fmt.Errorf("%s has no network interface %s", vm.Name, labelOrID)
+}
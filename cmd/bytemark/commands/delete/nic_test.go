@@ -0,0 +1,98 @@
+package delete_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	appPkg "github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	mock "github.com/maraino/go-mock"
+)
+
+func TestDeleteNIC(t *testing.T) {
+	tests := []struct {
+		name           string
+		command        string
+		shouldPrompt   bool
+		promptResponse string
+		shouldCall     bool
+		shouldErr      bool
+	}{{
+		name:           "by label no force Y",
+		command:        "delete nic db1 eth1",
+		shouldPrompt:   true,
+		promptResponse: "y",
+		shouldCall:     true,
+	}, {
+		name:           "by label no force N",
+		command:        "delete nic db1 eth1",
+		shouldPrompt:   true,
+		promptResponse: "n",
+		shouldErr:      true,
+	}, {
+		name:       "by ID force",
+		command:    "delete nic --force --server db1 --nic 12",
+		shouldCall: true,
+	}, {
+		name:      "public interface",
+		command:   "delete nic --force db1 eth0",
+		shouldErr: true,
+	}, {
+		name:      "no such interface",
+		command:   "delete nic --force db1 eth2",
+		shouldErr: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testPrompter := mocks.Prompter{}
+			if test.shouldPrompt {
+				testPrompter.When("Prompt", mock.Any).Return(test.promptResponse).Times(1)
+			}
+
+			config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			appPkg.SetPrompter(app, &testPrompter)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			vmName := pathers.VirtualMachineName{VirtualMachine: "db1", GroupName: testutil.DefGroup}
+			client.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{
+				Name: "db1",
+				NetworkInterfaces: []brain.NetworkInterface{{
+					ID:    11,
+					Label: "eth0",
+					IPs:   brain.IPs{net.ParseIP("192.168.1.2")},
+				}, {
+					ID:      12,
+					Label:   "eth1",
+					VlanNum: 1042,
+				}},
+			}, nil)
+
+			if test.shouldCall {
+				req := mocks.Request{
+					T:          t,
+					StatusCode: 200,
+				}
+				client.When("BuildRequest", "DELETE", lib.BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/nics/%s", []string{"default-account", "default", "db1", "12"}).Return(&req, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark "+test.command, " "))
+			if err != nil && !test.shouldErr {
+				t.Errorf("Unexpected error from app.Run: %s", err)
+			} else if err == nil && test.shouldErr {
+				t.Error("Expected error but did not get one")
+			}
+			if ok, err := client.Verify(); !ok {
+				t.Fatal(err)
+			}
+			if ok, err := testPrompter.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
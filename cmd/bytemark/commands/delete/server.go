@@ -2,65 +2,95 @@ package delete
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
-	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/urfave/cli"
 )
 
 func init() {
 	Commands = append(Commands, cli.Command{
-		Name:        "server",
-		Usage:       "delete the given server",
-		UsageText:   `delete server [--purge] <server name>`,
-		Description: "Deletes the given server. Deleted servers still exist and can be restored. To ensure a server is fully deleted, use the --purge flag.",
-		Flags: []cli.Flag{
+		Name:      "server",
+		Usage:     "delete the given server",
+		UsageText: `delete server [--purge] [--group <group> [--filter <expression>]] [--parallel <n>] [<server name>...]`,
+		Description: `Deletes the given server. Deleted servers still exist and can be restored. To ensure a server is fully deleted, use the --purge flag.
+
+Many servers can be deleted at once by specifying several servers, or every server in a --group (optionally only those matching --filter, which works as in show servers). You will be asked to confirm once for all of them. Up to --parallel servers are deleted at a time, and a table of results is output.`,
+		Flags: cliutil.ConcatFlags(app.OutputFlags("results", "array"), bulk.Flags, []cli.Flag{
 			cli.BoolFlag{
 				Name:  "purge",
 				Usage: "If set, the server will be irrevocably deleted.",
 			},
 			flagsets.Force,
-			cli.GenericFlag{
-				Name:  "server",
-				Usage: "the server to delete",
-				Value: new(flags.VirtualMachineNameFlag),
-			},
-		},
-		Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.VirtualMachine("server"), func(c *app.Context) (err error) {
-			purge := c.Bool("purge")
-			vm := c.VirtualMachine
-
-			if vm.Deleted && !purge {
-				c.LogErr("Server %s has already been deleted.\r\nIf you wish to permanently delete it, add --purge", vm.Hostname)
-				// we don't return an error because we want a 0 exit code - the deletion request has happened, just not now.
-				return
-			}
-			fstr := fmt.Sprintf("Are you certain you wish to delete %s?", vm.Hostname)
-			if purge {
-				fstr = fmt.Sprintf("Are you certain you wish to permanently delete %s? You will not be able to un-delete it.", vm.Hostname)
-
-			}
-
-			if !c.Bool("force") && !util.PromptYesNo(c.Prompter(), fstr) {
-				err = util.UserRequestedExit{}
-				return
-			}
-
-			vmName := flags.VirtualMachineName(c, "server")
-			err = c.Client().DeleteVirtualMachine(vmName, purge)
+		}),
+		Action: app.Action(args.All("server"), with.Auth, func(c *app.Context) (err error) {
+			servers, err := bulk.Servers(c)
 			if err != nil {
 				return
 			}
-			if purge {
-				c.Log("Server %s purged successfully.", vm.Hostname)
-			} else {
-				c.Log("Server %s deleted successfully.", vm.Hostname)
+			purge := c.Bool("purge")
+			confirmed := c.Bool("force")
+			if !confirmed && bulk.IsBulk(c, servers) {
+				if !util.PromptYesNo(c.Prompter(), deleteServersPrompt(servers, purge)) {
+					return util.UserRequestedExit{}
+				}
+				confirmed = true
 			}
-			return
+
+			return bulk.Run(c, servers, func(c *app.Context, vmName pathers.VirtualMachineName) (string, error) {
+				return deleteServer(c, vmName, purge, confirmed)
+			})
 		}),
 	})
 }
+
+// deleteServersPrompt returns the question to ask before deleting many servers
+func deleteServersPrompt(servers []pathers.VirtualMachineName, purge bool) string {
+	names := make([]string, len(servers))
+	for i, server := range servers {
+		names[i] = server.String()
+	}
+	if purge {
+		return fmt.Sprintf("Are you certain you wish to permanently delete these %d servers? You will not be able to un-delete them.\r\n  %s\r\n", len(servers), strings.Join(names, "\r\n  "))
+	}
+	return fmt.Sprintf("Are you certain you wish to delete these %d servers?\r\n  %s\r\n", len(servers), strings.Join(names, "\r\n  "))
+}
+
+// deleteServer deletes the named server, prompting first unless confirmed is
+// set.
+func deleteServer(c *app.Context, vmName pathers.VirtualMachineName, purge bool, confirmed bool) (string, error) {
+	vm, err := c.Client().GetVirtualMachine(vmName)
+	if err != nil {
+		return "", err
+	}
+
+	if vm.Deleted && !purge {
+		// we don't return an error because we want a 0 exit code - the deletion request has happened, just not now.
+		return fmt.Sprintf("Server %s has already been deleted.\r\nIf you wish to permanently delete it, add --purge", vm.Hostname), nil
+	}
+	fstr := fmt.Sprintf("Are you certain you wish to delete %s?", vm.Hostname)
+	if purge {
+		fstr = fmt.Sprintf("Are you certain you wish to permanently delete %s? You will not be able to un-delete it.", vm.Hostname)
+
+	}
+
+	if !confirmed && !util.PromptYesNo(c.Prompter(), fstr) {
+		return "", util.UserRequestedExit{}
+	}
+
+	err = c.Client().DeleteVirtualMachine(vmName, purge)
+	if err != nil {
+		return "", err
+	}
+	if purge {
+		return fmt.Sprintf("Server %s purged successfully.", vm.Hostname), nil
+	}
+	return fmt.Sprintf("Server %s deleted successfully.", vm.Hostname), nil
+}
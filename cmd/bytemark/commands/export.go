@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/manifest"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "export",
+		Usage:     "output a manifest describing everything in an account",
+		UsageText: "export [--json] [--account <account>]",
+		Description: `Outputs a manifest describing every group, server, disc, backup schedule and privilege in the given account, or your default account if not specified. Deleted servers are not included.
+
+The manifest is output as YAML unless --json is set, and is in the format read by 'bytemark apply' - so it can be kept under version control, compared with earlier exports to find changes, or edited and applied.
+
+` + manifestText,
+		Flags: []cli.Flag{
+			cli.GenericFlag{
+				Name:  "account",
+				Usage: "the account to export",
+				Value: new(flags.AccountNameFlag),
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the manifest as JSON rather than YAML",
+			},
+		},
+		Action: app.Action(args.Optional("account"), with.Account("account"), func(c *app.Context) error {
+			m, err := manifest.Export(c.Client(), *c.Account)
+			if err != nil {
+				return err
+			}
+			if c.Bool("json") {
+				return manifest.WriteJSON(c.Writer(), m)
+			}
+			return manifest.WriteYAML(c.Writer(), m)
+		}),
+	})
+}
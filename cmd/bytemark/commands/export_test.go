@@ -0,0 +1,65 @@
+package commands_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestExport(t *testing.T) {
+	group := pathers.GroupName{Group: "test-group", Account: "test-account"}
+	vmName := pathers.VirtualMachineName{GroupName: group, VirtualMachine: "test-server"}
+	setup := func(t *testing.T, config *mocks.Config, c *mocks.Client, app *cli.App) {
+		config.When("GetIgnoreErr", "account").Return("test-account")
+		c.When("GetAccount", "test-account").Return(lib.Account{
+			Name:   "test-account",
+			Groups: brain.Groups{{Name: "test-group"}},
+		}).Times(1)
+		c.When("GetGroup", group).Return(brain.Group{
+			Name:            "test-group",
+			VirtualMachines: []brain.VirtualMachine{{Name: "test-server"}},
+		}, nil).Times(1)
+		c.When("GetPrivilegesForGroup", group).Return(brain.Privileges{}, nil).Times(1)
+		c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{
+			Name:   "test-server",
+			Cores:  2,
+			Memory: 4096,
+		}, nil).Times(1)
+		c.When("GetPrivilegesForVirtualMachine", vmName).Return(brain.Privileges{}, nil).Times(1)
+	}
+
+	tests := []testutil.CommandT{
+		{
+			Name: "yaml",
+			Args: "export",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`(?m)^account: test-account$`),
+				regexp.MustCompile(`(?m)^      name: test-server$`),
+			},
+		}, {
+			Name: "json",
+			Args: "export --json test-account",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`"memory": 4096`),
+			},
+		}, {
+			Name: "account flag",
+			Args: "export --account test-account",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`(?m)^account: test-account$`),
+			},
+		},
+	}
+	for _, test := range tests {
+		test.Auth = true
+		test.Commands = commands.Commands
+		test.Run(t, setup)
+	}
+}
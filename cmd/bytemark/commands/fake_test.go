@@ -0,0 +1,40 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+)
+
+// TestServerLifecycle runs commands against the fake brain rather than mocks,
+// to check that they work together.
+func TestServerLifecycle(t *testing.T) {
+	_, app, cleanup := testutil.FakeTestSetup(t, commands.Commands)
+	defer cleanup()
+
+	run := func(command string) string {
+		buf, err := testutil.GetBuf(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		err = app.Run(strings.Split("bytemark "+command, " "))
+		if err != nil {
+			t.Fatalf("%s: %s\n%s", command, err, buf.String())
+		}
+		return buf.String()
+	}
+
+	run("add server --force --cores 2 --memory 2 --no-image web1")
+	out := run("show servers")
+	if !strings.Contains(out, "web1") {
+		t.Errorf("expected web1 to be listed after adding it, got\n%s", out)
+	}
+	run("delete server --force --purge web1")
+	out = run("show servers")
+	if strings.Contains(out, "web1") {
+		t.Errorf("expected web1 to be gone after deleting it, got\n%s", out)
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "login",
+		Usage:     "log in to Bytemark, with your username and password or with an API key",
+		UsageText: "login [--api-key <api key>]",
+		Description: `Logs in to Bytemark and remembers the login for future commands in the current profile.
+
+Without --api-key, this forgets any token or API key that's remembered and asks for your username, password and (if needed) 2FA code. Most commands do this automatically when they need to, so this is only necessary to change who you're logged in as.
+
+With --api-key, the API key is checked with the brain, and then remembered instead of a token, so future commands use the API key and never ask for a password. This is useful for service accounts and scripts. The key's label, user, expiry and privileges are shown. An API key can also be used for a single command with the --api-key global flag or the BYTEMARK_API_KEY environment variable.
+
+API keys are made with 'bytemark add api key'. They only work with the Bytemark Cloud Servers API, so commands which need billing or domains won't work.
+
+The API key and token are kept wherever the secret-store config var says - see 'bytemark update config --help'.`,
+		Flags: append(app.OutputFlags("API key", "object"),
+			cli.StringFlag{
+				Name:  "api-key",
+				Usage: "an API key to log in with. The 'apikey.' prefix is optional",
+			},
+		),
+		Action: app.Action(func(c *app.Context) error {
+			if c.String("api-key") != "" {
+				return loginWithAPIKey(c, c.String("api-key"))
+			}
+			for _, name := range []string{"api-key", "token"} {
+				if err := c.Config().Unset(name); err != nil {
+					return err
+				}
+			}
+			if err := with.Auth(c); err != nil {
+				return err
+			}
+			log.Logf("Logged in as %s\r\n", c.Client().GetSessionUser())
+			return nil
+		}),
+	})
+}
+
+// loginWithAPIKey checks the API key with the brain, and remembers it if it's
+// valid.
+func loginWithAPIKey(c *app.Context, apiKey string) error {
+	c.Config().Set("api-key", apiKey, "FLAG api-key")
+	if err := with.Auth(c); err != nil {
+		return err
+	}
+	if err := c.Config().SetPersistent("api-key", apiKey, "LOGIN"); err != nil {
+		return err
+	}
+	if err := c.Config().Unset("token"); err != nil {
+		return err
+	}
+
+	key, ok := lib.SessionAPIKey(c.Client())
+	if !ok {
+		log.Logf("Logged in as %s with an API key\r\n", c.Client().GetSessionUser())
+		return nil
+	}
+	// no need to show the key itself to the person who just typed it in
+	key.APIKey = ""
+	log.Logf("Logged in as %s with the API key %s\r\n", c.Client().GetSessionUser(), key.Label)
+	return c.OutputInDesiredForm(key)
+}
@@ -0,0 +1,62 @@
+package commands_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		testutil.CommandT
+		setup func(conf *mocks.Config, c *mocks.Client)
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "with credentials",
+				Args: "login",
+				Auth: true,
+			},
+			setup: func(conf *mocks.Config, c *mocks.Client) {
+				conf.When("Unset", "api-key").Return(nil).Times(1)
+				conf.When("Unset", "token").Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "with an api key",
+				Args: "login --api-key abc123",
+			},
+			setup: func(conf *mocks.Config, c *mocks.Client) {
+				conf.When("Set", "api-key", "abc123", "FLAG api-key").Times(1)
+				conf.When("GetIgnoreErr", "api-key").Return("abc123")
+				conf.When("GetIgnoreErr", "impersonate").Return("")
+				c.When("AuthWithToken", "apikey.abc123").Return(nil).Times(1)
+				c.When("GetSessionUser").Return("service-account")
+				conf.When("SetPersistent", "api-key", "abc123", "LOGIN").Return(nil).Times(1)
+				conf.When("Unset", "token").Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "with an expired api key",
+				Args:      "login --api-key abc123",
+				ShouldErr: true,
+			},
+			setup: func(conf *mocks.Config, c *mocks.Client) {
+				conf.When("Set", "api-key", "abc123", "FLAG api-key").Times(1)
+				conf.When("GetIgnoreErr", "api-key").Return("abc123")
+				conf.When("GetIgnoreErr", "impersonate").Return("")
+				c.When("AuthWithToken", "apikey.abc123").Return(lib.APIKeyExpiredError{Label: "ci", ExpiresAt: "2018-01-01T00:00:00+0000"}).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, conf *mocks.Config, c *mocks.Client, app *cli.App) {
+			test.setup(conf, c)
+		})
+	}
+}
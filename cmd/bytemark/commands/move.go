@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "move",
+		Usage:     "move things between servers",
+		UsageText: "move disc <server> <disc label> <new server>",
+		Action:    cli.ShowSubcommandHelp,
+		Subcommands: []cli.Command{{
+			Name:      "disc",
+			Aliases:   []string{"disk"},
+			Usage:     "move a disc from one server to another",
+			UsageText: "move disc [--force] <server> <disc label> <new server>",
+			Description: `Detaches the disc from <server> and attaches it to <new server>, which can be any server you can administer. If <new server> already has a disc with the same label, the disc is given a new label.
+
+Both servers must be powered off - use 'bytemark shutdown server' first. Root discs can't be moved.
+
+Moving the disc may require you to update the operating system configuration of both servers. Please find documentation for moving discs at https://docs.bytemark.co.uk/article/moving-a-disc`,
+			Flags: []cli.Flag{
+				flagsets.Force,
+				cli.StringFlag{
+					Name:  "disc",
+					Usage: "the disc to move",
+				},
+				cli.GenericFlag{
+					Name:  "server",
+					Usage: "the server the disc is attached to",
+					Value: new(flags.VirtualMachineNameFlag),
+				},
+				cli.GenericFlag{
+					Name:  "new-server",
+					Usage: "the server to move the disc to",
+					Value: new(flags.VirtualMachineNameFlag),
+				},
+			},
+			Action: app.Action(args.Optional("server", "disc", "new-server"), with.RequiredFlags("server", "disc", "new-server"), with.Auth, moveDisc),
+		}},
+	})
+}
+
+func moveDisc(c *app.Context) error {
+	srcName := flags.VirtualMachineName(c, "server")
+	dstName := flags.VirtualMachineName(c, "new-server")
+
+	src, err := stoppedServer(c, srcName)
+	if err != nil {
+		return err
+	}
+	dst, err := stoppedServer(c, dstName)
+	if err != nil {
+		return err
+	}
+	if src.ID == dst.ID {
+		return fmt.Errorf("The disc is already attached to %s", srcName)
+	}
+	disc := findDisc(src, c.String("disc"))
+	if disc == nil {
+		return fmt.Errorf("%s has no disc called %s", srcName, c.String("disc"))
+	}
+
+	log.Logf("This may require an update to the operating system configuration, please find documentation for moving discs at https://docs.bytemark.co.uk/article/moving-a-disc\r\n")
+	if !flagsets.Forced(c) && !util.PromptYesNo(c.Prompter(), fmt.Sprintf("Are you certain you wish to move %s from %s to %s?", disc.Label, srcName, dstName)) {
+		return util.UserRequestedExit{}
+	}
+	log.Logf("Moving %s from %s to %s...", disc.Label, srcName, dstName)
+	err = brainRequests.MoveDisc(c.Client(), srcName, disc.Label, dstName)
+	if err != nil {
+		log.Logf("Failed!\r\n")
+		return err
+	}
+	log.Logf("Completed.\r\n")
+	return nil
+}
+
+// stoppedServer gets the named server, returning an error if it's powered on.
+func stoppedServer(c *app.Context, name pathers.VirtualMachineName) (brain.VirtualMachine, error) {
+	vm, err := c.Client().GetVirtualMachine(name)
+	if err != nil {
+		return vm, err
+	}
+	if vm.Deleted {
+		return vm, fmt.Errorf("%s has been deleted", name)
+	}
+	if vm.PowerOn {
+		return vm, fmt.Errorf("%s is powered on - discs can only be moved between servers which are powered off. Use `bytemark shutdown server %s` first", name, name)
+	}
+	return vm, nil
+}
+
+// findDisc returns the disc on vm with the given label or ID, or nil if there
+// isn't one.
+func findDisc(vm brain.VirtualMachine, labelOrID string) *brain.Disc {
+	for i, disc := range vm.Discs {
+		if disc.Label == labelOrID || fmt.Sprint(disc.ID) == labelOrID {
+			return &vm.Discs[i]
+		}
+	}
+	return nil
+}
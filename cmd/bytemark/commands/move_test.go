@@ -0,0 +1,93 @@
+package commands_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestMoveDisc(t *testing.T) {
+	srcName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: testutil.DefGroup}
+	dstName := pathers.VirtualMachineName{VirtualMachine: "web2", GroupName: testutil.DefGroup}
+	src := brain.VirtualMachine{
+		ID:   40,
+		Name: "web1",
+		Discs: []brain.Disc{
+			{ID: 41, Label: "disc-1"},
+			{ID: 42, Label: "data"},
+		},
+	}
+	dst := brain.VirtualMachine{ID: 50, Name: "web2"}
+
+	tests := []struct {
+		name      string
+		args      string
+		srcOn     bool
+		dstOn     bool
+		shouldErr bool
+	}{
+		{
+			name: "by label",
+			args: "web1 data web2",
+		}, {
+			name: "by ID with flags",
+			args: "--server web1 --disc 42 --new-server web2",
+		}, {
+			name:      "source powered on",
+			args:      "web1 data web2",
+			srcOn:     true,
+			shouldErr: true,
+		}, {
+			name:      "destination powered on",
+			args:      "web1 data web2",
+			dstOn:     true,
+			shouldErr: true,
+		}, {
+			name:      "no such disc",
+			args:      "web1 logs web2",
+			shouldErr: true,
+		}, {
+			name:      "same server",
+			args:      "web1 data web1",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			srcVM := src
+			srcVM.PowerOn = test.srcOn
+			dstVM := dst
+			dstVM.PowerOn = test.dstOn
+			c.When("GetVirtualMachine", srcName).Return(srcVM, nil)
+			c.When("GetVirtualMachine", dstName).Return(dstVM, nil)
+
+			if !test.shouldErr {
+				req := mocks.Request{
+					T:          t,
+					StatusCode: 200,
+				}
+				c.When("BuildRequest", "PUT", lib.BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/discs/%s", []string{"default-account", "default", "web1", "data"}).Return(&req, nil).Times(1)
+				defer req.AssertRequestObjectEqual(brain.Disc{VirtualMachineID: 50})
+			}
+
+			err := app.Run(strings.Split("bytemark move disc --force "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
-	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
 	"github.com/urfave/cli"
 )
@@ -16,19 +18,19 @@ func init() {
 	Commands = append(Commands, cli.Command{
 		Name:        "restart",
 		Usage:       "power off a server and start it again",
-		UsageText:   "restart server [--rescue | --appliance <appliance>] <server>",
+		UsageText:   "restart server [--rescue | --appliance <appliance>] [<server>...]",
 		Description: "This command will power down a server and then start it back up again.",
 		Action:      cli.ShowSubcommandHelp,
 		Subcommands: []cli.Command{{
 			Name:      "server",
 			Usage:     "power off a server and start it again",
-			UsageText: "restart server [--rescue | --appliance <appliance>] <server>",
-			Flags: []cli.Flag{
-				cli.GenericFlag{
-					Name:  "server",
-					Usage: "the server to restart",
-					Value: new(flags.VirtualMachineNameFlag),
-				},
+			UsageText: "restart server [--rescue | --appliance <appliance>] [--wait] [--group <group> [--filter <expression>]] [--parallel <n>] [<server>...]",
+			Description: `This command will power down a server, wait for it to power off and then start it back up again.
+
+If --wait is set, bytemark will also wait until the server is powered back on before exiting. --wait-timeout applies to each wait separately.
+
+Many servers can be restarted at once by specifying several servers, or every server in a --group (optionally only those matching --filter, which works as in show servers). Up to --parallel servers are restarted at a time, so a rolling restart can be done with --parallel 1. A table of results is output at the end.`,
+			Flags: cliutil.ConcatFlags(app.OutputFlags("results", "array"), wait.OptionalFlags, bulk.Flags, []cli.Flag{
 				cli.BoolFlag{
 					Name:  "rescue",
 					Usage: "boots the server using the rescue appliance",
@@ -37,9 +39,8 @@ func init() {
 					Name:  "appliance",
 					Usage: "the appliance to boot into when the server starts",
 				},
-			},
-			Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) (err error) {
-				vmName := flags.VirtualMachineName(c, "server")
+			}),
+			Action: app.Action(args.All("server"), with.Auth, func(c *app.Context) (err error) {
 				appliance := c.String("appliance")
 
 				if appliance != "" && c.Bool("rescue") {
@@ -50,26 +51,50 @@ func init() {
 					appliance = "rescue"
 				}
 
-				c.Log("Shutting down %v...", vmName)
-				err = c.Client().ShutdownVirtualMachine(vmName, true)
-				if err != nil {
-					return
-				}
-				err = wait.VMPowerOff(c, vmName)
+				servers, err := bulk.Servers(c)
 				if err != nil {
 					return
 				}
+				opts := wait.OptionsFromFlags(c)
+				opts.Quiet = bulk.IsBulk(c, servers)
 
-				c.Log("Done!\n\nStarting %s back up.", vmName)
-				if appliance != "" {
-					err = brainRequests.StartVirtualMachineWithAppliance(c.Client(), vmName, appliance)
-					c.Log("Server has now started. Use `bytemark console %v` or visit %v to connect.", c.String("server"), c.Config().PanelURL())
-				} else {
-					err = c.Client().StartVirtualMachine(vmName)
-				}
-
-				return
+				return bulk.Run(c, servers, func(c *app.Context, vmName pathers.VirtualMachineName) (string, error) {
+					return restartServer(c, vmName, appliance, opts)
+				})
 			}),
 		}},
 	})
 }
+
+// restartServer shuts down the server, waits for it to power off and then
+// starts it again, waiting for it to power on if --wait was set.
+func restartServer(c *app.Context, vmName pathers.VirtualMachineName, appliance string, opts wait.Options) (string, error) {
+	err := c.Client().ShutdownVirtualMachine(vmName, true)
+	if err != nil {
+		return "", err
+	}
+	err = wait.For(c, opts, wait.VMPoweredOff(vmName))
+	if err != nil {
+		return "", err
+	}
+
+	if appliance != "" {
+		err = brainRequests.StartVirtualMachineWithAppliance(c.Client(), vmName, appliance)
+	} else {
+		err = c.Client().StartVirtualMachine(vmName)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if c.Bool("wait") {
+		err = wait.For(c, opts, wait.VMPoweredOn(vmName))
+		if err != nil {
+			return "", err
+		}
+	}
+	if appliance != "" {
+		return fmt.Sprintf("%s has now started. Use `bytemark console %s` or visit %s to connect.", vmName, vmName, c.Config().PanelURL()), nil
+	}
+	return fmt.Sprintf("%s restarted successfully.", vmName), nil
+}
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "rotate",
+		Usage:     "replace credentials with new ones",
+		UsageText: "rotate api key <api key>",
+		Action:    cli.ShowSubcommandHelp,
+		Subcommands: []cli.Command{{
+			Name:      "api key",
+			Aliases:   []string{"apikey"},
+			Usage:     "replace an API key with a new one which has the same privileges",
+			UsageText: "rotate api key <api key> [--label <new label>] [--expires-at <date>] [--grace-period <duration> | --delete-old]",
+			Description: `Creates a new API key for the same user and with the same privileges as an existing one, so that the old key can be retired.
+
+<api key> may be the label or ID of the key. The new key is labelled --label, or the old key's label followed by today's date if --label isn't set. It expires at --expires-at, or never if --expires-at isn't set.
+
+By default the old key is left alone. With --grace-period, the old key is set to expire that long from now (e.g. 7d or 12h), giving time to swap the new key in wherever the old one is used. With --delete-old the old key is deleted straight away.
+
+The new key is output at the end, and can't be retrieved again later, so make sure to store it somewhere safe.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "api-key",
+					Usage: "the label or ID of the API key to rotate",
+				},
+				cli.StringFlag{
+					Name:  "label",
+					Usage: "label for the new API key",
+				},
+				cli.StringFlag{
+					Name:  "expires-at",
+					Usage: "when the new API key should expire. Leave unset for a key that never expires",
+				},
+				cli.GenericFlag{
+					Name:  "grace-period",
+					Usage: "make the old API key expire this long from now (e.g. 7d)",
+					Value: new(flags.DurationFlag),
+				},
+				cli.BoolFlag{
+					Name:  "delete-old",
+					Usage: "delete the old API key as soon as the new one is ready",
+				},
+			},
+			Action: app.Action(args.Optional("api-key"), with.RequiredFlags("api-key"), with.Auth, rotateAPIKey),
+		}},
+	})
+}
+
+func rotateAPIKey(c *app.Context) error {
+	gracePeriod := flags.Duration(c, "grace-period")
+	if gracePeriod != 0 && c.Bool("delete-old") {
+		return errors.New("--grace-period and --delete-old can't both be set")
+	}
+
+	oldKey, err := brainRequests.GetAPIKey(c.Client(), c.String("api-key"))
+	if err != nil {
+		return err
+	}
+	label := c.String("label")
+	if label == "" {
+		label = oldKey.Label + "-" + time.Now().Format("20060102")
+	}
+
+	newKey, err := brainRequests.CreateAPIKey(c.Client(), "", brain.APIKey{
+		UserID:    oldKey.UserID,
+		Label:     label,
+		ExpiresAt: c.String("expires-at"),
+	})
+	if err != nil {
+		return err
+	}
+	c.LogErr("Created %s, now copying %d privileges from %s...", newKey.Label, len(oldKey.Privileges), oldKey.Label)
+
+	failures := []string{}
+	for _, priv := range oldKey.Privileges {
+		priv.ID = 0
+		priv.APIKeyID = newKey.ID
+		if err := c.Client().GrantPrivilege(priv); err != nil {
+			failures = append(failures, fmt.Sprintf("  • %s: %s", priv, err))
+			continue
+		}
+		newKey.Privileges = append(newKey.Privileges, priv)
+	}
+	if len(failures) > 0 {
+		// leave the old key alone so nothing stops working
+		c.LogErr("Couldn't copy every privilege, so %s hasn't been changed. Here's the new API key with the privileges that were copied:", oldKey.Label)
+		if err := newKey.PrettyPrint(c.Writer(), prettyprint.Full); err != nil {
+			return err
+		}
+		return fmt.Errorf("Couldn't copy %d/%d privileges:\n%s", len(failures), len(oldKey.Privileges), strings.Join(failures, "\n"))
+	}
+
+	switch {
+	case c.Bool("delete-old"):
+		err = brainRequests.DeleteAPIKey(c.Client(), strconv.Itoa(oldKey.ID))
+		if err != nil {
+			return err
+		}
+		c.LogErr("Deleted %s", oldKey.Label)
+	case gracePeriod != 0:
+		expiresAt := time.Now().Add(gracePeriod)
+		if current, ok := oldKey.ExpiresAtTime(); ok && current.Before(expiresAt) {
+			c.LogErr("%s already expires at %s, so its expiry hasn't been changed", oldKey.Label, oldKey.ExpiresAt)
+			break
+		}
+		expiresAtStr := expiresAt.Format("2006-01-02T15:04:05-0700")
+		err = brainRequests.UpdateAPIKey(c.Client(), oldKey.ID, brain.APIKeyModification{ExpiresAt: &expiresAtStr})
+		if err != nil {
+			return err
+		}
+		c.LogErr("%s will expire at %s", oldKey.Label, expiresAtStr)
+	}
+
+	c.LogErr("Here's the new API key:")
+	return newKey.PrettyPrint(c.Writer(), prettyprint.Full)
+}
@@ -0,0 +1,102 @@
+package commands_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestRotateAPIKey(t *testing.T) {
+	oldKey := brain.APIKey{
+		ID:     12,
+		UserID: 299,
+		Label:  "ci",
+		Privileges: brain.Privileges{{
+			ID:       1001,
+			Username: "test-user",
+			Level:    brain.GroupAdminPrivilege,
+			GroupID:  11,
+			APIKeyID: 12,
+		}, {
+			ID:               1002,
+			Username:         "test-user",
+			Level:            brain.VMAdminPrivilege,
+			VirtualMachineID: 21,
+			APIKeyID:         12,
+		}},
+	}
+	newKey := brain.APIKey{
+		ID:     13,
+		UserID: 299,
+		Label:  "ci-2",
+		APIKey: "n3wk3y",
+	}
+
+	tests := []struct {
+		testutil.CommandT
+		grantErr  error
+		expectPut bool
+		expectDel bool
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "leaving the old key alone",
+				Args: "rotate api key ci --label ci-2",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`ci-2\n  Expires: never\n  Key: n3wk3y\n`),
+				},
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "with a grace period",
+				Args: "rotate api key ci --label ci-2 --grace-period 7d",
+			},
+			expectPut: true,
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "deleting the old key",
+				Args: "rotate api key ci --label ci-2 --delete-old",
+			},
+			expectDel: true,
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "when a privilege can't be copied",
+				Args:      "rotate api key ci --label ci-2 --delete-old",
+				ShouldErr: true,
+			},
+			grantErr: errors.New("no privileges for you"),
+		},
+	}
+	for _, test := range tests {
+		test.Commands = commands.Commands
+		test.Auth = true
+		getRequest := mocks.Request{StatusCode: 200, ResponseObject: brain.APIKeys{oldKey}}
+		postRequest := mocks.Request{StatusCode: 200, ResponseObject: newKey}
+		putRequest := mocks.Request{StatusCode: 200}
+		deleteRequest := mocks.Request{StatusCode: 200}
+		test.Run(t, func(t *testing.T, conf *mocks.Config, c *mocks.Client, app *cli.App) {
+			c.When("BuildRequest", "GET", lib.BrainEndpoint, "/api_keys?view=overview", []string(nil)).Return(&getRequest, nil).Times(1)
+			c.When("BuildRequest", "POST", lib.BrainEndpoint, "/api_keys", []string(nil)).Return(&postRequest, nil).Times(1)
+			for _, priv := range oldKey.Privileges {
+				priv.ID = 0
+				priv.APIKeyID = newKey.ID
+				c.When("GrantPrivilege", priv).Return(test.grantErr).Times(1)
+			}
+			if test.expectPut {
+				c.When("BuildRequest", "PUT", lib.BrainEndpoint, "/api_keys/%s", []string{"12"}).Return(&putRequest, nil).Times(1)
+			}
+			if test.expectDel {
+				c.When("BuildRequest", "DELETE", lib.BrainEndpoint, "/api_keys/%s", []string{"12"}).Return(&deleteRequest, nil).Times(1)
+			}
+		})
+		postRequest.T = t
+		postRequest.AssertRequestObjectEqual(brain.APIKey{UserID: 299, Label: "ci-2"})
+	}
+}
@@ -2,6 +2,7 @@ package show
 
 import (
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/output"
@@ -11,12 +12,20 @@ import (
 
 func init() {
 	Commands = append(Commands, cli.Command{
-		Name:        "api keys",
-		Aliases:     []string{"apikeys"},
-		Usage:       "show all your API keys",
-		UsageText:   "show api keys",
-		Description: `Shows all API keys for your user.`,
-		Flags:       app.OutputFlags("API keys", "array"),
+		Name:      "api keys",
+		Aliases:   []string{"apikeys"},
+		Usage:     "show all your API keys",
+		UsageText: "show api keys [--expiring-within <duration>]",
+		Description: `Shows all API keys for your user.
+
+--expiring-within only shows the keys which expire within the given time from now, or have already expired, which is handy for a regular job that warns about keys that need replacing with 'bytemark rotate api key'. It can be given in days or weeks as well as hours, minutes and seconds - e.g. 30d, 2w or 12h.`,
+		Flags: append(app.OutputFlags("API keys", "array"),
+			cli.GenericFlag{
+				Name:  "expiring-within",
+				Usage: "only show keys which expire within this long from now (e.g. 30d), or have already expired",
+				Value: new(flags.DurationFlag),
+			},
+		),
 		Action: app.Action(with.Auth, func(ctx *app.Context) error {
 			apiKeys, err := brainRequests.GetAPIKeys(ctx.Client())
 			if err != nil {
@@ -26,11 +35,16 @@ func init() {
 			if err != nil {
 				return err
 			}
+			expiringWithin := flags.Duration(ctx, "expiring-within")
 			myKeys := brain.APIKeys{}
 			for _, key := range apiKeys {
-				if key.UserID == user.ID {
-					myKeys = append(myKeys, key)
+				if key.UserID != user.ID {
+					continue
+				}
+				if expiringWithin != 0 && !key.ExpiresWithin(expiringWithin) {
+					continue
 				}
+				myKeys = append(myKeys, key)
 			}
 			return ctx.OutputInDesiredForm(myKeys, output.List)
 		}),
@@ -3,6 +3,7 @@ package show
 import (
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
 	"github.com/BytemarkHosting/bytemark-client/lib"
@@ -41,6 +42,35 @@ func TestShowApiKeys(t *testing.T) {
 				GroupName: "jeffgroup",
 			}},
 		}},
+	}, {
+		CommandT: testutil.CommandT{
+			Name: "only keys expiring soon are listed with --expiring-within",
+			Args: "apikeys --expiring-within 30d",
+			OutputMustMatch: []*regexp.Regexp{
+				// a header row, then only the two keys
+				regexp.MustCompile(`^[^\n]*\n[^\n]*expiring-soon[^\n]*\n[^\n]*long-gone[^\n]*\n$`),
+			},
+		},
+		user: brain.User{
+			ID:       100,
+			Username: "jeff",
+		},
+		apiKeys: brain.APIKeys{{
+			UserID: 100,
+			Label:  "never-expires",
+		}, {
+			UserID:    100,
+			Label:     "expiring-soon",
+			ExpiresAt: time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02T15:04:05-0700"),
+		}, {
+			UserID:    100,
+			Label:     "long-gone",
+			ExpiresAt: "2006-01-01T01:01:01-0000",
+		}, {
+			UserID:    100,
+			Label:     "expiring-later",
+			ExpiresAt: time.Now().Add(90 * 24 * time.Hour).Format("2006-01-02T15:04:05-0700"),
+		}},
 	}}
 	for _, test := range tests {
 		test.CommandT.Commands = Commands
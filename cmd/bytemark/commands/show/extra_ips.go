@@ -0,0 +1,39 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "extra ips",
+		Usage:     "show all the extra IPs in an account, and the servers they're routed to",
+		UsageText: "show extra ips [--account <account>]",
+		Description: `This command shows every extra IP routed to the servers in the given account (or your default account), along with the server IP each is routed to.
+
+Extra IPs can be added with 'bytemark add ips', moved to another server with 'bytemark update extra ip' and released with 'bytemark delete ip'.`,
+		Flags: append(app.OutputFlags("extra IPs", "array"),
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "the account to show the extra IPs of",
+			},
+		),
+		Action: app.Action(args.Optional("account"), with.Account("account"), func(c *app.Context) error {
+			ips := brain.ExtraIPs{}
+			for _, group := range c.Account.Groups {
+				for _, vm := range group.VirtualMachines {
+					if vm.Deleted {
+						continue
+					}
+					ips = append(ips, vm.ExtraIPs()...)
+				}
+			}
+			return c.OutputInDesiredForm(ips, output.Table)
+		}),
+	})
+}
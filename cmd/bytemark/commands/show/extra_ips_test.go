@@ -0,0 +1,74 @@
+package show_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+func TestShowExtraIPs(t *testing.T) {
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	config.When("GetIgnoreErr", "account").Return("default-account")
+
+	server := func(name string, deleted bool, extraIPs map[string]net.IP) brain.VirtualMachine {
+		return brain.VirtualMachine{
+			Name:     name,
+			Hostname: name + ".default.failover.uk0.bigv.io",
+			Deleted:  deleted,
+			NetworkInterfaces: []brain.NetworkInterface{{
+				ExtraIPs: extraIPs,
+			}},
+		}
+	}
+	c.When("GetAccount", "failover").Return(lib.Account{
+		Groups: []brain.Group{{
+			Name: "default",
+			VirtualMachines: brain.VirtualMachines{
+				server("lb1", false, map[string]net.IP{
+					"192.168.1.51": net.ParseIP("192.168.1.2"),
+					"192.168.1.50": net.ParseIP("192.168.1.2"),
+				}),
+				server("lb2", false, map[string]net.IP{}),
+				server("old-lb", true, map[string]net.IP{
+					"192.168.1.52": net.ParseIP("192.168.1.4"),
+				}),
+			},
+		}, {
+			Name: "mail",
+			VirtualMachines: brain.VirtualMachines{
+				server("mx1", false, map[string]net.IP{
+					"fe80::50": net.ParseIP("fe80::5"),
+				}),
+			},
+		}},
+	}).Times(1)
+
+	err := app.Run(strings.Split("bytemark show extra ips --account failover --json", " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+	buf, err := testutil.GetBuf(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{"192.168.1.50", "192.168.1.51", "fe80::50", "lb1.default.failover", "mx1.default.failover"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "192.168.1.52") {
+		t.Errorf("output contained the deleted server's extra IP\n%s", out)
+	}
+	if strings.Index(out, "192.168.1.50") > strings.Index(out, "192.168.1.51") {
+		t.Errorf("expected the extra IPs to be sorted\n%s", out)
+	}
+}
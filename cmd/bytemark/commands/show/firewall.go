@@ -0,0 +1,54 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "firewall",
+		Usage:     "show the firewall rules of a group or server",
+		UsageText: "show firewall (--group <group> | --server <server>)",
+		Description: `This command shows the firewall rules attached to the given group, or all the rules which apply to the given server - its group's rules followed by its own.
+
+If a server has no rules at all, all incoming traffic is allowed. Rules can be added with 'bytemark add firewall rule' and removed by ID with 'bytemark delete firewall rule'.`,
+		Flags: append(app.OutputFlags("firewall rules", "array"),
+			cli.GenericFlag{
+				Name:  "group",
+				Usage: "the group to show the firewall rules of",
+				Value: new(flags.GroupNameFlag),
+			},
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to show the firewall rules of",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+		),
+		Action: app.Action(with.Auth, func(c *app.Context) error {
+			if c.IsSet("group") == c.IsSet("server") {
+				return c.Help("Exactly one of --group and --server must be specified")
+			}
+			if c.IsSet("group") {
+				rules, err := c.Client().GetGroupFirewallRules(flags.GroupName(c, "group"))
+				if err != nil {
+					return err
+				}
+				return c.OutputInDesiredForm(rules, output.Table)
+			}
+			server := flags.VirtualMachineName(c, "server")
+			rules, err := c.Client().GetGroupFirewallRules(server.GroupName)
+			if err != nil {
+				return err
+			}
+			serverRules, err := c.Client().GetServerFirewallRules(server)
+			if err != nil {
+				return err
+			}
+			return c.OutputInDesiredForm(append(rules, serverRules...), output.Table)
+		}),
+	})
+}
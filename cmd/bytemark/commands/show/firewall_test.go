@@ -0,0 +1,71 @@
+package show_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func TestShowFirewall(t *testing.T) {
+	groupRules := brain.FirewallRules{{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", Label: "office"}}
+	serverRules := brain.FirewallRules{{ID: 13, Protocol: "udp", Ports: "8000-8080"}}
+
+	tests := []struct {
+		name       string
+		args       string
+		serverCall bool
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:       "group",
+			args:       "--group default",
+			expected:   []string{"192.0.2.0/24", "office"},
+			unexpected: []string{"8000-8080"},
+		}, {
+			name:       "server",
+			args:       "--server web1",
+			serverCall: true,
+			expected:   []string{"192.0.2.0/24", "office", "udp", "8000-8080"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			config.When("GetGroup").Return(testutil.DefGroup)
+
+			c.When("GetGroupFirewallRules", testutil.DefGroup).Return(groupRules, nil).Times(1)
+			if test.serverCall {
+				server := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: testutil.DefGroup}
+				c.When("GetServerFirewallRules", server).Return(serverRules, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark show firewall "+test.args, " "))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+			buf, err := testutil.GetBuf(app)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range test.expected {
+				if !strings.Contains(buf.String(), expected) {
+					t.Errorf("expected output to contain %q\n%s", expected, buf.String())
+				}
+			}
+			for _, unexpected := range test.unexpected {
+				if strings.Contains(buf.String(), unexpected) {
+					t.Errorf("expected output not to contain %q\n%s", unexpected, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "ips",
+		Usage:       "show all the IPs of a server, with their reverse DNS",
+		UsageText:   "show ips --server <server>",
+		Description: `This command shows all the IPv4 and IPv6 addresses of the given server - including extra IPs routed to it - along with the reverse DNS (PTR record) of each. Reverse DNS can be changed with 'bytemark update ip' or 'bytemark update rdns'.`,
+		Flags: append(app.OutputFlags("IPs", "array"),
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server whose IPs you wish to list",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+		),
+		Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.VirtualMachine("server"), func(c *app.Context) error {
+			ips := append(c.VirtualMachine.AllIPv4Addresses(), c.VirtualMachine.AllIPv6Addresses()...)
+			records := make(brain.IPRecords, 0, len(ips))
+			for _, ip := range ips {
+				record, err := brainRequests.GetIP(c.Client(), ip)
+				if err != nil {
+					return err
+				}
+				records = append(records, record)
+			}
+			return c.OutputInDesiredForm(records, output.List)
+		}),
+	})
+}
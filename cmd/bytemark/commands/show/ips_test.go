@@ -0,0 +1,66 @@
+package show_test
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestShowIPs(t *testing.T) {
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+	name := pathers.VirtualMachineName{
+		VirtualMachine: "mail",
+		GroupName: pathers.GroupName{
+			Group:   "default",
+			Account: "default-account",
+		},
+	}
+	c.When("GetVirtualMachine", name).Return(brain.VirtualMachine{
+		ID:   4,
+		Name: "mail",
+		NetworkInterfaces: []brain.NetworkInterface{{
+			IPs:      brain.IPs{net.ParseIP("192.168.1.2"), net.ParseIP("fe80::2")},
+			ExtraIPs: map[string]net.IP{"192.168.1.50": net.ParseIP("192.168.1.2")},
+		}},
+	}, nil).Times(1)
+	records := map[string]string{
+		"192.168.1.2":  "mail.example.com",
+		"192.168.1.50": "smtp.example.com",
+		"fe80::2":      "mail6.example.com",
+	}
+	for ip, rdns := range records {
+		req := mocks.Request{
+			T:              t,
+			StatusCode:     200,
+			ResponseObject: brain.IP{IP: net.ParseIP(ip), RDns: rdns},
+		}
+		c.When("BuildRequest", "GET", lib.BrainEndpoint, "/ips/%s", []string{ip}).Return(&req, nil).Times(1)
+	}
+
+	err := app.Run(strings.Split("bytemark show ips mail", " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+	buf, err := testutil.GetBuf(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, rdns := range records {
+		if !regexp.MustCompile(regexp.QuoteMeta(ip) + ` +` + regexp.QuoteMeta(rdns)).MatchString(buf.String()) {
+			t.Errorf("expected %s %s in the output, got:\n%s", ip, rdns, buf.String())
+		}
+	}
+}
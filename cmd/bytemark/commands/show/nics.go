@@ -0,0 +1,31 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "nics",
+		Aliases:     []string{"network interfaces"},
+		Usage:       "show the network interfaces of a server",
+		UsageText:   "show nics --server <server>",
+		Description: `This command shows the network interfaces of the given server, along with the VLAN each is attached to and its IPs. Network interfaces on private VLANs can be added with 'bytemark add nic' and removed with 'bytemark delete nic'.`,
+		Flags: append(app.OutputFlags("network interfaces", "array"),
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server whose network interfaces you wish to list",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+		),
+		Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.VirtualMachine("server"), func(c *app.Context) error {
+			return c.OutputInDesiredForm(brain.NetworkInterfaces(c.VirtualMachine.NetworkInterfaces), output.Table)
+		}),
+	})
+}
@@ -0,0 +1,51 @@
+package show_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func TestShowNICs(t *testing.T) {
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+	name := pathers.VirtualMachineName{VirtualMachine: "db1", GroupName: testutil.DefGroup}
+	c.When("GetVirtualMachine", name).Return(brain.VirtualMachine{
+		Name: "db1",
+		NetworkInterfaces: []brain.NetworkInterface{{
+			ID:      11,
+			Label:   "eth0",
+			Mac:     "fe:ff:00:00:00:0b",
+			VlanNum: 1,
+			IPs:     brain.IPs{net.ParseIP("192.168.1.2")},
+		}, {
+			ID:      12,
+			Label:   "eth1",
+			Mac:     "fe:ff:00:00:00:0c",
+			VlanNum: 1042,
+		}},
+	}, nil).Times(1)
+
+	err := app.Run(strings.Split("bytemark show nics db1", " "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+	buf, err := testutil.GetBuf(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"eth0", "fe:ff:00:00:00:0b", "192.168.1.2", "eth1", "1042"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected output to contain %q\n%s", expected, buf.String())
+		}
+	}
+}
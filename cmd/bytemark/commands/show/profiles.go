@@ -0,0 +1,24 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "profiles",
+		Usage:       "show the bytemark client's configuration profiles",
+		UsageText:   "show profiles",
+		Description: "Lists all the configuration profiles, marking the one in use with a *. See `bytemark help profiles` for more about profiles.",
+		Flags:       app.OutputFlags("profiles", "array"),
+		Action: app.Action(func(c *app.Context) error {
+			profiles, err := c.Config().Profiles()
+			if err != nil {
+				return err
+			}
+			return c.OutputInDesiredForm(profiles, output.List)
+		}),
+	})
+}
@@ -0,0 +1,31 @@
+package show_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/config"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestShowProfiles(t *testing.T) {
+	test := testutil.CommandT{
+		Name:     "show profiles",
+		Args:     "show profiles",
+		Commands: commands.Commands,
+		OutputMustMatch: []*regexp.Regexp{
+			regexp.MustCompile(`default +https://uk0.bigv.io +alice`),
+			regexp.MustCompile(`true +staging +https://staging.bigv.io +bob +bobs-account`),
+		},
+	}
+	test.Run(t, func(t *testing.T, conf *mocks.Config, c *mocks.Client, app *cli.App) {
+		conf.When("GetV", "output-format").Return(config.Var{Name: "output-format", Value: "table", Source: "FLAG output-format"})
+		conf.When("Profiles").Return(config.Profiles{
+			{Name: "default", Endpoint: "https://uk0.bigv.io", User: "alice"},
+			{Name: "staging", Current: true, Endpoint: "https://staging.bigv.io", User: "bob", Account: "bobs-account"},
+		}, nil).Times(1)
+	})
+}
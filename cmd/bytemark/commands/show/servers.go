@@ -1,12 +1,11 @@
 package show
 
 import (
-	"errors"
-
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util/filter"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/output"
 	"github.com/urfave/cli"
@@ -16,11 +15,26 @@ func init() {
 	Commands = append(Commands, cli.Command{
 		Name:      "servers",
 		Usage:     "show all the servers in an account",
-		UsageText: "show servers [--group <group> | --account <account>] [group]",
-		Description: `This command shows all the servers in the given account, or in your default account if not specified.
+		UsageText: "show servers [--group <group> | --account <account>] [--filter <expression>] [--sort <fields>] [group]",
+		Description: `This command shows all the servers in the given group, or in every group of the given account. If neither is specified, all the servers in your default account are shown.
 Deleted servers are included in the list, with ' (deleted)' appended.
 
-If --group and --account are specified, the group will be displayed and the account will be ignored.`,
+If --group and --account are specified, the group will be displayed and the account will be ignored.
+
+--filter picks out servers using an expression which compares the servers' fields to values. Fields are named as in the JSON output (zone_name) or the table output (ZoneName), nested fields are separated by dots, and comparisons are combined with and, or, not and brackets. The comparisons are =, !=, <, <=, >, >=, and ~ and !~ which match regular expressions. A field on its own is true if it's not false, zero or empty. When a field is a list, like discs, the comparison is true if it's true for any disc. Memory and disc sizes are in MiB, but values can be given with a unit like 500GiB.
+
+--sort sorts the servers by a comma-separated list of fields. Prefix a field with - to sort in descending order.
+
+EXAMPLES
+
+Show powered-off servers with more than 4 cores in Manchester:
+    bytemark show servers --filter 'not power_on and cores > 4 and zone_name = manchester'
+
+Show servers with a disc over 500GiB, biggest memory first:
+    bytemark show servers --filter 'discs.size > 500GiB' --sort -memory,hostname
+
+Show the names of servers whose hostnames start with web, which have an IP in 192.168.1.0/24:
+    bytemark show servers --filter "hostname ~ '^web' and network_interfaces.ips ~ '^192\.168\.1\.'" --table-fields Hostname`,
 		Flags: append(app.OutputFlags("servers", "array"),
 			cli.GenericFlag{
 				Name:  "group",
@@ -32,8 +46,20 @@ If --group and --account are specified, the group will be displayed and the acco
 				Name:  "account",
 				Usage: "the account to show all the servers of",
 			},
+			cli.StringFlag{
+				Name:  "filter",
+				Usage: "only show servers matching this expression, e.g. 'cores > 4 and not power_on'",
+			},
+			cli.StringFlag{
+				Name:  "sort",
+				Usage: "a comma-separated list of fields to sort the servers by, e.g. 'zone_name,-memory'",
+			},
 		),
 		Action: app.Action(args.Optional("group"), with.Auth, func(c *app.Context) error {
+			f, err := parseServerFilter(c)
+			if err != nil {
+				return err
+			}
 			servers := brain.VirtualMachines{}
 			if c.IsSet("group") {
 				groupName := flags.GroupName(c, "group")
@@ -41,9 +67,8 @@ If --group and --account are specified, the group will be displayed and the acco
 				if err != nil {
 					return err
 				}
-				return c.OutputInDesiredForm(brain.VirtualMachines(group.VirtualMachines), output.List)
-			}
-			if c.IsSet("account") {
+				servers = group.VirtualMachines
+			} else {
 				err := with.Account("account")(c)
 				if err != nil {
 					return err
@@ -51,9 +76,37 @@ If --group and --account are specified, the group will be displayed and the acco
 				for _, g := range c.Account.Groups {
 					servers = append(servers, g.VirtualMachines...)
 				}
-				return c.OutputInDesiredForm(servers, output.List)
 			}
-			return errors.New("A group or account must be specified")
+
+			if f != nil {
+				filtered, err := f.Apply(servers)
+				if err != nil {
+					return err
+				}
+				servers = filtered.(brain.VirtualMachines)
+			}
+			if sortBy := c.String("sort"); sortBy != "" {
+				err := filter.Sort(servers, sortBy)
+				if err != nil {
+					return err
+				}
+			}
+			return c.OutputInDesiredForm(servers, output.List)
 		}),
 	})
 }
+
+// parseServerFilter parses the --filter flag and checks it makes sense for
+// servers, so that mistakes are pointed out before fetching anything. If
+// --filter wasn't specified, nil is returned.
+func parseServerFilter(c *app.Context) (*filter.Filter, error) {
+	expr := c.String("filter")
+	if expr == "" {
+		return nil, nil
+	}
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &f, f.Validate(brain.VirtualMachine{})
+}
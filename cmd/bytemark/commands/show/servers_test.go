@@ -1,6 +1,7 @@
 package show_test
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 
@@ -9,7 +10,9 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/lib"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
 	"github.com/cheekybits/is"
+	"github.com/urfave/cli"
 )
 
 func TestShowServers(t *testing.T) {
@@ -59,3 +62,77 @@ func TestShowServers(t *testing.T) {
 		}
 	})
 }
+
+func TestShowServersFilterAndSort(t *testing.T) {
+	account := lib.Account{
+		Name: "spooky-steve",
+		Groups: []brain.Group{{
+			Name: "default",
+			VirtualMachines: []brain.VirtualMachine{
+				{ID: 1, Name: "old-man-crumbles", Hostname: "old-man-crumbles.default", Cores: 8, ZoneName: "manchester"},
+				{ID: 23, Name: "jack-skellington", Hostname: "jack-skellington.default", Cores: 2, ZoneName: "manchester", PowerOn: true},
+			},
+		}, {
+			Name: "ghosts",
+			VirtualMachines: []brain.VirtualMachine{
+				{ID: 3, Name: "zero", Hostname: "zero.ghosts", Cores: 6, ZoneName: "york", Discs: brain.Discs{{Size: 1024000}}},
+				{ID: 4, Name: "sally", Hostname: "sally.ghosts", Cores: 4, ZoneName: "manchester"},
+			},
+		}},
+	}
+
+	tests := []struct {
+		testutil.CommandT
+		getAccount bool
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "default account, filtered and sorted",
+				Args: "show servers --filter cores>=4&&!power_on --sort -cores --table-fields Hostname",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`(?s)old-man-crumbles.*zero.*sally`),
+				},
+			},
+			getAccount: true,
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "nested fields",
+				Args: "show servers --account spooky-steve --filter discs.size>500GiB --json",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`"name": "zero"`),
+				},
+			},
+			getAccount: true,
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "bad filter",
+				Args:      "show servers --filter cores>>4",
+				ShouldErr: true,
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "unknown field",
+				Args:      "show servers --filter colour=red",
+				ShouldErr: true,
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "sort by a list",
+				Args:      "show servers --sort discs.size",
+				ShouldErr: true,
+			},
+			getAccount: true,
+		},
+	}
+	for _, test := range tests {
+		test.Auth = true
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, config *mocks.Config, client *mocks.Client, app *cli.App) {
+			config.When("GetIgnoreErr", "account").Return("spooky-steve")
+			config.When("GetGroup").Return(testutil.DefGroup)
+			if test.getAccount {
+				client.When("GetAccount", "spooky-steve").Return(account).Times(1)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package show
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "vm defaults",
+		Aliases:   []string{"vmdefaults"},
+		Usage:     "show the VM defaults you can use to make servers",
+		UsageText: "show vm defaults [--public | --account <account>]",
+		Description: `Shows the VM defaults you can see - the public ones, and those belonging to accounts you have privileges on.
+
+VM defaults are templates for servers, and can be used with 'bytemark add server --from-default'. To see the full spec of one, use 'bytemark show vm default'.`,
+		Flags: append(app.OutputFlags("VM defaults", "array"),
+			cli.BoolFlag{
+				Name:  "public",
+				Usage: "only show public VM defaults",
+			},
+			cli.GenericFlag{
+				Name:  "account",
+				Usage: "only show VM defaults belonging to this account",
+				Value: new(flags.AccountNameFlag),
+			},
+		),
+		Action: app.Action(with.Auth, func(c *app.Context) error {
+			vmds, err := brainRequests.GetVMDefaults(c.Client())
+			if err != nil {
+				return err
+			}
+			accountID := 0
+			if c.IsSet("account") {
+				account, err := c.Client().GetAccount(flags.AccountName(c, "account").AccountName)
+				if err != nil {
+					return err
+				}
+				accountID = account.BrainID
+			}
+			shown := brain.VirtualMachineDefaults{}
+			for _, vmd := range vmds {
+				if c.Bool("public") && !vmd.Public {
+					continue
+				}
+				if accountID != 0 && vmd.AccountID != accountID {
+					continue
+				}
+				shown = append(shown, vmd)
+			}
+			return c.OutputInDesiredForm(shown, output.List)
+		}),
+	}, cli.Command{
+		Name:        "vm default",
+		Aliases:     []string{"vmdefault"},
+		Usage:       "show the spec of a VM default",
+		UsageText:   "show vm default <name or ID>",
+		Description: `Shows the server spec of a VM default. If more than one VM default you can see has the same name, use its ID instead.`,
+		Flags: append(app.OutputFlags("VM default", "object"),
+			cli.StringFlag{
+				Name:  "default-name",
+				Usage: "the name or ID of the VM default to show",
+			},
+		),
+		Action: app.Action(args.Optional("default-name"), with.RequiredFlags("default-name"), with.Auth, func(c *app.Context) error {
+			vmd, err := brainRequests.GetVMDefault(c.Client(), c.String("default-name"))
+			if err != nil {
+				return err
+			}
+			return c.OutputInDesiredForm(vmd)
+		}),
+	})
+}
@@ -0,0 +1,67 @@
+package show
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestShowVMDefaults(t *testing.T) {
+	vmds := brain.VirtualMachineDefaults{{
+		ID:     12,
+		Name:   "public-web",
+		Public: true,
+	}, {
+		ID:        13,
+		AccountID: 4,
+		Name:      "my-db",
+		ServerSettings: brain.VirtualMachineSpec{
+			VirtualMachine: brain.VirtualMachine{Cores: 2, Memory: 4096},
+			Reimage:        &brain.ImageInstall{Distribution: "stretch"},
+		},
+	}}
+	tests := []testutil.CommandT{
+		{
+			Name: "all of them",
+			Args: "vmdefaults",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`^[^\n]*\n[^\n]*public-web[^\n]*\n[^\n]*my-db[^\n]*\n$`),
+			},
+		}, {
+			Name: "only public ones",
+			Args: "vmdefaults --public",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`^[^\n]*\n[^\n]*public-web[^\n]*\n$`),
+			},
+		}, {
+			Name: "one in full",
+			Args: "vmdefault my-db",
+			OutputMustMatch: []*regexp.Regexp{
+				regexp.MustCompile(`my-db \(ID: #13\)`),
+				regexp.MustCompile(`Specs: 2 cores and 4GiB memory`),
+				regexp.MustCompile(`Image: stretch`),
+			},
+		}, {
+			Name:      "missing",
+			Args:      "vmdefault nope",
+			ShouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		test.Commands = Commands
+		test.Auth = true
+		mockRequest := mocks.Request{
+			StatusCode:     200,
+			ResponseObject: vmds,
+		}
+		test.Run(t, func(t *testing.T, config *mocks.Config, client *mocks.Client, app *cli.App) {
+			config.When("GetIgnoreErr", "account").Return("default-account")
+			client.When("BuildRequest", "GET", lib.BrainEndpoint, "/vm_defaults", []string(nil)).Return(&mockRequest, nil).Times(1)
+		})
+	}
+}
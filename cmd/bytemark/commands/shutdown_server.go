@@ -6,6 +6,7 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 	"github.com/urfave/cli"
 )
 
@@ -20,14 +21,14 @@ func init() {
 			Name:        "server",
 			Usage:       "cleanly shut down a server",
 			UsageText:   "shutdown server <server>",
-			Description: "This command sends the ACPI shutdown signal to the server, causing a clean shut down. This is like pressing the power button on a computer you have physical access to.",
-			Flags: []cli.Flag{
+			Description: "This command sends the ACPI shutdown signal to the server, causing a clean shut down. This is like pressing the power button on a computer you have physical access to.\n\nbytemark waits for the server to power off before exiting - see --wait-timeout.",
+			Flags: cliutil.ConcatFlags(wait.Flags, []cli.Flag{
 				cli.GenericFlag{
 					Name:  "server",
 					Usage: "the server to shutdown",
 					Value: new(flags.VirtualMachineNameFlag),
 				},
-			},
+			}),
 			Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) (err error) {
 				vmName := flags.VirtualMachineName(c, "server")
 				c.Log("Shutting down %v...", vmName)
@@ -36,7 +37,7 @@ func init() {
 					return
 				}
 
-				err = wait.VMPowerOff(c, vmName)
+				err = wait.For(c, wait.OptionsFromFlags(c), wait.VMPoweredOff(vmName))
 				if err != nil {
 					return
 				}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "ssh",
+		Usage:     "log in to a server with ssh",
+		UsageText: "ssh [--user <user>] [--ipv6] [--ssh-args <args>] <server> [-- <command>...]",
+		Description: `Connects to the server's primary IP address with ssh, as root unless --user is set. Anything after -- is run on the server as a command, rather than starting a shell.
+
+The server's first IPv4 address is used, unless it has none or --ipv6 is set, in which case its first IPv6 address is used.
+
+--ssh-args are passed to ssh before the server's address, e.g. --ssh-args '-i ~/.ssh/bytemark -A'. You must have an ssh client on your computer.
+
+To use plain ssh (or scp, rsync and so on) with server names instead, see 'bytemark ssh-config'.`,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "ipv6",
+				Usage: "connect to the server's IPv6 address",
+			},
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to connect to",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+			cli.StringFlag{
+				Name:  "ssh-args",
+				Usage: "arguments that will be passed to ssh",
+			},
+			cli.StringFlag{
+				Name:  "user",
+				Usage: "the user to log in as",
+				Value: "root",
+			},
+		},
+		Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) error {
+			vm, err := c.Client().GetVirtualMachine(flags.VirtualMachineName(c, "server"))
+			if err != nil {
+				return err
+			}
+			command := c.Args()
+			if len(command) > 0 && command[0] == "--" {
+				command = command[1:]
+			}
+			sshArgs, err := sshCommandLine(c.String("user"), c.String("ssh-args"), vm, c.Bool("ipv6"), command)
+			if err != nil {
+				return err
+			}
+			return execSSH(sshArgs)
+		}),
+	})
+}
+
+// sshAddress returns the IP address to ssh to vm on - its first IPv4
+// address, or first IPv6 address if it has no IPv4 or ipv6 is true.
+func sshAddress(vm brain.VirtualMachine, ipv6 bool) (net.IP, error) {
+	if !ipv6 {
+		if ips := vm.AllIPv4Addresses(); len(ips) > 0 {
+			return ips[0], nil
+		}
+	}
+	if ips := vm.AllIPv6Addresses(); len(ips) > 0 {
+		return ips[0], nil
+	}
+	if ipv6 {
+		return nil, fmt.Errorf("%s has no IPv6 addresses", vm.Hostname)
+	}
+	return nil, fmt.Errorf("%s has no IP addresses", vm.Hostname)
+}
+
+// sshCommandLine returns the arguments to run ssh with (including "ssh" as
+// the first) to connect to vm as user and run command, if it isn't empty.
+func sshCommandLine(user string, sshArgs string, vm brain.VirtualMachine, ipv6 bool, command []string) ([]string, error) {
+	ip, err := sshAddress(vm, ipv6)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"ssh"}
+	if sshArgs != "" {
+		args = append(args, collectArgs(sshArgs)...)
+	}
+	host := ip.String()
+	if user != "" {
+		host = user + "@" + host
+	}
+	args = append(args, host)
+	if len(command) > 0 {
+		args = append(args, "--")
+		args = append(args, command...)
+	}
+	return args, nil
+}
+
+// execSSH replaces bytemark with ssh, run with args.
+func execSSH(args []string) error {
+	bin, err := exec.LookPath("ssh")
+	if err != nil {
+		return err
+	}
+	log.Debugf(5, "%+v\r\n", args)
+
+	/* #nosec */
+	return syscall.Exec(bin, args, os.Environ())
+}
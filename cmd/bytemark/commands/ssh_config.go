@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "ssh-config",
+		Usage:     "output OpenSSH configuration for servers",
+		UsageText: "ssh-config [--group <group> | --account <account>] [--user <user>] [--ipv6] [--file <path>] [group]",
+		Description: `Outputs a block of OpenSSH configuration for each server in the given group, or in every group of the given account. If neither is specified, all the servers in your default account are included. Deleted servers and servers with no IP addresses are left out.
+
+Each block is named after the server's name.group.account, and sets HostName to the server's first IPv4 address (or IPv6 address, if --ipv6 is set or the server has no IPv4) and User to --user. Once it's included, 'ssh web1.default.myaccount' (or scp, rsync and so on) will connect to that server.
+
+If --file is set then the configuration is written to that file instead of being output, replacing whatever was there. Run it again whenever servers are added or removed, and add a line like the following to the top of ~/.ssh/config to use it:
+    Include ~/.ssh/bytemark_config
+
+EXAMPLES
+
+    bytemark ssh-config --account myaccount --file ~/.ssh/bytemark_config`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "account",
+				Usage: "the account to output configuration for all the servers of",
+			},
+			cli.StringFlag{
+				Name:  "file",
+				Usage: "a file to write the configuration to, rather than outputting it",
+			},
+			cli.GenericFlag{
+				Name:  "group",
+				Usage: "the group to output configuration for the servers of",
+				Value: new(flags.GroupNameFlag),
+			},
+			cli.BoolFlag{
+				Name:  "ipv6",
+				Usage: "prefer the servers' IPv6 addresses",
+			},
+			cli.StringFlag{
+				Name:  "user",
+				Usage: "the user to log in to the servers as",
+				Value: "root",
+			},
+		},
+		Action: app.Action(args.Optional("group"), with.Auth, func(c *app.Context) error {
+			servers := brain.VirtualMachines{}
+			if c.IsSet("group") {
+				group, err := c.Client().GetGroup(flags.GroupName(c, "group"))
+				if err != nil {
+					return err
+				}
+				servers = group.VirtualMachines
+			} else {
+				err := with.Account("account")(c)
+				if err != nil {
+					return err
+				}
+				for _, g := range c.Account.Groups {
+					servers = append(servers, g.VirtualMachines...)
+				}
+			}
+
+			file := c.String("file")
+			if file == "" {
+				return writeSSHConfig(c.Writer(), servers, c.String("user"), c.Bool("ipv6"))
+			}
+			buf := bytes.Buffer{}
+			err := writeSSHConfig(&buf, servers, c.String("user"), c.Bool("ipv6"))
+			if err != nil {
+				return err
+			}
+			return replaceFile(file, buf.Bytes())
+		}),
+	})
+}
+
+// writeSSHConfig writes a Host block for each of servers to wr.
+func writeSSHConfig(wr io.Writer, servers brain.VirtualMachines, user string, ipv6 bool) error {
+	_, err := fmt.Fprintf(wr, "# Generated by bytemark ssh-config - any changes will be lost when it's next run.\n")
+	if err != nil {
+		return err
+	}
+	for _, vm := range servers {
+		if vm.Deleted {
+			continue
+		}
+		ip, addrErr := sshAddress(vm, ipv6)
+		if addrErr != nil {
+			continue
+		}
+		_, err = fmt.Fprintf(wr, "\nHost %s\n    HostName %s\n", vm.FullName(), ip)
+		if err != nil {
+			return err
+		}
+		if user != "" {
+			_, err = fmt.Fprintf(wr, "    User %s\n", user)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replaceFile writes contents to a new file next to path, then moves it over
+// path - so that ssh never reads a half-written config.
+func replaceFile(path string, contents []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package commands_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func TestSSHConfig(t *testing.T) {
+	nic := func(ips ...string) []brain.NetworkInterface {
+		netIPs := brain.IPs{}
+		for _, ip := range ips {
+			netIPs = append(netIPs, net.ParseIP(ip))
+		}
+		return []brain.NetworkInterface{{IPs: netIPs}}
+	}
+	defaultGroup := brain.Group{
+		Name: "default",
+		VirtualMachines: []brain.VirtualMachine{{
+			Name:              "web1",
+			Hostname:          "web1.default.test-account.uk0.bigv.io",
+			NetworkInterfaces: nic("fe80::1", "192.168.1.2"),
+		}, {
+			Name:              "old",
+			Hostname:          "old.default.test-account.uk0.bigv.io",
+			Deleted:           true,
+			NetworkInterfaces: nic("192.168.1.3"),
+		}},
+	}
+	dbGroup := brain.Group{
+		Name: "db",
+		VirtualMachines: []brain.VirtualMachine{{
+			Name:              "db1",
+			Hostname:          "db1.db.test-account.uk0.bigv.io",
+			NetworkInterfaces: nic("fe80::4"),
+		}, {
+			Name:     "blank",
+			Hostname: "blank.db.test-account.uk0.bigv.io",
+		}},
+	}
+
+	t.Run("group", func(t *testing.T) {
+		config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+		config.When("GetGroup").Return(testutil.DefGroup)
+		config.When("GetIgnoreErr", "account").Return("test-account")
+		c.When("GetGroup", pathers.GroupName{Group: "default", Account: "test-account"}).Return(defaultGroup, nil).Times(1)
+
+		err := app.Run([]string{"bytemark", "ssh-config", "--user", "deploy", "default.test-account"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := c.Verify(); !ok {
+			t.Fatal(err)
+		}
+		buf, err := testutil.GetBuf(app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `# Generated by bytemark ssh-config - any changes will be lost when it's next run.
+
+Host web1.default.test-account
+    HostName 192.168.1.2
+    User deploy
+`
+		if buf.String() != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+		}
+	})
+
+	t.Run("account to file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "ssh-config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "bytemark_config")
+		err = ioutil.WriteFile(file, []byte("Host stale\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+		config.When("GetGroup").Return(testutil.DefGroup)
+		config.When("GetIgnoreErr", "account").Return("test-account")
+		c.When("GetAccount", "test-account").Return(lib.Account{
+			Name:   "test-account",
+			Groups: brain.Groups{defaultGroup, dbGroup},
+		}, nil).Times(1)
+
+		err = app.Run([]string{"bytemark", "ssh-config", "--file", file})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := c.Verify(); !ok {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := `# Generated by bytemark ssh-config - any changes will be lost when it's next run.
+
+Host web1.default.test-account
+    HostName 192.168.1.2
+    User root
+
+Host db1.db.test-account
+    HostName fe80::4
+    User root
+`
+		if string(contents) != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, contents)
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil || len(files) != 1 {
+			t.Errorf("expected only bytemark_config in %s, got %v (%v)", dir, files, err)
+		}
+	})
+}
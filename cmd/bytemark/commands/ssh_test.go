@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+func TestSSHCommandLine(t *testing.T) {
+	dualStack := brain.VirtualMachine{
+		Hostname: "web1.default.test-account.uk0.bigv.io",
+		NetworkInterfaces: []brain.NetworkInterface{{
+			IPs:      brain.IPs{net.ParseIP("fe80::1"), net.ParseIP("192.168.1.2")},
+			ExtraIPs: map[string]net.IP{"192.168.1.50": net.ParseIP("192.168.1.2")},
+		}},
+	}
+	v6Only := brain.VirtualMachine{
+		Hostname: "web2.default.test-account.uk0.bigv.io",
+		NetworkInterfaces: []brain.NetworkInterface{{
+			IPs: brain.IPs{net.ParseIP("fe80::2")},
+		}},
+	}
+	noIPs := brain.VirtualMachine{Hostname: "web3.default.test-account.uk0.bigv.io"}
+
+	tests := []struct {
+		name      string
+		user      string
+		sshArgs   string
+		vm        brain.VirtualMachine
+		ipv6      bool
+		command   []string
+		expected  []string
+		shouldErr bool
+	}{
+		{
+			name:     "IPv4",
+			user:     "root",
+			vm:       dualStack,
+			expected: []string{"ssh", "root@192.168.1.2"},
+		}, {
+			name:     "IPv6",
+			user:     "root",
+			vm:       dualStack,
+			ipv6:     true,
+			expected: []string{"ssh", "root@fe80::1"},
+		}, {
+			name:     "IPv6 only",
+			user:     "admin",
+			vm:       v6Only,
+			expected: []string{"ssh", "admin@fe80::2"},
+		}, {
+			name:     "no user",
+			vm:       dualStack,
+			expected: []string{"ssh", "192.168.1.2"},
+		}, {
+			name:     "ssh args and command",
+			user:     "root",
+			sshArgs:  "-i 'a long path/id_rsa' -A",
+			vm:       dualStack,
+			command:  []string{"ls", "-l", "/"},
+			expected: []string{"ssh", "-i", "a long path/id_rsa", "-A", "root@192.168.1.2", "--", "ls", "-l", "/"},
+		}, {
+			name:      "no IPs",
+			user:      "root",
+			vm:        noIPs,
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := sshCommandLine(test.user, test.sshArgs, test.vm, test.ipv6, test.command)
+			if test.shouldErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, args)
+			}
+		})
+	}
+}
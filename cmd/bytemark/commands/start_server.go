@@ -4,7 +4,9 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 	"github.com/urfave/cli"
 )
 
@@ -19,14 +21,14 @@ func init() {
 			Name:        "server",
 			Usage:       "start a stopped server",
 			UsageText:   "start server <server>",
-			Description: "This command will start a server that is not currently running.",
-			Flags: []cli.Flag{
+			Description: "This command will start a server that is not currently running. If --wait is set, bytemark will wait until the server is powered on before exiting.",
+			Flags: cliutil.ConcatFlags(wait.OptionalFlags, []cli.Flag{
 				cli.GenericFlag{
 					Name:  "server",
 					Usage: "the server to start",
 					Value: new(flags.VirtualMachineNameFlag),
 				},
-			},
+			}),
 			Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) (err error) {
 				vmName := flags.VirtualMachineName(c, "server")
 				c.Log("Attempting to start %s...", vmName)
@@ -34,6 +36,10 @@ func init() {
 				if err != nil {
 					return
 				}
+				err = wait.IfRequested(c, wait.VMPoweredOn(vmName))
+				if err != nil {
+					return
+				}
 
 				c.Log("%s started successfully.", vmName)
 				return
@@ -6,6 +6,7 @@ import (
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/cheekybits/is"
 )
@@ -25,3 +26,21 @@ func TestStartCommand(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestStartCommandWait(t *testing.T) {
+	is := is.New(t)
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	vmn := pathers.VirtualMachineName{VirtualMachine: "test-server", GroupName: pathers.GroupName{Group: "test-group", Account: "test-account"}}
+
+	config.When("GetVirtualMachine").Return(pathers.VirtualMachineName{})
+
+	c.When("StartVirtualMachine", vmn).Times(1)
+	c.When("GetVirtualMachine", vmn).Return(brain.VirtualMachine{}, nil).Times(1)
+	c.When("GetVirtualMachine", vmn).Return(brain.VirtualMachine{PowerOn: true}, nil).Times(1)
+
+	err := app.Run(strings.Split("bytemark start server --wait test-server.test-group.test-account", " "))
+	is.Nil(err)
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
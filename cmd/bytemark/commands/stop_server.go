@@ -1,10 +1,14 @@
 package commands
 
 import (
+	"fmt"
+
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
-	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/urfave/cli"
 )
 
@@ -12,32 +16,30 @@ func init() {
 	Commands = append(Commands, cli.Command{
 		Name:        "stop",
 		Usage:       "stop a server, as though pulling the power cable out",
-		UsageText:   "stop server <server>",
+		UsageText:   "stop server [<server>...]",
 		Description: "This command will instantly power down a server. Note that this may cause data loss, particularly on servers with unjournaled file systems (e.g. ext2)",
 		Action:      cli.ShowSubcommandHelp,
 		Subcommands: []cli.Command{{
-			Name:        "server",
-			Usage:       "stop a server, as though pulling the power cable out",
-			UsageText:   "stop server <server>",
-			Description: "This command will instantly power down a server. Note that this may cause data loss, particularly on servers with unjournaled file systems (e.g. ext2)",
+			Name:      "server",
+			Usage:     "stop a server, as though pulling the power cable out",
+			UsageText: "stop server [--group <group> [--filter <expression>]] [--parallel <n>] [<server>...]",
+			Description: `This command will instantly power down a server. Note that this may cause data loss, particularly on servers with unjournaled file systems (e.g. ext2)
+
+Many servers can be stopped at once by specifying several servers, or every server in a --group (optionally only those matching --filter, which works as in show servers). Up to --parallel servers are stopped at a time, and a table of results is output.`,
 
-			Flags: []cli.Flag{
-				cli.GenericFlag{
-					Name:  "server",
-					Usage: "the server to stop",
-					Value: new(flags.VirtualMachineNameFlag),
-				},
-			},
-			Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) (err error) {
-				vmName := flags.VirtualMachineName(c, "server")
-				c.Log("Attempting to stop %s...", vmName)
-				err = c.Client().StopVirtualMachine(vmName)
+			Flags: cliutil.ConcatFlags(app.OutputFlags("results", "array"), bulk.Flags),
+			Action: app.Action(args.All("server"), with.Auth, func(c *app.Context) error {
+				servers, err := bulk.Servers(c)
 				if err != nil {
-					return
+					return err
 				}
-
-				c.Log("%s stopped successfully.", vmName)
-				return
+				return bulk.Run(c, servers, func(c *app.Context, vmName pathers.VirtualMachineName) (string, error) {
+					err := c.Client().StopVirtualMachine(vmName)
+					if err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("%s stopped successfully.", vmName), nil
+				})
 			}),
 		}},
 	})
@@ -1,13 +1,18 @@
 package commands_test
 
 import (
+	"errors"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
 	"github.com/cheekybits/is"
+	"github.com/urfave/cli"
 )
 
 func TestStopServerCommand(t *testing.T) {
@@ -27,3 +32,119 @@ func TestStopServerCommand(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestStopServerBulk(t *testing.T) {
+	group := pathers.GroupName{Group: "web", Account: "test-account"}
+	vmName := func(name string) pathers.VirtualMachineName {
+		return pathers.VirtualMachineName{VirtualMachine: name, GroupName: group}
+	}
+	webGroup := brain.Group{
+		Name: "web",
+		VirtualMachines: []brain.VirtualMachine{
+			{Name: "web1"},
+			{Name: "web2"},
+			{Name: "db1"},
+			{Name: "web3", Deleted: true},
+		},
+	}
+
+	tests := []struct {
+		testutil.CommandT
+		setup func(c *mocks.Client)
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "many servers",
+				Args: "stop server web1.web.test-account web2.web.test-account",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`web1\.web\.test-account \| true +\| web1\.web\.test-account stopped successfully\.`),
+					regexp.MustCompile(`web2\.web\.test-account \| true +\| web2\.web\.test-account stopped successfully\.`),
+				},
+			},
+			setup: func(c *mocks.Client) {
+				c.When("StopVirtualMachine", vmName("web1")).Return(nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web2")).Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "group",
+				Args: "stop server --group web.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(webGroup, nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web1")).Return(nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web2")).Return(nil).Times(1)
+				c.When("StopVirtualMachine", vmName("db1")).Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "group with filter",
+				Args: "stop server --group web.test-account --filter name~^web --parallel 1",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(webGroup, nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web1")).Return(nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web2")).Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "one fails",
+				Args:      "stop server --group web.test-account --filter name~^web",
+				ShouldErr: true,
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`web1\.web\.test-account \| true `),
+					regexp.MustCompile(`web2\.web\.test-account \| false +\| it broke`),
+				},
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(webGroup, nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web1")).Return(nil).Times(1)
+				c.When("StopVirtualMachine", vmName("web2")).Return(errors.New("it broke")).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "filter matches nothing",
+				Args:      "stop server --group web.test-account --filter name~^mail",
+				ShouldErr: true,
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(webGroup, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "servers with filter",
+				Args: "stop server --filter cores>1 web1.web.test-account web2.web.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName("web1")).Return(brain.VirtualMachine{Name: "web1", Cores: 1}).Times(1)
+				c.When("GetVirtualMachine", vmName("web2")).Return(brain.VirtualMachine{Name: "web2", Cores: 2}).Times(1)
+				c.When("StopVirtualMachine", vmName("web2")).Return(nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "invalid filter",
+				Args:      "stop server --group web.test-account --filter colour=red",
+				ShouldErr: true,
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetGroup", group).Return(webGroup, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "no servers",
+				Args:      "stop server",
+				ShouldErr: true,
+			},
+			setup: func(c *mocks.Client) {},
+		},
+	}
+	for _, test := range tests {
+		test.Auth = true
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, config *mocks.Config, client *mocks.Client, app *cli.App) {
+			config.When("GetVirtualMachine").Return(pathers.VirtualMachineName{})
+			config.When("GetGroup").Return(pathers.GroupName{})
+			test.setup(client)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package update
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "api key",
+		Aliases:   []string{"apikey"},
+		Usage:     "change the label or expiry of an API key",
+		UsageText: "update api key <api key> [--label <new label>] [--expires-at <date> | --never-expires]",
+		Description: `Changes the label or expiry date of an API key, leaving its privileges and the key itself the same.
+
+<api key> may be the label or ID of the key. --expires-at may be set to any date format the Brain accepts, but we generally recommend ISO8601 format.
+
+To change what an API key can access, use 'bytemark grant' and 'bytemark revoke' with --api-key-id. To replace the key itself, use 'bytemark rotate api key'.`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "api-key",
+				Usage: "the label or ID of the API key to update",
+			},
+			cli.StringFlag{
+				Name:  "label",
+				Usage: "a new label for the API key",
+			},
+			cli.StringFlag{
+				Name:  "expires-at",
+				Usage: "when the API key should expire",
+			},
+			cli.BoolFlag{
+				Name:  "never-expires",
+				Usage: "make the API key never expire",
+			},
+		},
+		Action: app.Action(args.Optional("api-key"), with.RequiredFlags("api-key"), with.Auth, func(c *app.Context) error {
+			mod, err := apiKeyModificationFromFlags(c)
+			if err != nil {
+				return err
+			}
+			apiKey, err := brainRequests.GetAPIKey(c.Client(), c.String("api-key"))
+			if err != nil {
+				return err
+			}
+			err = brainRequests.UpdateAPIKey(c.Client(), apiKey.ID, mod)
+			if err != nil {
+				return err
+			}
+			apiKey, err = brainRequests.GetAPIKey(c.Client(), strconv.Itoa(apiKey.ID))
+			if err != nil {
+				return err
+			}
+			c.LogErr("Updated the API key:")
+			return apiKey.PrettyPrint(c.Writer(), prettyprint.Full)
+		}),
+	})
+}
+
+func apiKeyModificationFromFlags(c *app.Context) (mod brain.APIKeyModification, err error) {
+	if c.IsSet("label") {
+		label := c.String("label")
+		if label == "" {
+			return mod, errors.New("--label can't be blank")
+		}
+		mod.Label = &label
+	}
+	if c.IsSet("expires-at") && c.Bool("never-expires") {
+		return mod, errors.New("--expires-at and --never-expires can't both be set")
+	}
+	if c.IsSet("expires-at") {
+		expiresAt := c.String("expires-at")
+		mod.ExpiresAt = &expiresAt
+	} else if c.Bool("never-expires") {
+		never := ""
+		mod.ExpiresAt = &never
+	}
+	if mod.Label == nil && mod.ExpiresAt == nil {
+		return mod, errors.New("Nothing to change - specify --label, --expires-at or --never-expires")
+	}
+	return mod, nil
+}
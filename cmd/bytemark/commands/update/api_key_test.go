@@ -0,0 +1,97 @@
+package update_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestUpdateAPIKey(t *testing.T) {
+	apiKey := brain.APIKey{
+		ID:        12,
+		Label:     "ci",
+		ExpiresAt: "2030-01-01T00:00:00+0000",
+	}
+	tests := []struct {
+		testutil.CommandT
+		// gets is how many times the API keys are listed
+		gets int
+		// expected is the request body for the PUT. nil means no PUT should
+		// happen.
+		expected map[string]interface{}
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "label",
+				Args: "update api key ci --label ci-new",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`ci`),
+				},
+			},
+			gets:     2,
+			expected: map[string]interface{}{"label": "ci-new"},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "expires-at",
+				Args: "update api key 12 --expires-at 2031-01-01",
+			},
+			gets:     2,
+			expected: map[string]interface{}{"expires_at": "2031-01-01"},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "never-expires",
+				Args: "update api key ci --label ci-forever --never-expires",
+			},
+			gets:     2,
+			expected: map[string]interface{}{"label": "ci-forever", "expires_at": nil},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "nothing to change",
+				Args:      "update api key ci",
+				ShouldErr: true,
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "expires-at and never-expires",
+				Args:      "update api key ci --expires-at 2031-01-01 --never-expires",
+				ShouldErr: true,
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "blank label",
+				Args:      "update api key ci --label=",
+				ShouldErr: true,
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "no such key",
+				Args:      "update api key nope --label ci-new",
+				ShouldErr: true,
+			},
+			gets: 1,
+		},
+	}
+	for _, test := range tests {
+		test.Commands = commands.Commands
+		test.Auth = true
+		putRequest := mocks.Request{T: t, StatusCode: 200}
+		test.Run(t, func(t *testing.T, conf *mocks.Config, c *mocks.Client, app *cli.App) {
+			if test.gets > 0 {
+				getRequest := mocks.Request{T: t, StatusCode: 200, ResponseObject: brain.APIKeys{apiKey}}
+				c.When("BuildRequest", "GET", lib.BrainEndpoint, "/api_keys?view=overview", []string(nil)).Return(&getRequest, nil).Times(test.gets)
+			}
+			if test.expected != nil {
+				c.When("BuildRequest", "PUT", lib.BrainEndpoint, "/api_keys/%s", []string{"12"}).Return(&putRequest, nil).Times(1)
+			}
+		})
+		if test.expected != nil {
+			putRequest.AssertRequestObjectEqual(test.expected)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
@@ -61,10 +62,23 @@ var configVariables = configVars{
 		name:        "token",
 		description: "token used for authentication",
 	},
+	{
+		name:        "api-key",
+		description: "API key used for authentication instead of a token",
+	},
 	{
 		name:        "user",
 		description: "user that you log in as by default",
 	},
+	{
+		name:        "secret-store",
+		description: "place to keep the token and API key - " + strings.Join(config.SecretStores, ", "),
+		validate:    validateSecretStoreForConfig,
+	},
+	{
+		name:        "age-identity",
+		description: "age identity file used by the age secret store",
+	},
 	{
 		name:        "account",
 		description: "default account",
@@ -145,6 +159,15 @@ func validateGroupForConfig(c *app.Context, name string) (err error) {
 	return
 }
 
+func validateSecretStoreForConfig(c *app.Context, name string) error {
+	for _, store := range config.SecretStores {
+		if name == store {
+			return nil
+		}
+	}
+	return config.UnknownSecretStoreError{Name: name}
+}
+
 func validateEndpointForConfigFunc(variable string) func(*app.Context, string) error {
 	return func(c *app.Context, endpoint string) error {
 		url, err := url.Parse(endpoint)
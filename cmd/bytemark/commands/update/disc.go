@@ -24,7 +24,9 @@ func init() {
 
 Resizes the given disc to the given size. Sizes may be specified with a + in front, in which case they are interpreted as relative. For example, '+2GB' is parsed as 'increase the disc size by 2GiB', where '2GB' is parsed as 'set the size of the disc to 2GiB'
 
-Moving the disc to another server may require you to update your operating system configuration. Both servers must be shutdown and root discs cannot be moved. Please find documentation for moving discs at https://docs.bytemark.co.uk/article/moving-a-disc`,
+Moving the disc to another server may require you to update your operating system configuration. Both servers must be shutdown and root discs cannot be moved. Please find documentation for moving discs at https://docs.bytemark.co.uk/article/moving-a-disc
+
+'bytemark move disc' also moves discs, and checks that both servers are powered off first.`,
 		Flags: []cli.Flag{
 			flagsets.Force,
 			cli.StringFlag{
@@ -0,0 +1,84 @@
+package update
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "extra ip",
+		Usage:     "route an extra IP to a different server",
+		UsageText: "update extra ip --ip <ip> --route-to <ip | server>",
+		Description: `Changes where an extra IP is routed to. --route-to can be one of a server's own IPs, or the name of a server - in which case the extra IP is routed to its first IP of the same version (IPv4 or IPv6). The server can be any server you can administer, so this can be used to move a failover IP between servers.
+
+Extra IPs can be listed with 'bytemark show extra ips'. Remember that the server the IP is moved to will need to be configured to accept traffic for it.
+
+EXAMPLES
+
+    bytemark update extra ip --ip 192.0.2.50 --route-to lb2.default`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "ip",
+				Usage: "the extra IP to route somewhere else",
+			},
+			cli.StringFlag{
+				Name:  "route-to",
+				Usage: "the IP or server to route the extra IP to",
+			},
+		},
+		Action: app.Action(args.Optional("ip", "route-to"), with.RequiredFlags("ip", "route-to"), with.Auth, func(c *app.Context) error {
+			ip, err := parseIP(c.String("ip"))
+			if err != nil {
+				return err
+			}
+			routeTo, err := routeTarget(c, ip, c.String("route-to"))
+			if err != nil {
+				return err
+			}
+			err = brainRequests.RouteExtraIP(c.Client(), ip, routeTo)
+			if err != nil {
+				return err
+			}
+			log.Logf("%s is now routed to %s\r\n", ip, routeTo)
+			return nil
+		}),
+	})
+}
+
+// routeTarget works out the IP to route ip to from --route-to, which is either
+// an IP or the name of a server. For a server, its first IP of the same
+// version as ip is used.
+func routeTarget(c *app.Context, ip net.IP, routeTo string) (net.IP, error) {
+	if target := net.ParseIP(routeTo); target != nil {
+		return target, nil
+	}
+	name, err := lib.ParseVirtualMachineName(routeTo, c.Config().GetVirtualMachine())
+	if err != nil {
+		return nil, err
+	}
+	vm, err := c.Client().GetVirtualMachine(name)
+	if err != nil {
+		return nil, err
+	}
+	ipv4 := ip.To4() != nil
+	for _, nic := range vm.NetworkInterfaces {
+		for _, nicIP := range nic.IPs {
+			if (nicIP.To4() != nil) == ipv4 {
+				return nicIP, nil
+			}
+		}
+	}
+	if ipv4 {
+		return nil, fmt.Errorf("%s has no IPv4 addresses to route %s to", name, ip)
+	}
+	return nil, fmt.Errorf("%s has no IPv6 addresses to route %s to", name, ip)
+}
@@ -0,0 +1,85 @@
+package update_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestUpdateExtraIP(t *testing.T) {
+	lb2 := pathers.VirtualMachineName{
+		VirtualMachine: "lb2",
+		GroupName: pathers.GroupName{
+			Group:   "default",
+			Account: "default-account",
+		},
+	}
+	tests := []struct {
+		name      string
+		args      string
+		ip        string
+		routeTo   string
+		shouldErr bool
+	}{
+		{
+			name:    "to an IP",
+			args:    "--ip 192.168.1.50 --route-to 192.168.1.3",
+			ip:      "192.168.1.50",
+			routeTo: "192.168.1.3",
+		}, {
+			name:    "IPv4 to a server",
+			args:    "192.168.1.50 lb2",
+			ip:      "192.168.1.50",
+			routeTo: "192.168.1.3",
+		}, {
+			name:    "IPv6 to a server",
+			args:    "--ip fe80::50 --route-to lb2.default",
+			ip:      "fe80::50",
+			routeTo: "fe80::3",
+		}, {
+			name:      "invalid IP",
+			args:      "--ip 192.168.1 --route-to lb2",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			client.When("GetVirtualMachine", lb2).Return(brain.VirtualMachine{
+				Name: "lb2",
+				NetworkInterfaces: []brain.NetworkInterface{{
+					IPs: brain.IPs{net.ParseIP("192.168.1.3"), net.ParseIP("fe80::3")},
+				}},
+			}, nil)
+
+			req := mocks.Request{
+				T:          t,
+				StatusCode: 200,
+			}
+			if !test.shouldErr {
+				client.When("BuildRequest", "PUT", lib.BrainEndpoint, "/ips/%s", []string{test.ip}).Return(&req, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark update extra ip "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := client.Verify(); !ok {
+				t.Fatal(err)
+			}
+			if !test.shouldErr {
+				req.AssertRequestObjectEqual(map[string]string{"routed_to": test.routeTo})
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package update
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "ip",
+		Usage:     "set the reverse DNS of an IP",
+		UsageText: "update ip --ip <ip> --rdns <hostname>",
+		Description: `Sets the reverse DNS (PTR record) of one of your servers' IPs - either one of its own IPs or an extra IP routed to it. Mail servers in particular usually need their reverse DNS to match the name they use.
+
+The current reverse DNS of a server's IPs can be seen with 'bytemark show ips'. To set the reverse DNS of many IPs at once, see 'bytemark update rdns'.`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "ip",
+				Usage: "the IP to change the reverse DNS of",
+			},
+			cli.StringFlag{
+				Name:  "rdns",
+				Usage: "the hostname to set as the IP's reverse DNS",
+			},
+		},
+		Action: app.Action(args.Optional("ip", "rdns"), with.RequiredFlags("ip", "rdns"), with.Auth, func(c *app.Context) error {
+			ip, err := parseIP(c.String("ip"))
+			if err != nil {
+				return err
+			}
+			rdns := c.String("rdns")
+			err = validateRDNS(rdns)
+			if err != nil {
+				return err
+			}
+			err = brainRequests.UpdateIPRDNS(c.Client(), ip, rdns)
+			if err != nil {
+				return err
+			}
+			log.Logf("Reverse DNS for %s set to %s\r\n", ip, rdns)
+			return nil
+		}),
+	})
+}
+
+// parseIP parses an IP address, returning an error if it isn't one.
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return nil, fmt.Errorf("%q isn't an IP address", s)
+	}
+	return ip, nil
+}
+
+var hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// validateRDNS returns an error if rdns isn't a hostname which could be used
+// as reverse DNS. A trailing dot is allowed.
+func validateRDNS(rdns string) error {
+	name := strings.TrimSuffix(rdns, ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("%q isn't a valid hostname for reverse DNS", rdns)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return fmt.Errorf("%q isn't a valid hostname for reverse DNS", rdns)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package update_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func TestUpdateIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      string
+		ip        string
+		rdns      string
+		shouldErr bool
+	}{
+		{
+			name: "IPv4",
+			args: "--ip 192.168.1.2 --rdns mail.example.com",
+			ip:   "192.168.1.2",
+			rdns: "mail.example.com",
+		}, {
+			name: "IPv6 with trailing dot",
+			args: "fe80::2 mail.example.com.",
+			ip:   "fe80::2",
+			rdns: "mail.example.com.",
+		}, {
+			name:      "invalid IP",
+			args:      "--ip 192.168.1 --rdns mail.example.com",
+			shouldErr: true,
+		}, {
+			name:      "invalid hostname",
+			args:      "--ip 192.168.1.2 --rdns mail_server.example.com",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+
+			req := mocks.Request{
+				T:          t,
+				StatusCode: 200,
+			}
+			if !test.shouldErr {
+				client.When("BuildRequest", "PUT", lib.BrainEndpoint, "/ips/%s", []string{test.ip}).Return(&req, nil).Times(1)
+			}
+
+			err := app.Run(strings.Split("bytemark update ip "+test.args, " "))
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok, err := client.Verify(); !ok {
+				t.Fatal(err)
+			}
+			if !test.shouldErr {
+				req.AssertRequestObjectEqual(map[string]string{"rdns": test.rdns})
+			}
+		})
+	}
+}
+
+func TestUpdateRDNS(t *testing.T) {
+	groupName := pathers.GroupName{Group: "default", Account: "default-account"}
+	mail1 := pathers.VirtualMachineName{VirtualMachine: "mail1", GroupName: groupName}
+	mail2 := pathers.VirtualMachineName{VirtualMachine: "mail2", GroupName: groupName}
+	vms := map[pathers.VirtualMachineName]brain.VirtualMachine{
+		mail1: {
+			ID:   1,
+			Name: "mail1",
+			NetworkInterfaces: []brain.NetworkInterface{{
+				IPs:      brain.IPs{net.ParseIP("192.168.1.2"), net.ParseIP("fe80::2")},
+				ExtraIPs: map[string]net.IP{"192.168.1.50": net.ParseIP("192.168.1.2")},
+			}},
+		},
+		mail2: {
+			ID:   2,
+			Name: "mail2",
+			NetworkInterfaces: []brain.NetworkInterface{{
+				IPs: brain.IPs{net.ParseIP("192.168.1.3")},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		updates     map[string]string
+		shouldErr   bool
+		errContains string
+	}{
+		{
+			name: "success",
+			file: `server,ip,rdns
+# the main mail server
+mail1,192.168.1.2,mail1.example.com
+mail1,fe80::2,mail1.example.com
+mail1.default,192.168.1.50,smtp.example.com
+mail2.default.default-account,192.168.1.3,mail2.example.com
+`,
+			updates: map[string]string{
+				"192.168.1.2":  "mail1.example.com",
+				"fe80::2":      "mail1.example.com",
+				"192.168.1.50": "smtp.example.com",
+				"192.168.1.3":  "mail2.example.com",
+			},
+		}, {
+			name: "IP on another server",
+			file: `mail1,192.168.1.2,mail1.example.com
+mail2,192.168.1.50,smtp.example.com
+`,
+			shouldErr: true,
+		}, {
+			name: "invalid line",
+			file: `mail1,192.168.1.2,mail1.example.com
+
+mail2,192.168.1.3
+`,
+			shouldErr:   true,
+			errContains: "line 3",
+		}, {
+			name:      "invalid hostname",
+			file:      "mail1,192.168.1.2,-mail1.example.com\n",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "bytemark-update-rdns")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			file := filepath.Join(dir, "mapping.csv")
+			err = ioutil.WriteFile(file, []byte(test.file), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+			config.When("GetVirtualMachine").Return(testutil.DefVM)
+			for name, vm := range vms {
+				client.When("GetVirtualMachine", name).Return(vm, nil)
+			}
+			reqs := map[string]*mocks.Request{}
+			for ip := range test.updates {
+				reqs[ip] = &mocks.Request{
+					T:          t,
+					StatusCode: 200,
+				}
+				client.When("BuildRequest", "PUT", lib.BrainEndpoint, "/ips/%s", []string{ip}).Return(reqs[ip], nil).Times(1)
+			}
+
+			err = app.Run([]string{"bytemark", "update", "rdns", "-f", file})
+			if test.shouldErr && err == nil {
+				t.Fatal("should error")
+			} else if !test.shouldErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil && !strings.Contains(err.Error(), test.errContains) {
+				t.Errorf("expected the error to contain %q, got %q", test.errContains, err)
+			}
+			if ok, err := client.Verify(); !ok {
+				t.Fatal(err)
+			}
+			for ip, rdns := range test.updates {
+				reqs[ip].AssertRequestObjectEqual(map[string]string{"rdns": rdns})
+			}
+		})
+	}
+}
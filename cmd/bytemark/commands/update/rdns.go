@@ -0,0 +1,183 @@
+package update
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/urfave/cli"
+)
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:      "rdns",
+		Usage:     "set the reverse DNS of many IPs from a CSV file",
+		UsageText: "update rdns --file <mapping.csv>",
+		Description: `Sets the reverse DNS of many IPs at once. The file should be CSV with three columns - the server, one of the server's IPs (or an extra IP routed to it) and the hostname to set as that IP's reverse DNS. Lines starting with # are ignored, as is a first line of 'server,ip,rdns'.
+
+Every line is checked before any changes are made - if any server can't be found, any IP doesn't belong to its server or any hostname is invalid then all the problems are listed and nothing is changed. Otherwise a table of results is output.
+
+EXAMPLE FILE
+
+    server,ip,rdns
+    mail1.default,192.0.2.10,mail1.example.com
+    mail1.default,2001:db8::10,mail1.example.com
+    mail2.default,192.0.2.11,mail2.example.com`,
+		Flags: append(app.OutputFlags("results", "array"),
+			cli.StringFlag{
+				Name:  "file, f",
+				Usage: "the CSV file of server,ip,rdns lines",
+			},
+		),
+		Action: app.Action(args.Optional("file"), with.RequiredFlags("file"), with.Auth, func(c *app.Context) error {
+			file, err := os.Open(c.String("file"))
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			mappings, problems := readRDNSMappings(file, c.Config().GetVirtualMachine())
+			problems = append(problems, checkRDNSMappings(c.Client(), mappings)...)
+			if len(problems) > 0 {
+				return fmt.Errorf("No reverse DNS was changed because of the following problems with %s:\n%s", c.String("file"), strings.Join(problems, "\n"))
+			}
+
+			results := make(bulk.Results, len(mappings))
+			for i, m := range mappings {
+				results[i] = bulk.Result{Server: m.server.String()}
+				err := brainRequests.UpdateIPRDNS(c.Client(), m.ip, m.rdns)
+				if err != nil {
+					results[i].Message = fmt.Sprintf("%s: %s", m.ip, err)
+					continue
+				}
+				results[i].Success = true
+				results[i].Message = fmt.Sprintf("%s → %s", m.ip, m.rdns)
+			}
+			err = c.OutputInDesiredForm(results, output.Table)
+			if err != nil {
+				return err
+			}
+			if failed := results.Failed(); failed > 0 {
+				return util.BulkOperationFailedError{Failed: failed, Total: len(results)}
+			}
+			return nil
+		}),
+	})
+}
+
+// rdnsMapping is a line of an update rdns file.
+type rdnsMapping struct {
+	line   int
+	server pathers.VirtualMachineName
+	ip     net.IP
+	rdns   string
+}
+
+// readRDNSMappings reads every line of an update rdns file, returning the
+// lines which could be parsed and a description of each problem with the ones
+// which couldn't.
+func readRDNSMappings(rd io.Reader, defaults pathers.VirtualMachineName) (mappings []rdnsMapping, problems []string) {
+	// each line is parsed on its own so that problems can be reported with
+	// their line number - mappings never need to span lines.
+	scanner := bufio.NewScanner(rd)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		r := csv.NewReader(strings.NewReader(text))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		record, err := r.Read()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+		if len(mappings) == 0 && len(problems) == 0 && len(record) == 3 && strings.EqualFold(record[1], "ip") {
+			continue
+		}
+		if len(record) != 3 {
+			problems = append(problems, fmt.Sprintf("line %d: expected 3 columns (server,ip,rdns) but got %d", line, len(record)))
+			continue
+		}
+		m := rdnsMapping{line: line, rdns: strings.TrimSpace(record[2])}
+		m.server, err = lib.ParseVirtualMachineName(strings.TrimSpace(record[0]), defaults)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+		m.ip, err = parseIP(record[1])
+		if err == nil {
+			err = validateRDNS(m.rdns)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s", line, err))
+			continue
+		}
+		mappings = append(mappings, m)
+	}
+	if err := scanner.Err(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return
+}
+
+// checkRDNSMappings makes sure that the IP in each mapping belongs to one of
+// the network interfaces of the server it's listed with, returning a
+// description of each problem found.
+func checkRDNSMappings(client lib.Client, mappings []rdnsMapping) (problems []string) {
+	servers := map[string]*brain.VirtualMachine{}
+	for _, m := range mappings {
+		key := m.server.String()
+		vm, seen := servers[key]
+		if !seen {
+			found, err := client.GetVirtualMachine(m.server)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("line %d: couldn't get %s: %s", m.line, m.server, err))
+			} else {
+				vm = &found
+			}
+			servers[key] = vm
+		}
+		if vm == nil {
+			continue
+		}
+		if !serverHasIP(*vm, m.ip) {
+			problems = append(problems, fmt.Sprintf("line %d: %s isn't one of %s's IPs", m.line, m.ip, m.server))
+		}
+	}
+	return
+}
+
+// serverHasIP returns true if ip is one of vm's IPs, or an extra IP routed to
+// one of them.
+func serverHasIP(vm brain.VirtualMachine, ip net.IP) bool {
+	for _, nic := range vm.NetworkInterfaces {
+		for _, nicIP := range nic.IPs {
+			if nicIP.Equal(ip) {
+				return true
+			}
+		}
+		for extra := range nic.ExtraIPs {
+			if net.ParseIP(extra).Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -6,12 +6,17 @@ import (
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
 	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
 	"github.com/urfave/cli"
 )
@@ -24,7 +29,7 @@ func init() {
 	Commands = append(Commands, cli.Command{
 		Name:      "server",
 		Usage:     "update a server's configuration",
-		UsageText: "update server [flags] <server>",
+		UsageText: "update server [flags] <server>\n   update server --group <group> [--filter <expression>] [--hwprofile <profile>] [--lock-hwprofile | --unlock-hwprofile]",
 		Description: `Updates the configuration of an existing Cloud Server.
 
     Note that for changes to cores, memory or hardware profile to take effect you will need to restart the server.
@@ -36,8 +41,15 @@ func init() {
 
     Memory is specified in GiB by default, but can be suffixed with an M to indicate that it is provided in MiB.
 
+    If --wait is set, bytemark will wait until the server's new cores, memory and hardware profile can be seen before
+    exiting.
+
     Updating a server's name also allows it to be moved between groups and accounts you administer.
 
+    The hardware profile of every server in a group (optionally only those matching --filter, which works as in show servers) can be changed
+    or locked at once by specifying --group instead of a server. Up to --parallel servers are updated at a time, and a
+    table of results is output.
+
 EXAMPLES
     bytemark update server --memory 768m --hwprofile virtio2018 small-server
         Changes small-server's memory to 768MiB, and its hwprofile to virtio2018
@@ -62,26 +74,16 @@ EXAMPLES
 
     bytemark update server --new-name rennes.bretagne.france charata.chaco.argentina
         This will move the server called charata in the chaco group in the argentina account, placing it in the bretagne
-        group in the france account and rename it to rennes.`,
-		Flags: append(app.OutputFlags("server", "object"),
-			flagsets.Force,
+        group in the france account and rename it to rennes.
+
+    bytemark update server --group web --hwprofile virtio2018 --lock-hwprofile
+        Changes the hwprofile of every server in the web group to virtio2018, and locks it.`,
+		Flags: cliutil.ConcatFlags(groupFlags, []cli.Flag{
 			cli.GenericFlag{
 				Name:  "memory",
 				Value: new(flags.SizeSpecFlag),
 				Usage: "How much memory the server will have available, specified in GiB or with GiB/MiB units.",
 			},
-			cli.StringFlag{
-				Name:  "hwprofile",
-				Usage: "The hardware profile to use. See `bytemark profiles` for a list of hardware profiles available.",
-			},
-			cli.BoolFlag{
-				Name:  "lock-hwprofile",
-				Usage: "Locks the hardware profile (prevents it from being automatically upgraded when we release a newer version)",
-			},
-			cli.BoolFlag{
-				Name:  "unlock-hwprofile",
-				Usage: "Locks the hardware profile (allows it to be automatically upgraded when we release a newer version)",
-			},
 			cli.GenericFlag{
 				Name:  "new-name",
 				Usage: "A new name for the server",
@@ -113,11 +115,110 @@ EXAMPLES
 				Usage: "The server to update",
 				Value: new(flags.VirtualMachineNameFlag),
 			},
-		),
-		Action: app.Action(args.Optional("new-name", "hwprofile", "memory"),
-			with.RequiredFlags("server"),
-			with.VirtualMachine("server"),
-			updateServer),
+		}),
+		Action: app.Action(args.Optional("new-name", "hwprofile", "memory"), checkGroupFlags, unlessGroup(with.RequiredFlags("server")), unlessGroup(with.VirtualMachine("server")), with.Auth, func(c *app.Context) error {
+			if c.String("group") != "" {
+				return updateGroup(c)
+			}
+			return updateServer(c)
+		}),
+	})
+}
+
+// groupFlags are the flags which can be used with update server --group. The
+// rest of update server's flags only make sense for a single server.
+var groupFlags = cliutil.ConcatFlags(app.OutputFlags("server", "object"), wait.OptionalFlags, bulk.SelectorFlags, []cli.Flag{
+	flagsets.Force,
+	cli.StringFlag{
+		Name:  "hwprofile",
+		Usage: "The hardware profile to use. See `bytemark profiles` for a list of hardware profiles available.",
+	},
+	cli.BoolFlag{
+		Name:  "lock-hwprofile",
+		Usage: "Locks the hardware profile (prevents it from being automatically upgraded when we release a newer version)",
+	},
+	cli.BoolFlag{
+		Name:  "unlock-hwprofile",
+		Usage: "Locks the hardware profile (allows it to be automatically upgraded when we release a newer version)",
+	},
+})
+
+// checkGroupFlags makes sure that only groupFlags are used with --group, and
+// that --filter and --parallel are only used with --group, since they would
+// otherwise be silently ignored.
+func checkGroupFlags(c *app.Context) error {
+	if c.String("group") == "" {
+		for _, name := range []string{"filter", "parallel"} {
+			if c.IsSet(name) {
+				return c.Help(fmt.Sprintf("--%s can only be used with --group", name))
+			}
+		}
+		return nil
+	}
+	for _, flag := range c.Command().Flags {
+		name := flag.GetName()
+		if c.IsSet(name) && !isGroupFlag(name) {
+			return fmt.Errorf("--%s can't be used with --group - only --hwprofile, --lock-hwprofile and --unlock-hwprofile can", name)
+		}
+	}
+	if c.String("hwprofile") == "" && !c.Bool("lock-hwprofile") && !c.Bool("unlock-hwprofile") {
+		return c.Help("--hwprofile, --lock-hwprofile or --unlock-hwprofile must be specified when using --group")
+	}
+	return nil
+}
+
+// isGroupFlag returns true if the named flag is one of groupFlags
+func isGroupFlag(name string) bool {
+	for _, flag := range groupFlags {
+		if flag.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unlessGroup wraps a preprocessor so that it is skipped when --group is set,
+// since it only applies when updating a single server.
+func unlessGroup(f func(*app.Context) error) func(*app.Context) error {
+	return func(c *app.Context) error {
+		if c.String("group") != "" {
+			return nil
+		}
+		return f(c)
+	}
+}
+
+// updateGroup updates the hardware profile of many servers at once, using the
+// bulk package.
+func updateGroup(c *app.Context) error {
+	hwProfile := c.String("hwprofile")
+	servers, err := bulk.Servers(c)
+	if err != nil {
+		return err
+	}
+	opts := wait.OptionsFromFlags(c)
+	opts.Quiet = true
+
+	return bulk.Run(c, servers, func(c *app.Context, vmName pathers.VirtualMachineName) (string, error) {
+		if hwProfile != "" {
+			err := c.Client().SetVirtualMachineHardwareProfile(vmName, hwProfile)
+			if err != nil {
+				return "", err
+			}
+		}
+		err := lockHwProfile(c, vmName)
+		if err != nil {
+			return "", err
+		}
+		if hwProfile != "" && c.Bool("wait") {
+			err = wait.For(c, opts, wait.VMMatches(vmName, fmt.Sprintf("%s to be updated", vmName), func(vm brain.VirtualMachine) bool {
+				return vm.HardwareProfile == hwProfile
+			}))
+			if err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%s updated successfully.", vmName), nil
 	})
 }
 
@@ -147,8 +248,12 @@ func updateHwProfile(c *app.Context) error {
 }
 
 func updateLock(c *app.Context) error {
-	server := flags.VirtualMachineName(c, "server")
+	return lockHwProfile(c, flags.VirtualMachineName(c, "server"))
+}
 
+// lockHwProfile locks or unlocks server's hardware profile according to the
+// --lock-hwprofile and --unlock-hwprofile flags.
+func lockHwProfile(c *app.Context, server pathers.VirtualMachineName) error {
 	lockProfile := c.Bool("lock-hwprofile")
 	unlockProfile := c.Bool("unlock-hwprofile")
 	if lockProfile && unlockProfile {
@@ -237,6 +342,7 @@ func updateServer(c *app.Context) error {
 		updateCores,
 		updateCdrom,
 		updateName, // needs to be last
+		waitForUpdate,
 	} {
 		err := f(c)
 		if err != nil {
@@ -245,3 +351,24 @@ func updateServer(c *app.Context) error {
 	}
 	return nil
 }
+
+// waitForUpdate waits for the changes to cores, memory and hardware profile
+// to be visible if --wait was specified.
+func waitForUpdate(c *app.Context) error {
+	vmName := flags.VirtualMachineName(c, "server")
+	if newName := flags.VirtualMachineName(c, "new-name"); newName.VirtualMachine != "" {
+		vmName = newName
+	}
+	memory := flags.Size(c, "memory")
+	cores := c.Int("cores")
+	hwProfile := c.String("hwprofile")
+
+	if memory == 0 && cores == 0 && hwProfile == "" {
+		return nil
+	}
+	return wait.IfRequested(c, wait.VMMatches(vmName, fmt.Sprintf("%s to be updated", vmName), func(vm brain.VirtualMachine) bool {
+		return (memory == 0 || vm.Memory == memory) &&
+			(cores == 0 || vm.Cores == cores) &&
+			(hwProfile == "" || vm.HardwareProfile == hwProfile)
+	}))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
 	"github.com/BytemarkHosting/bytemark-client/lib"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
@@ -298,3 +299,95 @@ func TestUpdateServer(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateServerWait(t *testing.T) {
+	vmName := pathers.VirtualMachineName{
+		VirtualMachine: "test",
+		GroupName: pathers.GroupName{
+			Group:   "default",
+			Account: "default-account",
+		},
+	}
+	vm := brain.VirtualMachine{
+		Name:   "test",
+		Memory: 2048,
+		Cores:  2,
+	}
+	updated := vm
+	updated.Cores = 4
+
+	config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+	config.When("GetVirtualMachine").Return(pathers.VirtualMachineName{
+		GroupName: vmName.GroupName,
+	})
+	config.When("GetBool", "force").Return(true, nil)
+	client.When("GetVirtualMachine", vmName).Return(vm).Times(2)
+	client.When("GetVirtualMachine", vmName).Return(updated).Times(1)
+	client.When("SetVirtualMachineCores", vmName, 4).Return(nil).Times(1)
+
+	err := app.Run(strings.Split("bytemark update server --force --wait --cores 4 --server test", " "))
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if ok, err := client.Verify(); !ok {
+		t.Error(err)
+	}
+}
+
+func TestUpdateServerGroup(t *testing.T) {
+	group := pathers.GroupName{Group: "web", Account: "test-account"}
+	vmName := func(name string) pathers.VirtualMachineName {
+		return pathers.VirtualMachineName{VirtualMachine: name, GroupName: group}
+	}
+	webGroup := brain.Group{
+		Name: "web",
+		VirtualMachines: []brain.VirtualMachine{
+			{Name: "web1"},
+			{Name: "web2"},
+		},
+	}
+
+	t.Run("hwprofile and lock", func(t *testing.T) {
+		config, client, app := testutil.BaseTestAuthSetup(t, false, commands.Commands)
+		config.When("GetGroup").Return(pathers.GroupName{})
+		client.When("GetGroup", group).Return(webGroup, nil).Times(1)
+		for _, name := range []string{"web1", "web2"} {
+			client.When("SetVirtualMachineHardwareProfile", vmName(name), "virtio2018", nil).Return(nil).Times(1)
+			client.When("SetVirtualMachineHardwareProfileLock", vmName(name), true).Return(nil).Times(1)
+		}
+
+		err := app.Run(strings.Split("bytemark update server --group web.test-account --hwprofile virtio2018 --lock-hwprofile", " "))
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if ok, err := client.Verify(); !ok {
+			t.Error(err)
+		}
+	})
+
+	t.Run("other flags not allowed", func(t *testing.T) {
+		config, client, app := testutil.BaseTestSetup(t, false, commands.Commands)
+		config.When("GetGroup").Return(pathers.GroupName{})
+
+		err := app.Run(strings.Split("bytemark update server --group web.test-account --cores 4", " "))
+		if err == nil {
+			t.Error("expected an error but didn't get one")
+		}
+		if ok, err := client.Verify(); !ok {
+			t.Error(err)
+		}
+	})
+
+	t.Run("filter without group", func(t *testing.T) {
+		config, client, app := testutil.BaseTestSetup(t, false, commands.Commands)
+		config.When("GetGroup").Return(pathers.GroupName{})
+
+		err := app.Run(strings.Split("bytemark update server --filter cores>1 --cores 4 web1", " "))
+		if _, ok := err.(util.UsageDisplayedError); !ok {
+			t.Errorf("expected a UsageDisplayedError but got %v", err)
+		}
+		if ok, err := client.Verify(); !ok {
+			t.Error(err)
+		}
+	})
+}
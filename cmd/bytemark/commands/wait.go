@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/urfave/cli"
+)
+
+const waitText = `The brain is checked every --poll-interval (5s by default), doubling the interval after each check up to --max-poll-interval (30s by default). If --wait-timeout is set and passes before the wait is over, bytemark exits with exit code 10. The flag is called --wait-timeout rather than --timeout because --timeout is the global flag which limits how long each request to the API may take - both can be used together.`
+
+func init() {
+	Commands = append(Commands, cli.Command{
+		Name:        "wait",
+		Usage:       "wait for something to happen - see `bytemark help wait <condition>`",
+		UsageText:   "wait power-on|power-off|deleted|imaged|disc-migration|migration-job|backup",
+		Description: "wait until a server, disc, backup or migration job is in a particular state. Useful for scripts which need to sequence several operations.\n\n" + waitText,
+		Action:      cli.ShowSubcommandHelp,
+		Subcommands: []cli.Command{
+			serverWaitCommand("power-on", "wait for a server to be powered on", wait.VMPoweredOn),
+			serverWaitCommand("power-off", "wait for a server to be powered off", wait.VMPoweredOff),
+			serverWaitCommand("deleted", "wait for a server to be deleted", wait.VMDeleted),
+			serverWaitCommand("imaged", "wait for a server to be imaged and powered on", wait.VMImaged),
+			{
+				Name:        "disc-migration",
+				Usage:       "wait for a disc to finish migrating",
+				UsageText:   "wait disc-migration [--wait-timeout <duration>] <server> <disc label>",
+				Description: "wait for a disc to finish migrating to its new storage pool or storage grade.\n\n" + waitText,
+				Flags: cliutil.ConcatFlags(wait.Flags, []cli.Flag{
+					cli.GenericFlag{
+						Name:  "server",
+						Usage: "the server the disc belongs to",
+						Value: new(flags.VirtualMachineNameFlag),
+					},
+					cli.StringFlag{
+						Name:  "disc",
+						Usage: "the label or ID of the disc",
+					},
+				}),
+				Action: app.Action(args.Optional("server", "disc"), with.RequiredFlags("server", "disc"), with.Auth, func(c *app.Context) error {
+					cond := wait.DiscMigrated(flags.VirtualMachineName(c, "server"), c.String("disc"))
+					return wait.For(c, wait.OptionsFromFlags(c), cond)
+				}),
+			}, {
+				Name:        "migration-job",
+				Usage:       "wait for a migration job to finish",
+				UsageText:   "wait migration-job [--wait-timeout <duration>] <id>",
+				Description: "wait for a migration job to finish. Since migration jobs can only be seen by cluster admins, this will only work for cluster admins.\n\n" + waitText,
+				Flags: cliutil.ConcatFlags(wait.Flags, []cli.Flag{
+					cli.IntFlag{
+						Name:  "id",
+						Usage: "the ID of the migration job",
+					},
+				}),
+				Action: app.Action(args.Optional("id"), with.RequiredFlags("id"), with.Auth, func(c *app.Context) error {
+					return wait.For(c, wait.OptionsFromFlags(c), wait.MigrationJobFinished(c.Int("id")))
+				}),
+			}, {
+				Name:        "backup",
+				Usage:       "wait for a backup to be created",
+				UsageText:   "wait backup [--wait-timeout <duration>] <server> <disc label> <backup label>",
+				Description: "wait for a backup to be created and moved to cold storage.\n\n" + waitText,
+				Flags: cliutil.ConcatFlags(wait.Flags, []cli.Flag{
+					cli.GenericFlag{
+						Name:  "server",
+						Usage: "the server the disc belongs to",
+						Value: new(flags.VirtualMachineNameFlag),
+					},
+					cli.StringFlag{
+						Name:  "disc",
+						Usage: "the label or ID of the disc the backup is of",
+					},
+					cli.StringFlag{
+						Name:  "backup",
+						Usage: "the label or ID of the backup",
+					},
+				}),
+				Action: app.Action(args.Optional("server", "disc", "backup"), with.RequiredFlags("server", "disc", "backup"), with.Auth, func(c *app.Context) error {
+					cond := wait.BackupCreated(flags.VirtualMachineName(c, "server"), c.String("disc"), c.String("backup"))
+					return wait.For(c, wait.OptionsFromFlags(c), cond)
+				}),
+			},
+		},
+	})
+}
+
+// serverWaitCommand makes a wait subcommand which waits for a condition on a
+// single server.
+func serverWaitCommand(name string, usage string, condition func(pathers.VirtualMachineName) wait.Condition) cli.Command {
+	return cli.Command{
+		Name:        name,
+		Usage:       usage,
+		UsageText:   "wait " + name + " [--wait-timeout <duration>] <server>",
+		Description: usage + ".\n\n" + waitText,
+		Flags: cliutil.ConcatFlags(wait.Flags, []cli.Flag{
+			cli.GenericFlag{
+				Name:  "server",
+				Usage: "the server to wait for",
+				Value: new(flags.VirtualMachineNameFlag),
+			},
+		}),
+		Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) error {
+			return wait.For(c, wait.OptionsFromFlags(c), condition(flags.VirtualMachineName(c, "server")))
+		}),
+	}
+}
@@ -0,0 +1,133 @@
+package commands_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/commands"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+	"github.com/urfave/cli"
+)
+
+func TestWait(t *testing.T) {
+	vmName := pathers.VirtualMachineName{
+		VirtualMachine: "test-server",
+		GroupName: pathers.GroupName{
+			Group:   "test-group",
+			Account: "test-account",
+		},
+	}
+	tests := []struct {
+		testutil.CommandT
+		setup func(c *mocks.Client)
+	}{
+		{
+			CommandT: testutil.CommandT{
+				Name: "power-on",
+				Args: "wait power-on test-server.test-group.test-account",
+				OutputMustMatch: []*regexp.Regexp{
+					regexp.MustCompile(`^\.\.\n$`),
+				},
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{}, nil).Times(2)
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{PowerOn: true}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name:      "power-on times out",
+				Args:      "wait power-on --wait-timeout 1ns test-server.test-group.test-account",
+				ShouldErr: true,
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{}, nil)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "power-off",
+				Args: "wait power-off test-server.test-group.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{PowerOn: true}, nil).Times(1)
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "deleted after purge",
+				Args: "wait deleted test-server.test-group.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{}, lib.NotFoundError{}).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "imaged",
+				Args: "wait imaged test-server.test-group.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{}, nil).Times(1)
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{PowerOn: true, LastImagedWith: "stretch"}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "already imaged and running",
+				Args: "wait imaged test-server.test-group.test-account",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{PowerOn: true, LastImagedWith: "stretch"}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "disc-migration",
+				Args: "wait disc-migration test-server.test-group.test-account disc-1",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetDisc", vmName, "disc-1").Return(brain.Disc{NewStoragePool: "t6-sata2"}, nil).Times(1)
+				c.When("GetDisc", vmName, "disc-1").Return(brain.Disc{StoragePool: "t6-sata2"}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "backup",
+				Args: "wait backup test-server.test-group.test-account disc-1 backup-1",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("GetBackups", vmName, "disc-1").Return(brain.Backups{}, nil).Times(1)
+				c.When("GetBackups", vmName, "disc-1").Return(brain.Backups{
+					{Disc: brain.Disc{Label: "backup-1", StorageGrade: "sata"}},
+				}, nil).Times(1)
+				c.When("GetBackups", vmName, "disc-1").Return(brain.Backups{
+					{Disc: brain.Disc{Label: "backup-1", StorageGrade: "iceberg"}},
+				}, nil).Times(1)
+			},
+		}, {
+			CommandT: testutil.CommandT{
+				Name: "migration-job",
+				Args: "wait migration-job 12",
+			},
+			setup: func(c *mocks.Client) {
+				c.When("BuildRequest", "GET", lib.BrainEndpoint, "/admin/migration_jobs/%s", []string{"12"}).Return(&mocks.Request{
+					T:              t,
+					StatusCode:     200,
+					ResponseObject: brain.MigrationJob{ID: 12},
+				}).Times(1)
+				c.When("BuildRequest", "GET", lib.BrainEndpoint, "/admin/migration_jobs/%s", []string{"12"}).Return(&mocks.Request{
+					T:              t,
+					StatusCode:     200,
+					ResponseObject: brain.MigrationJob{ID: 12, FinishedAt: "2019-01-01T00:00:00Z"},
+				}).Times(1)
+			},
+		},
+	}
+	for _, test := range tests {
+		test.Auth = true
+		test.Commands = commands.Commands
+		test.Run(t, func(t *testing.T, config *mocks.Config, c *mocks.Client, app *cli.App) {
+			config.When("GetVirtualMachine").Return(pathers.VirtualMachineName{})
+			test.setup(c)
+		})
+	}
+}
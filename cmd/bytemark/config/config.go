@@ -20,7 +20,8 @@ import (
 // Config determines the configuration of the Bytemark client.
 // It's responsible for handling things like the credentials to use and what endpoints to talk to.
 //
-// Each configuration item is read from the following places, falling back to successive places:
+// Each configuration item is read from the following places, falling back to successive places
+// (environment variables are named after the item with a BYTEMARK_ prefix, e.g. BYTEMARK_AUTH_ENDPOINT):
 //
 // Per-command command-line flags, global command-line flags, environment variables, configuration directory, hard-coded defaults
 //
@@ -31,12 +32,17 @@ type config struct {
 	Dir         string
 	Memo        map[string]Var
 	Definitions map[string]string
+	// cachedSecrets are the names of the Memo entries which were read from
+	// the secret store, rather than set by a flag or Set, and so which must
+	// be forgotten if the secret store or profile changes.
+	cachedSecrets map[string]bool
 }
 
 // New sets up a new config struct. Pass in an empty string to default to ~/.bytemark
 func New(configDir string) (manager Manager, err error) {
 	conf := new(config)
 	conf.Memo = make(map[string]Var)
+	conf.cachedSecrets = make(map[string]bool)
 	home := os.Getenv("HOME")
 	if runtime.GOOS == "windows" {
 		home = os.Getenv("APPDATA")
@@ -47,6 +53,9 @@ func New(configDir string) (manager Manager, err error) {
 	if os.Getenv("BM_CONFIG_DIR") != "" {
 		conf.Dir = os.Getenv("BM_CONFIG_DIR")
 	}
+	if os.Getenv("BYTEMARK_CONFIG_DIR") != "" {
+		conf.Dir = os.Getenv("BYTEMARK_CONFIG_DIR")
+	}
 
 	if configDir != "" {
 		conf.Dir = strings.Replace(configDir, "~", os.Getenv("HOME"), -1)
@@ -159,7 +168,9 @@ func (config *config) GetDebugLevel() int {
 	return config.debugLevel
 }
 
-// GetPath joins the given string onto the end of the Config.Dir path
+// GetPath joins the given string onto the end of the Config.Dir path. Note
+// that config vars are read from the current profile's directory, which is
+// only the same as Config.Dir for the default profile.
 func (config *config) GetPath(name string) string {
 	return filepath.Join(config.Dir, name)
 }
@@ -211,9 +222,33 @@ func (config *config) GetV(name string) (Var, error) {
 		}
 		return val, nil
 	}
+	if val, ok := config.readEnv(name); ok {
+		return val, nil
+	}
 	return config.read(name)
 }
 
+// EnvVarName returns the name of the environment variable which sets the
+// named config var, e.g. BYTEMARK_AUTH_ENDPOINT for auth-endpoint.
+func EnvVarName(name string) string {
+	return "BYTEMARK_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// readEnv reads the named config var from its BYTEMARK_ environment
+// variable, which takes precedence over the config directory. Only the
+// configVars can be set this way.
+func (config *config) readEnv(name string) (Var, bool) {
+	if !IsConfigVar(name) {
+		return Var{}, false
+	}
+	envName := EnvVarName(name)
+	val := os.Getenv(envName)
+	if val == "" {
+		return Var{}, false
+	}
+	return Var{name, val, "ENV " + envName}, true
+}
+
 // GetVirtualMachine returns a VirtualMachineName with the config's default group and account set, and a blank VirtualMachine field
 func (config *config) GetVirtualMachine() (vm pathers.VirtualMachineName) {
 	vm.Account = pathers.AccountName(config.GetIgnoreErr("account"))
@@ -229,11 +264,31 @@ func (config *config) GetGroup() (group pathers.GroupName) {
 	return group
 }
 
-// GetAll returns all of the available Vars in the Config.
+// GetAll returns all of the available Vars in the Config, preceded by the
+// profile they're being read from.
 func (config *config) GetAll() (vars Vars, err error) {
-	vars = make(Vars, len(configVars))
+	return config.getAll(true)
+}
+
+// GetAllInsensitive is like GetAll, except that the sensitive vars are left
+// blank rather than read - so the result is safe to log, and getting it never
+// asks the secret store for a passphrase.
+func (config *config) GetAllInsensitive() (vars Vars, err error) {
+	return config.getAll(false)
+}
+
+func (config *config) getAll(sensitive bool) (vars Vars, err error) {
+	vars = make(Vars, len(configVars)+1)
+	vars[0], err = config.profileVar()
+	if err != nil {
+		return
+	}
 	for i, v := range configVars {
-		vars[i], err = config.GetV(v)
+		if !sensitive && IsSensitiveVar(v) {
+			vars[i+1] = Var{Name: v}
+			continue
+		}
+		vars[i+1], err = config.GetV(v)
 		if err != nil {
 			return
 		}
@@ -328,8 +383,16 @@ func (config *config) GetDefault(name string) Var {
 		return Var{"force", "false", "CODE"}
 	case "output-format":
 		return Var{"output-format", "human", "CODE"}
+	case "retries":
+		return Var{"retries", "0", "CODE"}
 	case "session-validity":
 		return Var{"session-validity", fmt.Sprintf("%d", DefaultSessionValidity), "CODE"}
+	case "timeout":
+		return Var{"timeout", "0s", "CODE"}
+	case "secret-store":
+		return Var{"secret-store", PlaintextSecretStore, "CODE"}
+	case "age-identity":
+		return Var{"age-identity", config.GetPath("age-identity.txt"), "CODE"}
 	}
 	return Var{name, "", "UNSET"}
 }
@@ -344,7 +407,26 @@ func (config *config) GetBool(name string) (bool, error) {
 }
 
 func (config *config) read(name string) (Var, error) {
-	path := config.GetPath(name)
+	if IsSensitiveVar(name) {
+		store, err := config.secretStore()
+		if err != nil {
+			return config.GetDefault(name), err
+		}
+		if store != nil {
+			v, err := config.readSecret(store, name)
+			if err == nil {
+				// reading from the secret store can be slow or ask for a
+				// passphrase, so only do it once per run
+				config.Memo[name] = v
+				config.cachedSecrets[name] = true
+			}
+			return v, err
+		}
+	}
+	path, err := config.varPath(name)
+	if err != nil {
+		return config.GetDefault(name), err
+	}
 	contents, err := ioutil.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -357,12 +439,42 @@ func (config *config) read(name string) (Var, error) {
 	return Var{name, strings.TrimSpace(string(contents)), "FILE " + path}, nil
 }
 
+// forgetCachedSecrets removes the sensitive vars which were read from the
+// secret store from the Memo, so that they're read again next time.
+func (config *config) forgetCachedSecrets() {
+	for name := range config.cachedSecrets {
+		delete(config.Memo, name)
+	}
+	config.cachedSecrets = make(map[string]bool)
+}
+
+// readSecret reads a sensitive var from the secret store.
+func (config *config) readSecret(store secretStore, name string) (Var, error) {
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		return config.GetDefault(name), err
+	}
+	value, found, err := store.Get(profile, name)
+	if err != nil {
+		return config.GetDefault(name), err
+	}
+	if !found {
+		return config.GetDefault(name), nil
+	}
+	return Var{name, strings.TrimSpace(value), store.Source(profile, name)}, nil
+}
+
 // Set stores the given key-value pair in config's Memo. This storage does not persist once the program terminates.
 func (config *config) Set(name, value, source string) {
 	config.Memo[name] = Var{name, value, source}
+	delete(config.cachedSecrets, name)
+	if name == "secret-store" {
+		config.forgetCachedSecrets()
+	}
 }
 
-// SetPersistent writes a file to the config directory for the given key-value pair.
+// SetPersistent writes a file to the config directory for the given key-value
+// pair, or puts it in the secret store if it's one of the sensitive vars.
 func (config *config) SetPersistent(name, value, source string) error {
 	found := false
 	for _, v := range configVars {
@@ -373,9 +485,33 @@ func (config *config) SetPersistent(name, value, source string) error {
 	if !found {
 		return InvalidVarError{name}
 	}
-	path := config.GetPath(name)
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(config.profileDir(profile), name)
 	config.Set(name, value, source)
-	err := ioutil.WriteFile(path, []byte(value), 0600)
+	if IsSensitiveVar(name) {
+		store, err := config.secretStore()
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			if err := store.Set(profile, name, value); err != nil {
+				return err
+			}
+			// don't leave an old plaintext copy lying around
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return &WriteError{Name: name, Path: path, Err: err}
+			}
+			return nil
+		}
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return &WriteError{Name: name, Path: path, Err: err}
+	}
+	err = ioutil.WriteFile(path, []byte(value), 0600)
 	if err != nil {
 		return &WriteError{Name: name, Path: path, Err: err}
 	}
@@ -393,8 +529,31 @@ func (config *config) Unset(name string) (err error) {
 	if !found {
 		return InvalidVarError{name}
 	}
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		return err
+	}
 	delete(config.Memo, name)
-	err = os.Remove(config.GetPath(name))
+	delete(config.cachedSecrets, name)
+	if name == "secret-store" {
+		config.forgetCachedSecrets()
+	}
+	if _, ok := config.readEnv(name); ok {
+		// the environment can't be unset, so mask it for the rest of this run
+		config.Memo[name] = config.GetDefault(name)
+	}
+	if IsSensitiveVar(name) {
+		store, storeErr := config.secretStore()
+		if storeErr != nil {
+			return storeErr
+		}
+		if store != nil {
+			if storeErr = store.Unset(profile, name); storeErr != nil {
+				return storeErr
+			}
+		}
+	}
+	err = os.Remove(filepath.Join(config.profileDir(profile), name))
 	if err != nil {
 		info, statErr := os.Stat(config.Dir)
 		if statErr != nil {
@@ -26,6 +26,10 @@ func CleanEnv() {
 	_ = os.Setenv("BM_ENDPOINT", "")
 	_ = os.Setenv("BM_AUTH_ENDPOINT", "")
 	_ = os.Setenv("BM_DEBUG_LEVEL", "")
+	for _, name := range configVars {
+		_ = os.Setenv(EnvVarName(name), "")
+	}
+	_ = os.Setenv("BYTEMARK_CONFIG_DIR", "")
 }
 
 func JunkEnv() {
@@ -146,6 +150,55 @@ func TestConfigPassedConfigDir(t *testing.T) {
 	is.Equal(expected, config.ConfigDir())
 }
 
+func TestConfigBytemarkEnvVars(t *testing.T) {
+	is := is.New(t)
+
+	CleanEnv()
+	dir, fixture, err := FixtureDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_ = os.Setenv("BYTEMARK_ENDPOINT", "https://env.localhost.local")
+	_ = os.Setenv("BYTEMARK_TOKEN", "env-token")
+	_ = os.Setenv("BYTEMARK_OUTPUT_FORMAT", "json")
+	defer CleanEnv()
+
+	config, err := New(dir)
+	is.Nil(err)
+
+	// env beats the config dir
+	v, err := config.GetV("endpoint")
+	is.Nil(err)
+	is.Equal("https://env.localhost.local", v.Value)
+	is.Equal("ENV BYTEMARK_ENDPOINT", v.Source)
+	is.Equal("env-token", config.GetIgnoreErr("token"))
+	is.Equal("json", config.GetIgnoreErr("output-format"))
+	is.Equal(fixture["user"], config.GetIgnoreErr("user"))
+
+	// flags beat env
+	fs := flag.NewFlagSet("flags", flag.ContinueOnError)
+	fs.String("endpoint", "", "")
+	is.Nil(fs.Parse([]string{"--endpoint", "https://flag.localhost.local"}))
+	config.ImportFlags(fs)
+	v, err = config.GetV("endpoint")
+	is.Nil(err)
+	is.Equal("https://flag.localhost.local", v.Value)
+	is.Equal("FLAG endpoint", v.Source)
+
+	vars, err := config.GetAll()
+	is.Nil(err)
+	for _, v := range vars {
+		if v.Name == "token" {
+			is.Equal("ENV BYTEMARK_TOKEN", v.Source)
+		}
+	}
+
+	// unsetting masks the environment for the rest of the run
+	is.Nil(config.Unset("token"))
+	is.Equal("", config.GetIgnoreErr("token"))
+}
+
 /*
  ================
   Defaulting Tests
@@ -10,6 +10,9 @@ import (
 var configVars = [...]string{
 	"account",
 	"admin",
+	"age-identity",
+	"api-endpoint",
+	"api-key",
 	"auth-endpoint",
 	"billing-endpoint",
 	"debug-level",
@@ -17,8 +20,11 @@ var configVars = [...]string{
 	"group",
 	"insecure",
 	"output-format",
+	"retries",
+	"secret-store",
 	"session-validity",
 	"spp-endpoint",
+	"timeout",
 	"token",
 	"user",
 	"yubikey",
@@ -29,15 +35,28 @@ var configVars = [...]string{
 const VarsDescription = `
         account - the default account, used when you do not explicitly state an account - defaults to the same as your user name
         token - the token used for authentication
+        api-key - an API key to authenticate with instead of logging in - see 'bytemark login --help'
         user - the user that you log in as by default
         group - the default group, used when you do not explicitly state a group (defaults to 'default')
 
         debug-level - the default debug level. Set to 0 unless you like lots of output.
-	api-endpoint - the endpoint for domains (among other things?)
+        retries - how many times to retry requests which fail due to network or gateway errors. 0 is the default.
+        timeout - how long to wait for each request before giving up, e.g. 30s. 0 (wait forever) is the default.
+        api-endpoint - the endpoint for domains (among other things?)
         auth-endpoint - the endpoint to authenticate to. https://auth.bytemark.co.uk is the default.
         endpoint - the brain endpoint to connect to. https://uk0.bigv.io is the default.
         billing-endpoint - the billing API endpoint to connect to. https://bmbilling.bytemark.co.uk is the default.
-        spp-endpoint - the SPP endpoint to use. https://spp-submissions.bytemark.co.uk is the default.`
+        spp-endpoint - the SPP endpoint to use. https://spp-submissions.bytemark.co.uk is the default.
+
+        secret-store - where to keep the token and API key. One of plaintext (a file in the config directory, the default),
+                       secret-service (the desktop keyring, using secret-tool), pass, or age (a file in the config
+                       directory encrypted with age). Use 'bytemark config migrate-secrets' after changing it.
+        age-identity - the age identity file used by the age secret store. Defaults to age-identity.txt in the
+                       config directory. It may be passphrase-protected, in which case age will ask for the passphrase.
+
+    Every variable can also be set with an environment variable named after it with a BYTEMARK_ prefix, in capitals and
+    with dashes replaced by underscores - for example BYTEMARK_TOKEN, BYTEMARK_AUTH_ENDPOINT or BYTEMARK_OUTPUT_FORMAT.
+    Flags take precedence over environment variables, which take precedence over the config directory.`
 
 // IsConfigVar checks to see if the named variable is actually one of the settable configVars.
 func IsConfigVar(name string) bool {
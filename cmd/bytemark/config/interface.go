@@ -16,6 +16,7 @@ type Manager interface {
 	GetVirtualMachine() pathers.VirtualMachineName
 	GetGroup() pathers.GroupName
 	GetAll() (Vars, error)
+	GetAllInsensitive() (Vars, error)
 	Set(string, string, string)
 	SetPersistent(varname string, value string, source string) error
 	Unset(string) error
@@ -24,5 +25,12 @@ type Manager interface {
 	PanelURL() string
 	ConfigDir() string
 
+	CurrentProfile() (string, error)
+	Profiles() (Profiles, error)
+	UseProfile(name string) error
+	CopyProfile(from, to string) error
+
+	MigrateSecrets() ([]string, error)
+
 	ImportFlags(*flag.FlagSet) []string
 }
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+)
+
+// DefaultProfile is the name of the profile whose config lives directly in
+// the config directory, used when no other profile has been chosen.
+const DefaultProfile = "default"
+
+// profilesDir is the directory inside the config directory which holds the
+// config directories for every profile other than DefaultProfile
+const profilesDir = "profiles"
+
+// currentProfileFile is the file in the config directory which stores the
+// profile chosen with 'bytemark config use-profile'
+const currentProfileFile = "profile"
+
+var profileNameRE = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9_.-]*$`)
+
+// Profile is a summary of one of the profiles in the config directory.
+type Profile struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"`
+	Endpoint string `json:"endpoint"`
+	User     string `json:"user"`
+	Account  string `json:"account"`
+}
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (p Profile) DefaultFields(f output.Format) string {
+	return "Current, Name, Endpoint, User, Account"
+}
+
+// PrettyPrint outputs a line about the profile to the given writer.
+func (p Profile) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "profile_sgl" }}{{ if .Current }}*{{ else }} {{ end }} {{ .Name }}{{ end }}{{ define "profile_medium" }}{{ template "profile_sgl" . }} - {{ .Endpoint }}{{ with .User }} as {{ . }}{{ end }}{{ with .Account }}, account {{ . }}{{ end }}{{ end }}{{ define "profile_full" }}{{ template "profile_medium" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "profile"+string(detail), p)
+}
+
+// Profiles is a list of Profile
+type Profiles []Profile
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as Profile.DefaultFields.
+func (ps Profiles) DefaultFields(f output.Format) string {
+	return (Profile{}).DefaultFields(f)
+}
+
+// PrettyPrint writes a line about each profile to wr.
+func (ps Profiles) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	for _, p := range ps {
+		if err := p.PrettyPrint(wr, prettyprint.Medium); err != nil {
+			return err
+		}
+		if _, err := wr.Write([]byte("\r\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProfileNotFoundError is returned when trying to use or copy a profile which
+// doesn't exist.
+type ProfileNotFoundError struct {
+	Name string
+}
+
+func (e ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("There's no profile called %s. Create it by setting some config in it, e.g. `bytemark --profile %s update config --endpoint <url>`", e.Name, e.Name)
+}
+
+// ProfileExistsError is returned when copying a profile over one which
+// already exists.
+type ProfileExistsError struct {
+	Name string
+}
+
+func (e ProfileExistsError) Error() string {
+	return fmt.Sprintf("A profile called %s already exists", e.Name)
+}
+
+// InvalidProfileNameError is returned when a profile name contains
+// characters which aren't allowed.
+type InvalidProfileNameError struct {
+	Name string
+}
+
+func (e InvalidProfileNameError) Error() string {
+	return fmt.Sprintf("%q is not a valid profile name - profile names may only contain letters, numbers, dots, dashes and underscores", e.Name)
+}
+
+func validateProfileName(name string) error {
+	if !profileNameRE.MatchString(name) {
+		return InvalidProfileNameError{name}
+	}
+	return nil
+}
+
+// profileVar returns a Var saying which profile is in use, and why. The
+// --profile flag takes precedence over the BYTEMARK_PROFILE environment
+// variable, which takes precedence over the profile chosen with
+// 'bytemark config use-profile'. The profile's name is used as a directory
+// name, so an InvalidProfileNameError is returned if it isn't a valid one.
+func (config *config) profileVar() (v Var, err error) {
+	v = Var{"profile", DefaultProfile, "CODE"}
+	if memo, ok := config.Memo["profile"]; ok && memo.Value != "" {
+		v = memo
+	} else if val := os.Getenv("BYTEMARK_PROFILE"); val != "" {
+		v = Var{"profile", val, "ENV BYTEMARK_PROFILE"}
+	} else {
+		path := config.GetPath(currentProfileFile)
+		if contents, readErr := ioutil.ReadFile(path); readErr == nil {
+			if name := strings.TrimSpace(string(contents)); name != "" {
+				v = Var{"profile", name, "FILE " + path}
+			}
+		}
+	}
+	return v, validateProfileName(v.Value)
+}
+
+// CurrentProfile returns the name of the profile in use.
+func (config *config) CurrentProfile() (string, error) {
+	v, err := config.profileVar()
+	if err != nil {
+		return "", err
+	}
+	return v.Value, nil
+}
+
+// profileDir returns the directory which holds the config for the named
+// profile. name must already have been validated.
+func (config *config) profileDir(name string) string {
+	if name == DefaultProfile {
+		return config.Dir
+	}
+	return filepath.Join(config.Dir, profilesDir, name)
+}
+
+// varPath returns the path of the file holding the named var in the
+// current profile.
+func (config *config) varPath(name string) (string, error) {
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config.profileDir(profile), name), nil
+}
+
+// profileExists returns true if the named profile has a directory.
+func (config *config) profileExists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(config.profileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// readProfileVar reads the named var straight from the named profile's
+// directory, without falling back to defaults.
+func (config *config) readProfileVar(profile, name string) string {
+	contents, err := ioutil.ReadFile(filepath.Join(config.profileDir(profile), name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+// Profiles returns a summary of every profile, sorted by name with
+// DefaultProfile first.
+func (config *config) Profiles() (Profiles, error) {
+	names := []string{}
+	infos, err := ioutil.ReadDir(filepath.Join(config.Dir, profilesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.IsDir() && info.Name() != DefaultProfile && validateProfileName(info.Name()) == nil {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{DefaultProfile}, names...)
+
+	current, err := config.CurrentProfile()
+	if err != nil {
+		return nil, err
+	}
+	profiles := make(Profiles, len(names))
+	for i, name := range names {
+		profiles[i] = Profile{
+			Name:     name,
+			Current:  name == current,
+			Endpoint: config.readProfileVar(name, "endpoint"),
+			User:     config.readProfileVar(name, "user"),
+			Account:  config.readProfileVar(name, "account"),
+		}
+		if profiles[i].Endpoint == "" {
+			profiles[i].Endpoint = config.GetDefault("endpoint").Value
+		}
+	}
+	return profiles, nil
+}
+
+// UseProfile makes the named profile the one used when neither --profile
+// nor BYTEMARK_PROFILE are set. The profile must already exist.
+func (config *config) UseProfile(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	config.forgetCachedSecrets()
+	path := config.GetPath(currentProfileFile)
+	if name == DefaultProfile {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return &WriteError{Name: "profile", Path: path, Err: err}
+		}
+		return nil
+	}
+	if !config.profileExists(name) {
+		return ProfileNotFoundError{name}
+	}
+	if err := ioutil.WriteFile(path, []byte(name), 0600); err != nil {
+		return &WriteError{Name: "profile", Path: path, Err: err}
+	}
+	return nil
+}
+
+// CopyProfile creates a new profile called to, with the same config as the
+// profile called from. Sensitive vars like the token aren't copied, so the new
+// profile has to be logged in to separately.
+func (config *config) CopyProfile(from, to string) error {
+	for _, name := range []string{from, to} {
+		if err := validateProfileName(name); err != nil {
+			return err
+		}
+	}
+	if !config.profileExists(from) {
+		return ProfileNotFoundError{from}
+	}
+	if config.profileExists(to) {
+		return ProfileExistsError{to}
+	}
+	dir := config.profileDir(to)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for _, name := range configVars {
+		if IsSensitiveVar(name) {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(config.profileDir(from), name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return &ReadError{Name: name, Path: filepath.Join(config.profileDir(from), name), Err: err}
+		}
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+			return &WriteError{Name: name, Path: path, Err: err}
+		}
+	}
+	return nil
+}
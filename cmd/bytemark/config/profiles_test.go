@@ -0,0 +1,148 @@
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+func currentProfile(t *testing.T, config Manager) string {
+	profile, err := config.CurrentProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profile
+}
+
+func TestConfigProfiles(t *testing.T) {
+	is := is.New(t)
+
+	CleanEnv()
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+	dir, fixture, err := FixtureDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config, err := New(dir)
+	is.Nil(err)
+	is.Equal(DefaultProfile, currentProfile(t, config))
+
+	// using a profile which doesn't exist yet should give defaults, then
+	// setting something in it creates it
+	flags := flag.NewFlagSet("flags", flag.ContinueOnError)
+	flags.String("profile", "", "")
+	is.Nil(flags.Parse([]string{"--profile", "staging"}))
+	config.ImportFlags(flags)
+	is.Equal("staging", currentProfile(t, config))
+	v, err := config.GetV("endpoint")
+	is.Nil(err)
+	is.Equal("CODE", v.SourceType())
+
+	is.Nil(config.SetPersistent("endpoint", "https://staging.localhost.local", "TEST"))
+	is.Nil(config.SetPersistent("token", "staging-token", "TEST"))
+
+	// the default profile is untouched
+	config, err = New(dir)
+	is.Nil(err)
+	is.Equal(fixture["endpoint"], config.GetIgnoreErr("endpoint"))
+	is.Equal("", config.GetIgnoreErr("token"))
+
+	profiles, err := config.Profiles()
+	is.Nil(err)
+	is.Equal(2, len(profiles))
+	is.Equal(Profile{Name: "default", Current: true, Endpoint: fixture["endpoint"], User: fixture["user"], Account: fixture["account"]}, profiles[0])
+	is.Equal(Profile{Name: "staging", Endpoint: "https://staging.localhost.local"}, profiles[1])
+
+	// use-profile is remembered, but BYTEMARK_PROFILE overrides it
+	is.Err(config.UseProfile("nonexistent"))
+	is.Err(config.UseProfile("../escape"))
+	is.Nil(config.UseProfile("staging"))
+	config, err = New(dir)
+	is.Nil(err)
+	is.Equal("staging", currentProfile(t, config))
+	is.Equal("https://staging.localhost.local", config.GetIgnoreErr("endpoint"))
+	is.Equal("staging-token", config.GetIgnoreErr("token"))
+
+	_ = os.Setenv("BYTEMARK_PROFILE", "default")
+	is.Equal(DefaultProfile, currentProfile(t, config))
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+
+	// copy-profile copies everything but the token
+	is.Nil(config.CopyProfile("staging", "staging2"))
+	is.Err(config.CopyProfile("staging", "staging2"))
+	is.Err(config.CopyProfile("nonexistent", "staging3"))
+	is.Nil(config.UseProfile("staging2"))
+	is.Equal("https://staging.localhost.local", config.GetIgnoreErr("endpoint"))
+	is.Equal("", config.GetIgnoreErr("token"))
+
+	is.Nil(config.UseProfile("default"))
+	is.Equal(DefaultProfile, currentProfile(t, config))
+	is.Equal(fixture["endpoint"], config.GetIgnoreErr("endpoint"))
+}
+
+func TestConfigInvalidProfile(t *testing.T) {
+	CleanEnv()
+	dir, _, err := FixtureDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("BYTEMARK_PROFILE", "")
+
+	tests := []struct {
+		name string
+		flag string
+		env  string
+		file string
+	}{
+		{name: "flag", flag: "../../escape"},
+		{name: "env", env: "../escape"},
+		{name: "file", file: "../escape"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_ = os.Setenv("BYTEMARK_PROFILE", test.env)
+			profileFile := filepath.Join(dir, "profile")
+			_ = os.Remove(profileFile)
+			if test.file != "" {
+				if err := ioutil.WriteFile(profileFile, []byte(test.file), 0600); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Remove(profileFile)
+			}
+			config, err := New(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.flag != "" {
+				flags := flag.NewFlagSet("flags", flag.ContinueOnError)
+				flags.String("profile", "", "")
+				if err := flags.Parse([]string{"--profile", test.flag}); err != nil {
+					t.Fatal(err)
+				}
+				config.ImportFlags(flags)
+			}
+
+			if _, err := config.CurrentProfile(); err == nil {
+				t.Error("expected CurrentProfile to fail")
+			} else if _, ok := err.(InvalidProfileNameError); !ok {
+				t.Errorf("expected an InvalidProfileNameError, got %T: %v", err, err)
+			}
+			if _, err := config.GetV("endpoint"); err == nil {
+				t.Error("expected reading a var to fail")
+			}
+			if err := config.SetPersistent("token", "stolen", "TEST"); err == nil {
+				t.Error("expected writing a var to fail")
+			}
+			if _, err := os.Stat(filepath.Join(dir, "..", "escape")); !os.IsNotExist(err) {
+				t.Error("wrote outside of the config dir")
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+)
+
+// sensitiveVars are the config vars which are kept in the secret store chosen
+// with the secret-store config var, rather than in plain files in the config
+// directory.
+var sensitiveVars = [...]string{
+	"api-key",
+	"token",
+}
+
+// IsSensitiveVar returns true if the named config var holds a secret, and so
+// is kept in the secret store.
+func IsSensitiveVar(name string) bool {
+	for _, v := range sensitiveVars {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The names of the secret stores that can be chosen with the secret-store
+// config var.
+const (
+	// PlaintextSecretStore keeps secrets in files in the config directory,
+	// like every other config var. This is the default.
+	PlaintextSecretStore = "plaintext"
+	// SecretServiceSecretStore keeps secrets in the desktop keyring using the
+	// freedesktop Secret Service, via the secret-tool program from libsecret.
+	SecretServiceSecretStore = "secret-service"
+	// PassSecretStore keeps secrets in the pass password store.
+	PassSecretStore = "pass"
+	// AgeSecretStore keeps secrets in files in the config directory which are
+	// encrypted with age, using the identity file named by the age-identity
+	// config var.
+	AgeSecretStore = "age"
+)
+
+// SecretStores is the list of names valid for the secret-store config var.
+var SecretStores = []string{PlaintextSecretStore, SecretServiceSecretStore, PassSecretStore, AgeSecretStore}
+
+// secretStore is somewhere to keep the values of sensitive config vars. Each
+// profile has its own secrets.
+type secretStore interface {
+	// Get returns the value of the named secret, and whether it was found
+	Get(profile, name string) (string, bool, error)
+	Set(profile, name, value string) error
+	// Unset removes the named secret. It is not an error if it didn't exist.
+	Unset(profile, name string) error
+	// Source describes where the named secret is kept, for the Source of a Var.
+	Source(profile, name string) string
+}
+
+// UnknownSecretStoreError is returned when the secret-store config var is set
+// to something other than one of SecretStores.
+type UnknownSecretStoreError struct {
+	Name string
+}
+
+func (e UnknownSecretStoreError) Error() string {
+	return fmt.Sprintf("'%s' is not a known secret store. Valid secret stores are: %s", e.Name, strings.Join(SecretStores, ", "))
+}
+
+// SecretStoreError is returned when a program used by a secret store failed.
+type SecretStoreError struct {
+	Store  string
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e SecretStoreError) Error() string {
+	msg := fmt.Sprintf("The %s secret store failed running '%s': %v", e.Store, strings.Join(e.Args, " "), e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		msg += "\r\n" + stderr
+	}
+	return msg
+}
+
+// secretCommandRunner runs the named program with the given stdin, returning
+// its stdout. It is a variable so that the tests can avoid depending on
+// secret-tool, pass and age being installed.
+var secretCommandRunner = func(stdin string, name string, args ...string) (stdout string, err error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	// age asks for the passphrase of an encrypted identity on the terminal
+	// itself, so capturing stdout and stderr doesn't get in its way.
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	log.Debugf(log.LvlOutline, "Executing %s %s\r\n", name, strings.Join(args, " "))
+	err = cmd.Run()
+	if err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			// the program couldn't be found - pass that straight up so the
+			// user gets told about it clearly
+			return "", err
+		}
+		return outBuf.String(), SecretStoreError{
+			Store:  name,
+			Args:   append([]string{name}, args...),
+			Stderr: errBuf.String(),
+			Err:    err,
+		}
+	}
+	return outBuf.String(), nil
+}
+
+// secretStore returns the secret store chosen with the secret-store config
+// var, or nil if secrets are kept in plain files.
+func (config *config) secretStore() (secretStore, error) {
+	name, err := config.Get("secret-store")
+	if err != nil {
+		return nil, err
+	}
+	return config.secretStoreByName(name)
+}
+
+func (config *config) secretStoreByName(name string) (secretStore, error) {
+	switch name {
+	case "", PlaintextSecretStore:
+		return nil, nil
+	case SecretServiceSecretStore:
+		return secretServiceStore{configDir: config.Dir}, nil
+	case PassSecretStore:
+		return passStore{}, nil
+	case AgeSecretStore:
+		return ageStore{config: config, identity: config.GetIgnoreErr("age-identity")}, nil
+	}
+	return nil, UnknownSecretStoreError{name}
+}
+
+// secretServiceStore keeps secrets in the freedesktop Secret Service (i.e.
+// GNOME Keyring, KWallet and friends), using secret-tool to talk to it.
+type secretServiceStore struct {
+	configDir string
+}
+
+// attributes returns the secret-tool attributes which identify the secret.
+// The config directory is included so that several config directories don't
+// trample on each other's secrets.
+func (s secretServiceStore) attributes(profile, name string) []string {
+	return []string{"application", "bytemark-client", "config-dir", s.configDir, "profile", profile, "var", name}
+}
+
+func (s secretServiceStore) Get(profile, name string) (string, bool, error) {
+	args := append([]string{"lookup"}, s.attributes(profile, name)...)
+	out, err := secretCommandRunner("", "secret-tool", args...)
+	if err != nil {
+		// secret-tool exits 1 without saying anything when there's no
+		// matching secret
+		if storeErr, ok := err.(SecretStoreError); ok && strings.TrimSpace(storeErr.Stderr) == "" {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func (s secretServiceStore) Set(profile, name, value string) error {
+	label := fmt.Sprintf("bytemark-client %s (%s profile)", name, profile)
+	args := append([]string{"store", "--label", label}, s.attributes(profile, name)...)
+	_, err := secretCommandRunner(value, "secret-tool", args...)
+	return err
+}
+
+func (s secretServiceStore) Unset(profile, name string) error {
+	args := append([]string{"clear"}, s.attributes(profile, name)...)
+	_, err := secretCommandRunner("", "secret-tool", args...)
+	return err
+}
+
+func (s secretServiceStore) Source(profile, name string) string {
+	return "SECRET-SERVICE " + strings.Join(s.attributes(profile, name), " ")
+}
+
+// passStore keeps secrets in the pass password store, under bytemark-client/
+type passStore struct{}
+
+func (s passStore) path(profile, name string) string {
+	return "bytemark-client/" + profile + "/" + name
+}
+
+func (s passStore) Get(profile, name string) (string, bool, error) {
+	out, err := secretCommandRunner("", "pass", "show", s.path(profile, name))
+	if err != nil {
+		if storeErr, ok := err.(SecretStoreError); ok && strings.Contains(storeErr.Stderr, "is not in the password store") {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	// pass entries conventionally keep the password on the first line
+	return strings.SplitN(out, "\n", 2)[0], true, nil
+}
+
+func (s passStore) Set(profile, name, value string) error {
+	_, err := secretCommandRunner(value+"\n", "pass", "insert", "--multiline", "--force", s.path(profile, name))
+	return err
+}
+
+func (s passStore) Unset(profile, name string) error {
+	_, err := secretCommandRunner("", "pass", "rm", "--force", s.path(profile, name))
+	if storeErr, ok := err.(SecretStoreError); ok && strings.Contains(storeErr.Stderr, "is not in the password store") {
+		return nil
+	}
+	return err
+}
+
+func (s passStore) Source(profile, name string) string {
+	return "PASS " + s.path(profile, name)
+}
+
+// ageStore keeps secrets in files in the profile's directory named after the
+// var with a .age suffix, encrypted with age to the recipient of the identity
+// file. The identity file may itself be protected with a passphrase, in which
+// case age will ask for it.
+type ageStore struct {
+	config   *config
+	identity string
+}
+
+func (s ageStore) path(profile, name string) string {
+	return filepath.Join(s.config.profileDir(profile), name+".age")
+}
+
+// checkIdentity makes sure the identity file exists, so that the user gets a
+// helpful error rather than whatever age says.
+func (s ageStore) checkIdentity() error {
+	if _, err := os.Stat(s.identity); err != nil {
+		return fmt.Errorf("Couldn't read the age identity file %s (%v) - create one with 'age-keygen -o %s' or set age-identity to the path of an existing one", s.identity, err, s.identity)
+	}
+	return nil
+}
+
+func (s ageStore) Get(profile, name string) (string, bool, error) {
+	path := s.path(profile, name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err := s.checkIdentity(); err != nil {
+		return "", false, err
+	}
+	out, err := secretCommandRunner("", "age", "--decrypt", "--identity", s.identity, path)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func (s ageStore) Set(profile, name, value string) error {
+	if err := s.checkIdentity(); err != nil {
+		return err
+	}
+	path := s.path(profile, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return &WriteError{Name: name, Path: path, Err: err}
+	}
+	out, err := secretCommandRunner(value, "age", "--encrypt", "--armor", "--identity", s.identity)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, []byte(out), 0600); err != nil {
+		return &WriteError{Name: name, Path: path, Err: err}
+	}
+	return nil
+}
+
+func (s ageStore) Unset(profile, name string) error {
+	path := s.path(profile, name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return &WriteError{Name: name, Path: path, Err: err}
+	}
+	return nil
+}
+
+func (s ageStore) Source(profile, name string) string {
+	return "AGE " + s.path(profile, name)
+}
+
+// MigrateSecrets moves the sensitive vars of every profile out of plain files
+// in the config directory and into the secret store chosen with the
+// secret-store config var. It returns a list of the secrets which were moved,
+// each in the form profile/var.
+func (config *config) MigrateSecrets() (migrated []string, err error) {
+	store, err := config.secretStore()
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, fmt.Errorf("secret-store is set to %s, so there's nowhere to migrate secrets to. Set it to one of %s first", PlaintextSecretStore, strings.Join(SecretStores[1:], ", "))
+	}
+	profiles, err := config.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, profile := range profiles {
+		for _, name := range sensitiveVars {
+			path := filepath.Join(config.profileDir(profile.Name), name)
+			contents, readErr := ioutil.ReadFile(path)
+			if os.IsNotExist(readErr) {
+				continue
+			} else if readErr != nil {
+				return migrated, &ReadError{Name: name, Path: path, Err: readErr}
+			}
+			value := strings.TrimSpace(string(contents))
+			if value != "" {
+				if err = store.Set(profile.Name, name, value); err != nil {
+					return migrated, err
+				}
+			}
+			if err = os.Remove(path); err != nil {
+				return migrated, &WriteError{Name: name, Path: path, Err: err}
+			}
+			migrated = append(migrated, profile.Name+"/"+name)
+		}
+	}
+	config.forgetCachedSecrets()
+	return migrated, nil
+}
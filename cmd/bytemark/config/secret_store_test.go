@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/is"
+)
+
+// fakePass replaces secretCommandRunner with something that behaves like pass,
+// keeping its entries in a map.
+func fakePass(t *testing.T, entries map[string]string) func() {
+	oldRunner := secretCommandRunner
+	secretCommandRunner = func(stdin string, name string, args ...string) (string, error) {
+		if name != "pass" {
+			t.Fatalf("expected to run pass, ran %s", name)
+		}
+		path := args[len(args)-1]
+		notFound := SecretStoreError{Store: "pass", Args: args, Stderr: "Error: " + path + " is not in the password store.", Err: errors.New("exit status 1")}
+		switch args[0] {
+		case "show":
+			if value, ok := entries[path]; ok {
+				return value, nil
+			}
+			return "", notFound
+		case "insert":
+			entries[path] = stdin
+			return "", nil
+		case "rm":
+			if _, ok := entries[path]; !ok {
+				return "", notFound
+			}
+			delete(entries, path)
+			return "", nil
+		}
+		t.Fatalf("unexpected pass command %v", args)
+		return "", nil
+	}
+	return func() { secretCommandRunner = oldRunner }
+}
+
+func TestSecretStorePass(t *testing.T) {
+	is := is.New(t)
+	CleanEnv()
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+	dir, _, err := MakeDirFromFixture(map[string]string{"token": "default-token"})
+	is.Nil(err)
+	defer os.RemoveAll(dir)
+	is.Nil(os.MkdirAll(filepath.Join(dir, profilesDir, "staging"), 0700))
+	is.Nil(ioutil.WriteFile(filepath.Join(dir, profilesDir, "staging", "token"), []byte("staging-token\n"), 0600))
+
+	entries := map[string]string{}
+	defer fakePass(t, entries)()
+
+	config, err := New(dir)
+	is.Nil(err)
+
+	// can't migrate while secrets are still in plaintext
+	_, err = config.MigrateSecrets()
+	is.Err(err)
+
+	is.Nil(config.SetPersistent("secret-store", "nonsense", "TEST"))
+	_, err = config.GetV("token")
+	is.Err(err)
+
+	is.Nil(config.SetPersistent("secret-store", PassSecretStore, "TEST"))
+	// the plaintext token isn't used any more, and there's none in pass yet
+	config, err = New(dir)
+	is.Nil(err)
+	is.Equal("", config.GetIgnoreErr("token"))
+
+	migrated, err := config.MigrateSecrets()
+	is.Nil(err)
+	is.Equal([]string{"default/token", "staging/token"}, migrated)
+	is.Equal(map[string]string{
+		"bytemark-client/default/token": "default-token\n",
+		"bytemark-client/staging/token": "staging-token\n",
+	}, entries)
+	_, err = os.Stat(filepath.Join(dir, "token"))
+	is.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, profilesDir, "staging", "token"))
+	is.True(os.IsNotExist(err))
+
+	v, err := config.GetV("token")
+	is.Nil(err)
+	is.Equal("default-token", v.Value)
+	is.Equal("PASS", v.SourceType())
+
+	// new tokens go into pass and never touch the disc
+	is.Nil(config.SetPersistent("token", "new-token", "TEST"))
+	is.Equal("new-token\n", entries["bytemark-client/default/token"])
+	_, err = os.Stat(filepath.Join(dir, "token"))
+	is.True(os.IsNotExist(err))
+
+	is.Nil(config.Unset("token"))
+	_, ok := entries["bytemark-client/default/token"]
+	is.False(ok)
+	is.Nil(config.Unset("token"))
+	is.Equal("", config.GetIgnoreErr("token"))
+}
+
+func TestSecretStoreAge(t *testing.T) {
+	is := is.New(t)
+	CleanEnv()
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+	dir, _, err := MakeDirFromFixture(map[string]string{"secret-store": AgeSecretStore})
+	is.Nil(err)
+	defer os.RemoveAll(dir)
+
+	oldRunner := secretCommandRunner
+	defer func() { secretCommandRunner = oldRunner }()
+	secretCommandRunner = func(stdin string, name string, args ...string) (string, error) {
+		is.Equal("age", name)
+		identity := ""
+		for i, arg := range args[:len(args)-1] {
+			if arg == "--identity" {
+				identity = args[i+1]
+			}
+		}
+		is.Equal(filepath.Join(dir, "age-identity.txt"), identity)
+		switch args[0] {
+		case "--encrypt":
+			return "ENCRYPTED " + stdin, nil
+		case "--decrypt":
+			contents, err := ioutil.ReadFile(args[len(args)-1])
+			is.Nil(err)
+			return strings.TrimPrefix(string(contents), "ENCRYPTED "), nil
+		}
+		t.Fatalf("unexpected age command %v", args)
+		return "", nil
+	}
+
+	config, err := New(dir)
+	is.Nil(err)
+
+	// no identity file yet
+	is.Err(config.SetPersistent("token", "secret-token", "TEST"))
+
+	is.Nil(ioutil.WriteFile(filepath.Join(dir, "age-identity.txt"), []byte("AGE-SECRET-KEY-1..."), 0600))
+	is.Nil(config.SetPersistent("token", "secret-token", "TEST"))
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "token.age"))
+	is.Nil(err)
+	is.Equal("ENCRYPTED secret-token", string(contents))
+
+	config, err = New(dir)
+	is.Nil(err)
+	v, err := config.GetV("token")
+	is.Nil(err)
+	is.Equal("secret-token", v.Value)
+	is.Equal("AGE", v.SourceType())
+
+	is.Nil(config.Unset("token"))
+	_, err = os.Stat(filepath.Join(dir, "token.age"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestGetAllInsensitive(t *testing.T) {
+	is := is.New(t)
+	CleanEnv()
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+	dir, _, err := MakeDirFromFixture(map[string]string{"secret-store": PassSecretStore, "account": "test-account"})
+	is.Nil(err)
+	defer os.RemoveAll(dir)
+
+	oldRunner := secretCommandRunner
+	defer func() { secretCommandRunner = oldRunner }()
+	secretCommandRunner = func(stdin string, name string, args ...string) (string, error) {
+		t.Fatalf("the secret store shouldn't be read, but %s %v was run", name, args)
+		return "", nil
+	}
+
+	config, err := New(dir)
+	is.Nil(err)
+	vars, err := config.GetAllInsensitive()
+	is.Nil(err)
+	for _, v := range vars {
+		switch v.Name {
+		case "account":
+			is.Equal("test-account", v.Value)
+		case "api-key", "token":
+			is.Equal("", v.Value)
+		}
+	}
+}
+
+func TestSecretStoreReadOnce(t *testing.T) {
+	is := is.New(t)
+	CleanEnv()
+	_ = os.Setenv("BYTEMARK_PROFILE", "")
+	dir, _, err := MakeDirFromFixture(map[string]string{"secret-store": PassSecretStore})
+	is.Nil(err)
+	defer os.RemoveAll(dir)
+
+	entries := map[string]string{"bytemark-client/default/token": "pass-token\n"}
+	defer fakePass(t, entries)()
+	passRunner := secretCommandRunner
+	shows := 0
+	secretCommandRunner = func(stdin string, name string, args ...string) (string, error) {
+		if args[0] == "show" {
+			shows++
+		}
+		return passRunner(stdin, name, args...)
+	}
+
+	config, err := New(dir)
+	is.Nil(err)
+	for i := 0; i < 3; i++ {
+		is.Equal("pass-token", config.GetIgnoreErr("token"))
+		is.Equal("", config.GetIgnoreErr("api-key"))
+	}
+	is.Equal(2, shows)
+
+	// switching profile means the secrets have to be read again
+	is.Nil(config.UseProfile(DefaultProfile))
+	is.Equal("pass-token", config.GetIgnoreErr("token"))
+	is.Equal(3, shows)
+}
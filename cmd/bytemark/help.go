@@ -11,25 +11,30 @@ func init() {
 		UsageText: "profiles",
 		Action:    cli.ShowSubcommandHelp,
 		Description: `Having multiple configurations with Bytemark client is useful if you regularly log in as two different users,
-or to different instances of the Bytemark API. You can set up and use different configurations with the --config-dir global flag.
+or to different instances of the Bytemark API. Each configuration is kept in a named profile, which has its own endpoints,
+default account and group, output format and cached login token.
 
-For example, to set up your default configuration to log in by default as 'alice', and one configuration where you log in as 'bob'
-with a yubikey, run the following commands (windows users, note that in cmd.exe you'll need to use --config-dir="%HOME%\.bob"):
+The profile called 'default' is the configuration in the top level of the config directory (~/.bytemark unless --config-dir
+is set). Other profiles are created by setting some configuration in them with the --profile global flag. For example, to
+log in by default as 'alice', and set up a 'staging' profile where you log in to the staging brain as 'bob', run:
 
     bytemark update config --user alice
-    bytemark --config-dir="$HOME/.bob" update config --user bob --yubikey
-	
+    bytemark --profile staging update config --endpoint https://staging.bigv.io --user bob
+
 Almost every one of the global flags can be set into the config in this way - see 'bytemark update config --help' for the full list of accepted flags.
 
-At this point you can set up an alias to use your 'bob' configuration. Say you use bash/zsh, add the following to your bashrc/zshrc:
-    alias bytemark-bob='bytemark --config-dir="$HOME/.bob"'
+The profile to use is chosen by the first of these which is set:
+    * the --profile global flag
+    * the BYTEMARK_PROFILE environment variable
+    * the profile chosen with 'bytemark config use-profile <profile>'
 
-Alternatively you could create a shim in your PATH like the following:
+So now 'bytemark show servers' will list alice's servers, and 'bytemark --profile staging show servers' will list bob's.
+Run 'bytemark config use-profile staging' to use bob's configuration by default instead, and 'bytemark config use-profile default' to switch back.
 
-  #!/bin/sh
-  bytemark --config-dir="$HOME/.bob" "$@"
+'bytemark show profiles' lists all your profiles, and 'bytemark config copy-profile <from> <to>' makes a new profile with the same configuration
+as an existing one (except for the cached token).
 
-Either way, now you can run 'bytemark-bob show servers' to list all the servers in bob's default account and 'bytemark show servers' to do the same for alice.`,
+Completely separate config directories can still be used with the --config-dir global flag, each with their own profiles.`,
 	}, cli.Command{
 		Name:      "scripting",
 		Usage:     "information on scripting with the client",
@@ -2,11 +2,14 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	bmapp "github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
@@ -64,14 +67,14 @@ func main() {
 	// add admin commands if --admin is set
 	wantAdminCmds, err := config.GetBool("admin")
 	if err != nil {
-		os.Exit(int(util.ProcessError(err)))
+		os.Exit(int(processError(config, err)))
 	}
 
 	myCommands := Commands(wantAdminCmds)
 
 	app, err := bmapp.BaseAppSetup(flags, myCommands)
 	if err != nil {
-		os.Exit(int(util.ProcessError(err)))
+		os.Exit(int(processError(config, err)))
 	}
 
 	client, err := lib.NewWithURLs(lib.EndpointURLs{
@@ -82,10 +85,18 @@ func main() {
 		Auth:    config.GetIgnoreErr("auth-endpoint"),
 	})
 	if err != nil {
-		os.Exit(int(util.ProcessError(err)))
+		os.Exit(int(processError(config, err)))
 	}
 	client.SetDebugLevel(config.GetDebugLevel())
 	setInsecure(client, config)
+	client, err = setTimeoutAndRetries(client, config)
+	if err != nil {
+		os.Exit(int(processError(config, err)))
+	}
+	client, err = setCassette(client, config)
+	if err != nil {
+		os.Exit(int(processError(config, err)))
+	}
 
 	bmapp.SetClientAndConfig(app, client, config)
 
@@ -93,7 +104,16 @@ func main() {
 
 	err = app.Run(args)
 
-	os.Exit(int(util.ProcessError(err)))
+	os.Exit(int(processError(config, err)))
+}
+
+// processError outputs err as JSON if the output-format is json, or as a
+// human-readable message otherwise, and returns the exit code to use.
+func processError(config config.Manager, err error) util.ExitCode {
+	if config.GetIgnoreErr("output-format") == "json" {
+		return util.ProcessErrorJSON(log.ErrWriter, err)
+	}
+	return util.ProcessError(err)
 }
 
 func setInsecure(client lib.Client, config config.Manager) {
@@ -102,19 +122,60 @@ func setInsecure(client lib.Client, config config.Manager) {
 	}
 }
 
-func outputDebugInfo(config config.Manager) {
+// setTimeoutAndRetries returns a copy of client which uses the timeout and
+// retries config vars.
+func setTimeoutAndRetries(client lib.Client, config config.Manager) (lib.Client, error) {
+	timeout, err := time.ParseDuration(config.GetIgnoreErr("timeout"))
+	if err != nil {
+		return client, fmt.Errorf("timeout should be a duration like 30s or 2m: %s", err)
+	}
+	retries, err := strconv.Atoi(config.GetIgnoreErr("retries"))
+	if err != nil {
+		return client, fmt.Errorf("retries should be a number: %s", err)
+	}
+	policy := lib.DefaultRetryPolicy()
+	policy.Retries = retries
+	client = lib.WithTimeout(client, timeout)
+	return lib.WithRetryPolicy(client, policy), nil
+}
+
+// setCassette returns a copy of client which records to or replays from the
+// cassette file given by the record or replay config vars, if either is set.
+func setCassette(client lib.Client, config config.Manager) (lib.Client, error) {
+	record := config.GetIgnoreErr("record")
+	replay := config.GetIgnoreErr("replay")
+	var cassette *lib.Cassette
+	var err error
+	switch {
+	case record != "" && replay != "":
+		return client, fmt.Errorf("--record and --replay can't be used at the same time")
+	case record != "":
+		cassette, err = lib.RecordCassette(record)
+	case replay != "":
+		cassette, err = lib.ReplayCassette(replay)
+	default:
+		return client, nil
+	}
+	if err != nil {
+		return client, err
+	}
+	return lib.WithCassette(client, cassette), nil
+}
+
+func outputDebugInfo(conf config.Manager) {
 	log.Debugf(log.LvlOutline, "bytemark-client %s\r\n\r\n", lib.Version)
-	// assemble a string of config vars (excluding token)
-	vars, err := config.GetAll()
+	// assemble a string of config vars (excluding the sensitive ones, which
+	// aren't even read)
+	vars, err := conf.GetAllInsensitive()
 	if err != nil {
 		log.Debugf(log.LvlFlags, "(not a real problem maybe): had trouble getting all config vars: %s\r\n", err.Error())
 	}
 
-	log.Debugf(log.LvlFlags, "reading config from %s\r\n\r\n", config.ConfigDir())
+	log.Debugf(log.LvlFlags, "reading config from %s\r\n\r\n", conf.ConfigDir())
 	log.Debug(log.LvlFlags, "config vars:")
 	for _, v := range vars {
-		if v.Name == "token" {
-			log.Debugf(log.LvlFlags, "  %s (%s): not printed for security\r\n", v.Name, v.Source)
+		if config.IsSensitiveVar(v.Name) {
+			log.Debugf(log.LvlFlags, "  %s: not printed for security\r\n", v.Name)
 			continue
 		}
 		log.Debugf(log.LvlFlags, "  %s (%s): '%s'\r\n", v.Name, v.Source, v.Value)
@@ -184,6 +245,9 @@ func prepConfig() (flags []cli.Flag, args []string, conf config.Manager) {
 		os.Exit(int(util.ProcessError(err)))
 	}
 	flargs := conf.ImportFlags(flagset)
+	if _, err = conf.CurrentProfile(); err != nil {
+		os.Exit(int(util.ProcessError(err)))
+	}
 
 	//juggle the arguments in order to get the executable on the beginning
 	args = make([]string, len(flargs)+1)
@@ -8,6 +8,7 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flagsets"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/wait"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util"
@@ -34,8 +35,12 @@ The root password will be output on stdout if the imaging succeeded, otherwise n
 				Description: `Image the given server with the specified image, prompting for confirmation.
 Specify --force to prevent prompting.
 
-The root password will be output on stdout if the imaging succeeded, otherwise nothing will (and the exit code will be nonzero)`,
-				Flags: cliutil.ConcatFlags(flagsets.ImageInstallFlags, flagsets.ImageInstallAuthFlags,
+The root password will be output on stdout if the imaging succeeded, otherwise nothing will (and the exit code will be nonzero)
+
+If --firstboot-template is set then the file is read as a Go text/template and added to the end of the firstboot script. Templates can use {{ .Name }}, {{ .Group }}, {{ .Account }}, {{ .Hostname }} and {{ .Zone }} for the server's details, and {{ .Vars.key }} for values given as --var key=value. --firstboot-template can be given several times to build the script out of several parts, which run in the order given.
+
+If --wait is set then bytemark will wait until the server has been imaged and started back up before exiting.`,
+				Flags: cliutil.ConcatFlags(flagsets.ImageInstallFlags, flagsets.ImageInstallAuthFlags, flagsets.FirstbootTemplateFlags, wait.OptionalFlags,
 					[]cli.Flag{
 						forceFlag, cli.GenericFlag{
 							Name:  "server",
@@ -45,6 +50,10 @@ The root password will be output on stdout if the imaging succeeded, otherwise n
 					}),
 				Action: app.Action(args.Optional("server"), with.RequiredFlags("server"), with.Auth, func(c *app.Context) (err error) {
 					vmName := flags.VirtualMachineName(c, "server")
+					err = c.Client().EnsureVirtualMachineName(&vmName)
+					if err != nil {
+						return
+					}
 					imageInstall, defaulted, err := flagsets.PrepareImageInstall(c, true)
 					if err != nil {
 						return
@@ -53,6 +62,18 @@ The root password will be output on stdout if the imaging succeeded, otherwise n
 					if defaulted {
 						return c.Help("No image was specified")
 					}
+					err = flagsets.RenderFirstbootScript(c, &imageInstall, func() (flagsets.FirstbootTemplateData, error) {
+						vm, err := c.Client().GetVirtualMachine(vmName)
+						data := flagsets.NewFirstbootTemplateData(vmName, vm.Hostname, vm.ZoneName)
+						// the server may have been specified by its numeric ID
+						if vm.Name != "" {
+							data.Name = vm.Name
+						}
+						return data, err
+					})
+					if err != nil {
+						return
+					}
 
 					log.Logf("%s will be reimaged with the following. Note that this will wipe all data on the main disc:\r\n\r\n", vmName)
 					// don't use ctx.App().ErrWriter / ctx.LogErr here to avoid outputting a password to debug.log
@@ -71,7 +92,10 @@ The root password will be output on stdout if the imaging succeeded, otherwise n
 						// by default everything gets output to stdout + debug.log - don't want to output the password to debug log
 						_, _ = fmt.Fprintf(os.Stdout, imageInstall.RootPassword)
 					}
-					return
+					if err != nil {
+						return
+					}
+					return wait.IfRequested(c, wait.VMReimagedWith(vmName, imageInstall.Distribution))
 				}),
 			},
 		},
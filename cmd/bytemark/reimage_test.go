@@ -3,12 +3,14 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/testutil"
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/cheekybits/is"
+	mock "github.com/maraino/go-mock"
 )
 
 func TestReimage(t *testing.T) {
@@ -43,6 +45,41 @@ func TestReimage(t *testing.T) {
 	}
 }
 
+func TestReimageWait(t *testing.T) {
+	is := is.New(t)
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands)
+
+	vmname := pathers.VirtualMachineName{
+		VirtualMachine: "test-server",
+		GroupName: pathers.GroupName{
+			Group:   "test-group",
+			Account: "test-account",
+		},
+	}
+
+	image := brain.ImageInstall{
+		Distribution: "symbiosis",
+		RootPassword: "gNFgYYIgayyDOjkV",
+	}
+
+	config.When("GetVirtualMachine").Return(defVM)
+	config.When("Force").Return(true)
+
+	c.When("ReimageVirtualMachine", vmname, image).Return(nil).Times(1)
+	// the server is still running its old image at first, so the wait isn't
+	// over until it has powered off and back on
+	c.When("GetVirtualMachine", vmname).Return(brain.VirtualMachine{PowerOn: true, LastImagedWith: "symbiosis"}, nil).Times(1)
+	c.When("GetVirtualMachine", vmname).Return(brain.VirtualMachine{LastImagedWith: "symbiosis"}, nil).Times(1)
+	c.When("GetVirtualMachine", vmname).Return(brain.VirtualMachine{PowerOn: true, LastImagedWith: "symbiosis"}, nil).Times(1)
+
+	err := app.Run([]string{"bytemark", "reimage", "server", "--force", "--wait", "--image", image.Distribution, "--root-password", image.RootPassword, "test-server.test-group.test-account"})
+
+	is.Nil(err)
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
+
 func TestReimageFileFlags(t *testing.T) {
 	is := is.New(t)
 	config, c, app := testutil.BaseTestAuthSetup(t, false, commands)
@@ -85,3 +122,122 @@ func TestReimageFileFlags(t *testing.T) {
 	_ = os.Remove("firstboot")
 	_ = os.Remove("authorized-keys")
 }
+
+func TestReimageFirstbootTemplates(t *testing.T) {
+	is := is.New(t)
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands)
+
+	vmname := pathers.VirtualMachineName{
+		VirtualMachine: "test-server",
+		GroupName: pathers.GroupName{
+			Group:   "test-group",
+			Account: "test-account",
+		},
+	}
+
+	image := brain.ImageInstall{
+		FirstbootScript: "#!/bin/sh\napt-get update\nhostname test-server.test-group.test-account.uk0.bigv.io # in york\necho role=web >> /etc/facts\n",
+		Distribution:    "image",
+		RootPassword:    "test-pass",
+	}
+
+	err := ioutil.WriteFile("hostname.tmpl", []byte("hostname {{ .Hostname }} # in {{ .Zone }}"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("hostname.tmpl")
+	err = ioutil.WriteFile("role.tmpl", []byte("echo role={{ .Vars.role }} >> /etc/facts\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("role.tmpl")
+
+	config.When("GetVirtualMachine").Return(defVM)
+	config.When("Force").Return(true)
+
+	c.When("GetVirtualMachine", vmname).Return(brain.VirtualMachine{
+		Name:     "test-server",
+		Hostname: "test-server.test-group.test-account.uk0.bigv.io",
+		ZoneName: "york",
+	}, nil).Times(1)
+	c.When("ReimageVirtualMachine", vmname, image).Return(nil).Times(1)
+
+	err = app.Run([]string{"bytemark", "reimage", "server", "--force", "--image", "image", "--root-password", "test-pass", "--firstboot-script", "#!/bin/sh\napt-get update", "--firstboot-template", "hostname.tmpl", "--firstboot-template", "role.tmpl", "--var", "role=web", "test-server.test-group.test-account"})
+
+	is.Nil(err)
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestReimageFirstbootTemplateResolvesName(t *testing.T) {
+	is := is.New(t)
+	config, c, app := testutil.BaseTestAuthSetup(t, false, commands)
+
+	// no account set in the config, so the client has to fill it in
+	config.When("GetVirtualMachine").Return(pathers.VirtualMachineName{GroupName: pathers.GroupName{Group: "default"}})
+	config.When("Force").Return(true)
+
+	vmname := pathers.VirtualMachineName{
+		VirtualMachine: "1234",
+		GroupName: pathers.GroupName{
+			Group:   "default",
+			Account: "blank-account-name",
+		},
+	}
+	image := brain.ImageInstall{
+		FirstbootScript: "echo test-server blank-account-name\n",
+		Distribution:    "image",
+		RootPassword:    "test-pass",
+	}
+
+	err := ioutil.WriteFile("name.tmpl", []byte("echo {{ .Name }} {{ .Account }}\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("name.tmpl")
+
+	c.When("GetVirtualMachine", vmname).Return(brain.VirtualMachine{Name: "test-server"}, nil).Times(1)
+	c.When("ReimageVirtualMachine", vmname, image).Return(nil).Times(1)
+
+	err = app.Run([]string{"bytemark", "reimage", "server", "--force", "--image", "image", "--root-password", "test-pass", "--firstboot-template", "name.tmpl", "1234"})
+
+	is.Nil(err)
+	if ok, err := c.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestReimageFirstbootTemplateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{name: "missing var", template: "echo {{ .Vars.role }}"},
+		{name: "bad template", template: "echo {{ .Hostname "},
+		{name: "too long", template: strings.Repeat("#", brain.FirstbootScriptMaxLength+1)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, c, app := testutil.BaseTestAuthSetup(t, false, commands)
+			err := ioutil.WriteFile("firstboot.tmpl", []byte(test.template), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove("firstboot.tmpl")
+
+			config.When("GetVirtualMachine").Return(defVM)
+			config.When("Force").Return(true)
+			c.When("GetVirtualMachine", mock.Any).Return(brain.VirtualMachine{Name: "test-server"}, nil)
+			c.When("ReimageVirtualMachine", mock.Any, mock.Any).Return(nil).Times(0)
+
+			err = app.Run([]string{"bytemark", "reimage", "server", "--force", "--image", "image", "--firstboot-template", "firstboot.tmpl", "test-server.test-group.test-account"})
+			if err == nil {
+				t.Error("expected an error")
+			}
+			if ok, err := c.Verify(); !ok {
+				t.Fatal(err)
+			}
+		})
+	}
+}
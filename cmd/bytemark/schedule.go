@@ -1,11 +1,14 @@
 package main
 
 import (
+	"fmt"
+
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/args"
-	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/flags"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/bulk"
 	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app/with"
-	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/cliutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/urfave/cli"
 )
 
@@ -28,8 +31,8 @@ bytemark schedule backups --start "2017-04-05T14:37:00+02:00" fileserver very-im
 			{
 				Name:      "backups",
 				Usage:     "schedule backups to occur at a regular frequency",
-				UsageText: "schedule backups [--start <date>] <server> <disc> [<interval>]",
-				Flags: []cli.Flag{
+				UsageText: "schedule backups [--start <date>] [--group <group> [--filter <expression>]] <server> <disc> [<interval>]",
+				Flags: cliutil.ConcatFlags(app.OutputFlags("results", "array"), []cli.Flag{
 					cli.StringFlag{
 						Name:  "start",
 						Usage: "date & time the schedule starts. Assumes BST/GMT (depending on time of year) if not specified - defaults to 00:00",
@@ -38,17 +41,12 @@ bytemark schedule backups --start "2017-04-05T14:37:00+02:00" fileserver very-im
 						Name:  "disc",
 						Usage: "the disc to schedule backups of",
 					},
-					cli.GenericFlag{
-						Name:  "server",
-						Usage: "the server the disc belongs to",
-						Value: new(flags.VirtualMachineNameFlag),
-					},
 					cli.IntFlag{
 						Name:  "interval",
 						Usage: "the interval between backups, in seconds. Defaults to 86400 (daily).",
 						Value: 86400,
 					},
-				},
+				}, bulk.Flags),
 				Description: `schedule backups to occur at a regular interval (defined in seconds)
 		
 EXAMPLES
@@ -57,19 +55,27 @@ To have daily backups at midnight of a server called 'fileservers' 'very-importa
 bytemark schedule backups --start 00:00 fileserver very-important-data 86400
 
 To have hourly backups starting at 14:37 (Central European Summer Time) on the 5th of April, 2017:
-bytemark schedule backups --start "2017-04-05T14:37:00+02:00" fileserver very-important-data 3600`,
-				Action: app.Action(args.Optional("server", "disc", "interval"), with.RequiredFlags("server", "disc"), with.Auth, func(c *app.Context) (err error) {
+bytemark schedule backups --start "2017-04-05T14:37:00+02:00" fileserver very-important-data 3600
+
+To have daily backups of the 'vda' disc of every server in the 'web' group, scheduling up to 8 at a time:
+bytemark schedule backups --group web --parallel 8 --disc vda`,
+				Action: app.Action(args.Optional("server", "disc", "interval"), with.RequiredFlags("disc"), with.Auth, func(c *app.Context) (err error) {
 					start := c.String("start")
 					if start == "" {
 						start = "00:00"
 					}
 
-					vmName := flags.VirtualMachineName(c, "server")
-					sched, err := c.Client().CreateBackupSchedule(vmName, c.String("disc"), start, c.Int("interval"))
-					if err == nil {
-						log.Logf("Schedule set. Backups will be taken every %d seconds.", sched.Interval)
+					servers, err := bulk.Servers(c)
+					if err != nil {
+						return
 					}
-					return
+					return bulk.Run(c, servers, func(c *app.Context, vmName pathers.VirtualMachineName) (string, error) {
+						sched, err := c.Client().CreateBackupSchedule(vmName, c.String("disc"), start, c.Int("interval"))
+						if err != nil {
+							return "", err
+						}
+						return fmt.Sprintf("Schedule set. Backups will be taken every %d seconds.", sched.Interval), nil
+					})
 				}),
 			},
 		},
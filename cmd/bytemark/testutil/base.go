@@ -128,6 +128,7 @@ func BaseTestAuthSetup(t *testing.T, admin bool, commands []cli.Command) (config
 	config, c, cliapp = BaseTestSetup(t, admin, commands)
 
 	config.When("Get", "account").Return("test-account")
+	config.When("GetIgnoreErr", "api-key").Return("")
 	config.When("GetIgnoreErr", "token").Return("test-token")
 	config.When("GetIgnoreErr", "user").Return("test-user")
 	config.When("GetIgnoreErr", "yubikey").Return("")
@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/app"
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/config"
+	"github.com/BytemarkHosting/bytemark-client/lib/fake"
+	"github.com/BytemarkHosting/bytemark-client/util/log"
+	"github.com/urfave/cli"
+)
+
+// FakeTestSetup starts a fake brain with a user called alice who owns an
+// account called alice-account, and produces a cli.App with the given
+// commands which talks to it. Unlike BaseTestSetup nothing is mocked - the app
+// has a real config in a temporary directory, logged in as alice - so
+// commands can be run one after another, each seeing what the last did.
+// cleanup must be called once the test is finished.
+func FakeTestSetup(t *testing.T, commands []cli.Command) (s *fake.Server, cliapp *cli.App, cleanup func()) {
+	cli.OsExiter = func(_ int) {}
+	s = fake.NewServer()
+	s.AddUser("alice", "secret")
+	if _, err := s.AddAccount("alice-account", "alice"); err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "bytemark-fake-test")
+	if err != nil {
+		s.Close()
+		t.Fatal(err)
+	}
+	cleanup = func() {
+		s.Close()
+		_ = os.RemoveAll(dir)
+	}
+
+	client, err := s.Client()
+	if err == nil {
+		err = client.AuthWithCredentials(map[string]string{"username": "alice", "password": "secret"})
+	}
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	conf, err := config.New(dir)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{
+		"user":    "alice",
+		"account": "alice-account",
+		"token":   client.GetSessionToken(),
+	} {
+		if err := conf.SetPersistent(name, value, "TEST"); err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+	}
+
+	cliapp, err = app.BaseAppSetup(app.GlobalFlags(), commands)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	app.SetClientAndConfig(cliapp, client, conf)
+
+	buf := bytes.Buffer{}
+	cliapp.Metadata["buf"] = &buf
+	cliapp.Metadata["debugWriter"] = &TestWriter{t}
+	fixCommandFullName(cliapp, commands)
+	cliapp.Writer = &buf
+	cliapp.ErrWriter = &buf
+	log.Writer = &buf
+	log.ErrWriter = &buf
+	return
+}
@@ -0,0 +1,149 @@
+package util
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+)
+
+// ErrorCode is a stable, machine-readable name for a kind of error. They are
+// output as part of an ErrorReport and won't change between releases, so
+// scripts can rely on them.
+type ErrorCode string
+
+const (
+	// ErrorCodeUnknown is used for errors which bytemark-client doesn't know how to deal with
+	ErrorCodeUnknown ErrorCode = "unknown"
+	// ErrorCodeClientBug is used when bytemark-client knows it's faulty
+	ErrorCodeClientBug ErrorCode = "client_bug"
+	// ErrorCodeBadInput is used when the command, name, or flags given were malformed
+	ErrorCodeBadInput ErrorCode = "bad_input"
+	// ErrorCodeUserExit is used when the user said no to a prompt
+	ErrorCodeUserExit ErrorCode = "user_exit"
+	// ErrorCodeWontDeletePopulated is used when asked to delete a group which still has servers in
+	ErrorCodeWontDeletePopulated ErrorCode = "wont_delete_populated"
+	// ErrorCodeSubprocessFailed is used when a subprocess (e.g. ssh or a browser) couldn't be run or failed
+	ErrorCodeSubprocessFailed ErrorCode = "subprocess_failed"
+	// ErrorCodeNoDefaultAccount is used when no account was specified and a default couldn't be found
+	ErrorCodeNoDefaultAccount ErrorCode = "no_default_account"
+	// ErrorCodeTimedOut is used when waiting for something took too long
+	ErrorCodeTimedOut ErrorCode = "timed_out"
+	// ErrorCodeBulkOperationFailed is used when an operation on many servers failed for some of them
+	ErrorCodeBulkOperationFailed ErrorCode = "bulk_operation_failed"
+	// ErrorCodeInsecureEndpoint is used when an endpoint was plain HTTP but --insecure wasn't specified
+	ErrorCodeInsecureEndpoint ErrorCode = "insecure_endpoint"
+	// ErrorCodeCantConnect is used when an HTTP connection to the auth server or API couldn't be made
+	ErrorCodeCantConnect ErrorCode = "cant_connect"
+	// ErrorCodeAuthFailed is used when creating an auth session failed for some other reason
+	ErrorCodeAuthFailed ErrorCode = "auth_failed"
+	// ErrorCodeInvalidCredentials is used when the credentials contained invalid characters
+	ErrorCodeInvalidCredentials ErrorCode = "invalid_credentials"
+	// ErrorCodeBadCredentials is used when the credentials didn't match a user
+	ErrorCodeBadCredentials ErrorCode = "bad_credentials"
+	// ErrorCode2FARequired is used when a 2FA one-time password is needed but wasn't given
+	ErrorCode2FARequired ErrorCode = "2fa_required"
+	// ErrorCodeUnauthorized is used when the API rejected the session token or API key (HTTP 401)
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	// ErrorCodeAPIKeyExpired is used when the API key being used has expired
+	ErrorCodeAPIKeyExpired ErrorCode = "api_key_expired"
+	// ErrorCodeForbidden is used when the user doesn't have permission to do something (HTTP 403)
+	ErrorCodeForbidden ErrorCode = "forbidden"
+	// ErrorCodeNotFound is used when something doesn't exist or can't be seen by the user (HTTP 404)
+	ErrorCodeNotFound ErrorCode = "not_found"
+	// ErrorCodeBadRequest is used when the API rejected the request as invalid (HTTP 400)
+	ErrorCodeBadRequest ErrorCode = "bad_request"
+	// ErrorCodeInternalServerError is used when the API reported an internal error (HTTP 500)
+	ErrorCodeInternalServerError ErrorCode = "internal_server_error"
+	// ErrorCodeServiceUnavailable is used when the API is temporarily unavailable (HTTP 503)
+	ErrorCodeServiceUnavailable ErrorCode = "service_unavailable"
+	// ErrorCodeUnknownAPIError is used when the API returned a status code the client didn't expect
+	ErrorCodeUnknownAPIError ErrorCode = "unknown_api_error"
+)
+
+// ErrorReport is a machine-readable description of an error, which is output
+// in place of the usual error message when --output-format json is used.
+type ErrorReport struct {
+	Code     ErrorCode `json:"code"`
+	Message  string    `json:"message"`
+	ExitCode ExitCode  `json:"exit_code"`
+	// These are only set for errors which came from an HTTP request.
+	StatusCode int    `json:"status_code,omitempty"`
+	Method     string `json:"method,omitempty"`
+	URL        string `json:"url,omitempty"`
+	// Problems is the brain's validation messages for each field, for bad_request errors.
+	Problems map[string][]string `json:"problems,omitempty"`
+}
+
+// NewErrorReport makes an ErrorReport describing err
+func NewErrorReport(err error) ErrorReport {
+	code, message, exitCode := classifyError(err)
+	report := ErrorReport{
+		Code:     code,
+		Message:  message,
+		ExitCode: exitCode,
+	}
+	if code == ErrorCodeUnknown {
+		report.Message = err.Error()
+	}
+	if apiErr, ok := apiErrorOf(err); ok {
+		report.StatusCode = apiErr.StatusCode
+		report.Method = apiErr.Method
+		if apiErr.URL != nil {
+			report.URL = apiErr.URL.String()
+		}
+	}
+	switch e := err.(type) {
+	case lib.BadRequestError:
+		report.Problems = e.Problems
+	case lib.InsecureConnectionError:
+		if e.Request != nil {
+			u := e.Request.GetURL()
+			report.URL = u.String()
+		}
+	}
+	return report
+}
+
+// ProcessErrorJSON is like ProcessError, but outputs the error to wr as an
+// ErrorReport in JSON, wrapped in an object with a single "error" key.
+func ProcessErrorJSON(wr io.Writer, err error) ExitCode {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	report := NewErrorReport(err)
+	if report.ExitCode == ExitCodeSuccess {
+		return ExitCodeSuccess
+	}
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "    ")
+	_ = enc.Encode(map[string]ErrorReport{"error": report})
+	return report.ExitCode
+}
+
+// apiErrorOf gets the APIError out of any of the lib errors which embed one
+func apiErrorOf(err error) (lib.APIError, bool) {
+	switch e := err.(type) {
+	case lib.APIError:
+		return e, true
+	case lib.BadNameError:
+		return e.APIError, e.Method != ""
+	case lib.BadRequestError:
+		return e.APIError, true
+	case lib.UnauthorizedError:
+		return e.APIError, true
+	case lib.APIKeyRejectedError:
+		return e.APIError, true
+	case lib.ForbiddenError:
+		return e.APIError, true
+	case lib.NotFoundError:
+		return e.APIError, true
+	case lib.InternalServerError:
+		return e.APIError, true
+	case lib.ServiceUnavailableError:
+		return e.APIError, true
+	case lib.UnknownStatusCodeError:
+		return e.APIError, true
+	}
+	return lib.APIError{}, false
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	auth3 "github.com/BytemarkHosting/auth-client"
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestNewErrorReport(t *testing.T) {
+	vmURL, _ := url.Parse("https://uk0.bigv.io/accounts/a/groups/g/virtual_machines/vm")
+	apiErr := lib.APIError{
+		Method:     "GET",
+		URL:        vmURL,
+		StatusCode: 404,
+	}
+	unauthErr := apiErr
+	unauthErr.StatusCode = 401
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorReport
+	}{
+		{
+			name: "not found",
+			err:  lib.NotFoundError{APIError: apiErr},
+			expected: ErrorReport{
+				Code:       ErrorCodeNotFound,
+				Message:    lib.NotFoundError{APIError: apiErr}.Error(),
+				ExitCode:   ExitCodeNotFound,
+				StatusCode: 404,
+				Method:     "GET",
+				URL:        vmURL.String(),
+			},
+		}, {
+			name: "bad request",
+			err: lib.BadRequestError{
+				APIError: lib.APIError{Method: "POST", URL: vmURL, StatusCode: 400},
+				Problems: map[string][]string{"name": {"is too short"}},
+			},
+			expected: ErrorReport{
+				Code:       ErrorCodeBadRequest,
+				Message:    "• Name is too short",
+				ExitCode:   ExitCodeBadRequest,
+				StatusCode: 400,
+				Method:     "POST",
+				URL:        vmURL.String(),
+				Problems:   map[string][]string{"name": {"is too short"}},
+			},
+		}, {
+			name: "unauthorized",
+			err:  lib.UnauthorizedError{APIError: unauthErr},
+			expected: ErrorReport{
+				Code:       ErrorCodeUnauthorized,
+				Message:    lib.UnauthorizedError{APIError: unauthErr}.Error(),
+				ExitCode:   ExitCodeUnauthorized,
+				StatusCode: 401,
+				Method:     "GET",
+				URL:        vmURL.String(),
+			},
+		}, {
+			name: "2fa required",
+			err:  &auth3.Error{Message: "Couldn't create session", Err: errors.New("Missing 2FA")},
+			expected: ErrorReport{
+				Code:     ErrorCode2FARequired,
+				Message:  "A 2FA one-time password is required to log in - use --2fa-otp to supply one",
+				ExitCode: ExitCode2FARequired,
+			},
+		}, {
+			name: "insecure endpoint",
+			err:  lib.InsecureConnectionError{},
+			expected: ErrorReport{
+				Code:     ErrorCodeInsecureEndpoint,
+				Message:  "Refusing to talk to an endpoint over plain HTTP. Use --insecure if you really meant to.",
+				ExitCode: ExitCodeInsecureEndpoint,
+			},
+		}, {
+			name: "bulk operation",
+			err:  BulkOperationFailedError{Failed: 1, Total: 3},
+			expected: ErrorReport{
+				Code:     ErrorCodeBulkOperationFailed,
+				Message:  "Failed for 1 of 3 servers",
+				ExitCode: ExitCodeBulkOperationFailed,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.name, test.expected, NewErrorReport(test.err))
+		})
+	}
+}
+
+func TestProcessErrorJSON(t *testing.T) {
+	buf := bytes.Buffer{}
+	exitCode := ProcessErrorJSON(&buf, TimedOutError{What: "web1 to stop"})
+	assert.Equal(t, "exit code", ExitCode(ExitCodeTimedOut), exitCode)
+
+	out := map[string]map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("output wasn't valid JSON: %s\n%s", err, buf.String())
+	}
+	assert.Equal(t, "code", "timed_out", out["error"]["code"])
+	assert.Equal(t, "exit_code", float64(ExitCodeTimedOut), out["error"]["exit_code"])
+
+	buf.Reset()
+	assert.Equal(t, "nil error", ExitCode(ExitCodeSuccess), ProcessErrorJSON(&buf, nil))
+	assert.Equal(t, "nil error output", "", buf.String())
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 )
@@ -28,6 +29,29 @@ func (e UsageDisplayedError) Error() string {
 	return e.TheProblem + "\r\n\r\nFor more information, see `bytemark help " + e.Command + "`"
 }
 
+// BulkOperationFailedError is returned when an operation on many servers
+// failed for at least one of them.
+type BulkOperationFailedError struct {
+	Failed int
+	Total  int
+}
+
+func (e BulkOperationFailedError) Error() string {
+	return fmt.Sprintf("Failed for %d of %d servers", e.Failed, e.Total)
+}
+
+// TimedOutError is returned when waiting for something to happen took longer
+// than the user was willing to wait.
+type TimedOutError struct {
+	// What describes the thing being waited for
+	What    string
+	Timeout time.Duration
+}
+
+func (e TimedOutError) Error() string {
+	return fmt.Sprintf("Timed out after %s waiting for %s", e.Timeout, e.What)
+}
+
 // WontDeleteGroupWithVMsError is returned when 'delete group' was called on a group with stuff in, without --recursive being specified
 type WontDeleteGroupWithVMsError struct {
 	Group pathers.GroupName
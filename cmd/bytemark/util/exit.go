@@ -50,6 +50,15 @@ const (
 	// ExitCodeNoDefaultAccount is the exit code returned when the client couldn't determine a default account. In this situation, the user should manually specify the account to use with the --account flag or using `bytemark config set account`
 	ExitCodeNoDefaultAccount = 9
 
+	// ExitCodeTimedOut is the exit code returned when the client gave up waiting for something to happen, e.g. in `bytemark wait`
+	ExitCodeTimedOut = 10
+
+	// ExitCodeBulkOperationFailed is the exit code returned when an operation on many servers failed for at least one of them
+	ExitCodeBulkOperationFailed = 11
+
+	// ExitCodeInsecureEndpoint is the exit code returned when an endpoint was plain HTTP but --insecure wasn't specified
+	ExitCodeInsecureEndpoint = 12
+
 	// ExitCodeUnknownError is the exit code returned when we got an error we couldn't deal with.
 	ExitCodeUnknownError = 49
 
@@ -61,7 +70,7 @@ const (
 	// ExitCodeAuthInternalError is the exit code returned when the auth server reported an internal error.
 	ExitCodeAuthInternalError = 51
 	// ExitCodeAPIInternalError is the exit code returned when the API server reported an internal error.
-	ExitCodeAPIInternalError = 152
+	ExitCodeAPIInternalError = 151
 
 	// ExitCodeCantParseAuthResponse is the exit code returned when the auth server returned something we were unable to parse.
 	ExitCodeCantParseAuthResponse = 52
@@ -72,6 +81,11 @@ const (
 	ExitCodeInvalidCredentials = 53
 	// ExitCodeBadCredentials is the exit code returned when the auth server says your credentials don't match a user in its database.
 	ExitCodeBadCredentials = 54
+	// ExitCodeUnauthorized is the exit code returned when the API server rejected your session token or API key.
+	ExitCodeUnauthorized = 154
+
+	// ExitCode2FARequired is the exit code returned when the auth server wants a 2FA one-time password which wasn't given.
+	ExitCode2FARequired = 55
 
 	// ExitCodeActionNotPermitted is the exit code returned when the API server says you haven't got permission to do that.
 	ExitCodeActionNotPermitted = 155
@@ -102,6 +116,9 @@ Exit code ranges:
 
     Exit codes between 50 and 249 with the same tens and units have the same meaning but for a different endpoint
 
+    When --output-format json is used, errors are output as a JSON object
+    containing the exit code and a code which names the kind of error.
+
   0 -  49 Exit codes:
 
     0
@@ -120,6 +137,14 @@ Exit code ranges:
 	The program was called with malformed arguments
     8
 	Attempting to execute a subprocess failed
+    9
+	Couldn't work out which account to use - set one with --account or bytemark config set account
+    10
+	Gave up waiting for something to happen (e.g. in bytemark wait)
+    11
+	An operation on many servers failed for at least one of them
+    12
+	An endpoint was plain HTTP and --insecure wasn't specified
 
  50 - 249 Exit codes:
 
@@ -135,8 +160,12 @@ Exit code ranges:
      53
 	Your credentials were rejected for containing invalid characters or fields.
 
-     54
-	Your credentials did not match any user on file - check you entered them correctly
+     54 / 154
+	Your credentials did not match any user on file - check you entered them correctly.
+	154 means the API server rejected your session token or API key.
+
+     55
+	A 2FA one-time password is needed to log in - supply one with --2fa-otp
 
     155
 	Your user account doesn't have authorisation to perform that action
@@ -147,6 +176,9 @@ Exit code ranges:
 	    * Your user account doesn't have authorisation to see it
 	    * Protocol mismatch between the Bytemark endpoint and our client (i.e. client out of date).
 
+    157
+	The API server rejected the request as invalid, e.g. a name being too short or having wrong characters in
+
     149 / 249
 
         An unknown error fell out of the auth / API library.
@@ -172,126 +204,204 @@ func ProcessError(err error, message ...string) ExitCode {
 	if len(message) > 0 {
 		log.Error(message)
 	}
-	errorMessage := "Unknown error"
-	exitCode := ExitCode(ExitCodeUnknownError)
-	if err != nil {
-		switch e := err.(type) {
-		case *auth3.Error:
-			// TODO(telyn): I feel like this entire chunk should be in github.com/BytemarkHosting/auth-client
-			switch e.Err.(type) {
-			case *url.Error:
-				urlErr, _ := e.Err.(*url.Error)
-				if urlErr.Err != nil {
-					if opError, ok := urlErr.Err.(*net.OpError); ok {
-						errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %v", opError.Err)
-					} else {
-						errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %T %v\r\nPlease file a bug report quoting this message.", urlErr.Err, urlErr.Err)
-					}
-				} else {
-					errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %v", urlErr)
-				}
-				exitCode = ExitCodeCantConnectAuth
-			default:
-				errorMessage = fmt.Sprintf("Couldn't create auth session: %v", e.Err)
-				exitCode = ExitCodeUnknownAuthError
-			}
+	_, errorMessage, exitCode := classifyError(err)
+
+	if exitCode == ExitCodeUnknownError {
+		log.Errorf("Unknown error of type %T: %s.\r\nPlease send a bug report containing %s to support@bytemark.co.uk.\r\n", err, err, log.LogFile.Name())
+	} else if len(message) == 0 { // the message (passed as argument) is shadowed by errorMessage (made in this function)
+		log.Log(errorMessage)
+
+	}
+	return exitCode
+}
+
+// classifyError works out the ErrorCode, a message suitable for the user, and
+// the ExitCode for the given error.
+func classifyError(err error) (code ErrorCode, errorMessage string, exitCode ExitCode) {
+	if err == nil {
+		return "", "", ExitCodeSuccess
+	}
+	code = ErrorCodeUnknown
+	errorMessage = "Unknown error"
+	exitCode = ExitCode(ExitCodeUnknownError)
+
+	switch e := err.(type) {
+	case *auth3.Error:
+		// TODO(telyn): I feel like this entire chunk should be in github.com/BytemarkHosting/auth-client
+		switch e.Err.(type) {
 		case *url.Error:
-			if e.Err != nil {
-				if opError, ok := e.Err.(*net.OpError); ok {
-					errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %v", opError.Err)
+			urlErr, _ := e.Err.(*url.Error)
+			if urlErr.Err != nil {
+				if opError, ok := urlErr.Err.(*net.OpError); ok {
+					errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %v", opError.Err)
 				} else {
-					errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %T %v\r\nPlease file a bug report quoting this message.", e.Err, e.Err)
+					errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %T %v\r\nPlease file a bug report quoting this message.", urlErr.Err, urlErr.Err)
 				}
 			} else {
-				errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %v", e)
-			}
-		case *exec.Error:
-			if e.Name == "xdg-open" || e.Name == "x-www-browser" {
-				errorMessage = "Unable to find a browser to start. You may wish to install xdg-open (part of the xdg-utils package on Debian systems)"
-			} else if e.Name == "open" {
-				errorMessage = "Unable to find a browser to start. Ensure that the 'open' tool is in your PATH (usually lives in /usr/bin)."
-
-			} else if e.Name == "ssh" {
-				errorMessage = fmt.Sprintf("Unable to find an SSH client, please check you have one installed.")
-			} else {
-				errorMessage = fmt.Sprintf("Unable to find %s in your PATH.", e.Name)
-			}
-			exitCode = ExitCodeSubprocessFailed
-		case SubprocessFailedError:
-			if e.Err == nil {
-				return ExitCodeSuccess
+				errorMessage = fmt.Sprintf("Couldn't connect to the auth server: %v", urlErr)
 			}
-			errorMessage = err.Error()
-			exitCode = ExitCodeSubprocessFailed
-		case lib.NoDefaultAccountError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeNoDefaultAccount
-		case lib.NilAuthError:
-			errorMessage = "Authorization wasn't set up in the client - please file a bug report containing the name of the command you tried to run."
-			exitCode = ExitCodeClientBug
-		case lib.ForbiddenError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeActionNotPermitted
-		case lib.BadRequestError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeBadRequest
-		case lib.ServiceUnavailableError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeCantConnectAPI
-		case lib.InternalServerError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeAPIInternalError
-		case lib.NotFoundError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeNotFound
-		case RecursiveDeleteGroupError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeAPIInternalError
-		case UserRequestedExit:
-			errorMessage = ""
-			exitCode = ExitCodeUserExit
-		case WontDeleteGroupWithVMsError:
-			errorMessage = err.Error()
-			exitCode = ExitCodeWontDeletePopulated
-		case *syscall.Errno:
-			errorMessage = fmt.Sprintf("A command we tried to execute failed. The operating system gave us the error code %d", e)
-			exitCode = ExitCodeUnknownError
-		case lib.AmbiguousKeyError:
-			exitCode = ExitCodeBadInput
-			errorMessage = err.Error()
-		case UsageDisplayedError:
-			exitCode = ExitCodeBadInput
-			errorMessage = err.Error()
-
+			code = ErrorCodeCantConnect
+			exitCode = ExitCodeCantConnectAuth
 		default:
-			if fmt.Sprintf("%T", err) == "*errors.errorString" {
-				errorMessage = err.Error()
-				exitCode = ExitCodeBadInput // just going with BadInput because most errorStrings come from auth or validation functions.
-			}
-			msg := err.Error()
-			if strings.Contains(msg, "Badly-formed parameters") {
-				exitCode = ExitCodeInvalidCredentials
+			msg := e.Error()
+			switch {
+			case strings.Contains(msg, "Missing 2FA"):
+				errorMessage = "A 2FA one-time password is required to log in - use --2fa-otp to supply one"
+				code = ErrorCode2FARequired
+				exitCode = ExitCode2FARequired
+			case strings.Contains(msg, "Badly-formed parameters"):
 				errorMessage = "The supplied credentials contained invalid characters - please try again"
-			} else if strings.Contains(msg, "Bad login credentials") {
-				exitCode = ExitCodeBadCredentials
+				code = ErrorCodeInvalidCredentials
+				exitCode = ExitCodeInvalidCredentials
+			case strings.Contains(msg, "Bad login credentials"):
 				errorMessage = "A user account with those credentials could not be found. Check your details and try again"
-
+				code = ErrorCodeBadCredentials
+				exitCode = ExitCodeBadCredentials
+			default:
+				errorMessage = fmt.Sprintf("Couldn't create auth session: %v", e.Err)
+				code = ErrorCodeAuthFailed
+				exitCode = ExitCodeUnknownAuthError
+			}
+		}
+	case *url.Error:
+		if e.Err != nil {
+			if opError, ok := e.Err.(*net.OpError); ok {
+				errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %v", opError.Err)
+			} else {
+				errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %T %v\r\nPlease file a bug report quoting this message.", e.Err, e.Err)
 			}
+		} else {
+			errorMessage = fmt.Sprintf("Couldn't connect to the Bytemark API: %v", e)
+		}
+		code = ErrorCodeCantConnect
+		exitCode = ExitCodeCantConnectAPI
+	case *exec.Error:
+		if e.Name == "xdg-open" || e.Name == "x-www-browser" {
+			errorMessage = "Unable to find a browser to start. You may wish to install xdg-open (part of the xdg-utils package on Debian systems)"
+		} else if e.Name == "open" {
+			errorMessage = "Unable to find a browser to start. Ensure that the 'open' tool is in your PATH (usually lives in /usr/bin)."
+
+		} else if e.Name == "ssh" {
+			errorMessage = fmt.Sprintf("Unable to find an SSH client, please check you have one installed.")
+		} else {
+			errorMessage = fmt.Sprintf("Unable to find %s in your PATH.", e.Name)
 		}
+		code = ErrorCodeSubprocessFailed
+		exitCode = ExitCodeSubprocessFailed
+	case SubprocessFailedError:
+		if e.Err == nil {
+			return "", "", ExitCodeSuccess
+		}
+		errorMessage = err.Error()
+		code = ErrorCodeSubprocessFailed
+		exitCode = ExitCodeSubprocessFailed
+	case lib.NoDefaultAccountError:
+		errorMessage = err.Error()
+		code = ErrorCodeNoDefaultAccount
+		exitCode = ExitCodeNoDefaultAccount
+	case lib.NilAuthError:
+		errorMessage = "Authorization wasn't set up in the client - please file a bug report containing the name of the command you tried to run."
+		code = ErrorCodeClientBug
+		exitCode = ExitCodeClientBug
+	case lib.InsecureConnectionError:
+		errorMessage = "Refusing to talk to an endpoint over plain HTTP. Use --insecure if you really meant to."
+		code = ErrorCodeInsecureEndpoint
+		exitCode = ExitCodeInsecureEndpoint
+	case lib.BadNameError:
+		errorMessage = err.Error()
+		code = ErrorCodeBadInput
+		exitCode = ExitCodeBadInput
+	case lib.UnauthorizedError:
+		errorMessage = err.Error()
+		code = ErrorCodeUnauthorized
+		exitCode = ExitCodeUnauthorized
+	case lib.APIKeyRejectedError:
+		errorMessage = err.Error()
+		code = ErrorCodeUnauthorized
+		exitCode = ExitCodeUnauthorized
+	case lib.APIKeyExpiredError:
+		errorMessage = err.Error()
+		code = ErrorCodeAPIKeyExpired
+		exitCode = ExitCodeUnauthorized
+	case lib.ForbiddenError:
+		errorMessage = err.Error()
+		code = ErrorCodeForbidden
+		exitCode = ExitCodeActionNotPermitted
+	case lib.BadRequestError:
+		errorMessage = err.Error()
+		code = ErrorCodeBadRequest
+		exitCode = ExitCodeBadRequest
+	case lib.ServiceUnavailableError:
+		errorMessage = err.Error()
+		code = ErrorCodeServiceUnavailable
+		exitCode = ExitCodeCantConnectAPI
+	case lib.InternalServerError:
+		errorMessage = err.Error()
+		code = ErrorCodeInternalServerError
+		exitCode = ExitCodeAPIInternalError
+	case lib.NotFoundError:
+		errorMessage = err.Error()
+		code = ErrorCodeNotFound
+		exitCode = ExitCodeNotFound
+	case lib.UnknownStatusCodeError:
+		errorMessage = err.Error()
+		code = ErrorCodeUnknownAPIError
+		exitCode = ExitCodeUnknownAPIError
+	case RecursiveDeleteGroupError:
+		errorMessage = err.Error()
+		code = ErrorCodeInternalServerError
+		exitCode = ExitCodeAPIInternalError
+	case BulkOperationFailedError:
+		errorMessage = err.Error()
+		code = ErrorCodeBulkOperationFailed
+		exitCode = ExitCodeBulkOperationFailed
+	case TimedOutError:
+		errorMessage = err.Error()
+		code = ErrorCodeTimedOut
+		exitCode = ExitCodeTimedOut
+	case UserRequestedExit:
+		errorMessage = ""
+		code = ErrorCodeUserExit
+		exitCode = ExitCodeUserExit
+	case WontDeleteGroupWithVMsError:
+		errorMessage = err.Error()
+		code = ErrorCodeWontDeletePopulated
+		exitCode = ExitCodeWontDeletePopulated
+	case *syscall.Errno:
+		errorMessage = fmt.Sprintf("A command we tried to execute failed. The operating system gave us the error code %d", e)
+		exitCode = ExitCodeUnknownError
+	case lib.AmbiguousKeyError:
+		errorMessage = err.Error()
+		code = ErrorCodeBadInput
+		exitCode = ExitCodeBadInput
+	case UsageDisplayedError:
+		errorMessage = err.Error()
+		code = ErrorCodeBadInput
+		exitCode = ExitCodeBadInput
+
+	default:
+		if fmt.Sprintf("%T", err) == "*errors.errorString" {
+			errorMessage = err.Error()
+			code = ErrorCodeBadInput
+			exitCode = ExitCodeBadInput // just going with BadInput because most errorStrings come from auth or validation functions.
+		}
+		msg := err.Error()
+		if strings.Contains(msg, "Badly-formed parameters") {
+			exitCode = ExitCodeInvalidCredentials
+			code = ErrorCodeInvalidCredentials
+			errorMessage = "The supplied credentials contained invalid characters - please try again"
+		} else if strings.Contains(msg, "Bad login credentials") {
+			exitCode = ExitCodeBadCredentials
+			code = ErrorCodeBadCredentials
+			errorMessage = "A user account with those credentials could not be found. Check your details and try again"
 
-		if _, ok := err.(lib.APIError); ok && exitCode == ExitCodeUnknownError {
-			errorMessage = fmt.Sprintf("Unknown error from API client library. %s", err.Error())
-			exitCode = ExitCodeUnknownAPIError
 		}
-	} else {
-		exitCode = 0
 	}
 
-	if exitCode == ExitCodeUnknownError {
-		log.Errorf("Unknown error of type %T: %s.\r\nPlease send a bug report containing %s to support@bytemark.co.uk.\r\n", err, err, log.LogFile.Name())
-	} else if len(message) == 0 { // the message (passed as argument) is shadowed by errorMessage (made in this function)
-		log.Log(errorMessage)
-
+	if _, ok := err.(lib.APIError); ok && exitCode == ExitCodeUnknownError {
+		errorMessage = fmt.Sprintf("Unknown error from API client library. %s", err.Error())
+		code = ErrorCodeUnknownAPIError
+		exitCode = ExitCodeUnknownAPIError
 	}
-	return exitCode
+	return
 }
@@ -0,0 +1,205 @@
+// Package filter implements a small expression language for picking out
+// items from a list - such as servers - by their fields, and for sorting them.
+//
+// Expressions compare fields to values, and are combined with and, or, not
+// and brackets:
+//
+//	not power_on and cores > 4 and zone_name = manchester
+//	discs.size >= 500GiB or (hostname ~ '^web' && memory < 2048)
+//
+// Fields are named by their JSON name (zone_name) or by their Go name
+// (ZoneName), case-insensitively, and nested fields are separated by dots.
+// When a field is a list - like a server's discs - the comparison is true if
+// it's true for any item in the list. A field on its own is true if it is
+// not false, zero or empty.
+//
+// The comparisons are =, !=, <, <=, >, >= and ~ and !~, which match a regular
+// expression. Strings are compared case-insensitively by = and !=. Values may
+// be quoted with ' or ", and numeric values may have a size suffix such as
+// 500GiB or 768M, in which case they are converted to MiB.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Error is returned when a filter expression could not be parsed.
+type Error struct {
+	// Expression is the full expression that caused the error.
+	Expression string
+	// Position is the index in Expression at which the error was found.
+	Position int
+	// Message describes what was wrong.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Invalid filter '%s': %s at character %d.", e.Expression, e.Message, e.Position)
+}
+
+// Filter is a parsed filter expression.
+type Filter struct {
+	expr string
+	root node
+}
+
+// String returns the expression the filter was parsed from.
+func (f Filter) String() string {
+	return f.expr
+}
+
+// Matches returns true if v matches the filter. An error is returned if the
+// filter refers to fields v doesn't have.
+func (f Filter) Matches(v interface{}) (bool, error) {
+	return f.root.eval(reflect.ValueOf(v))
+}
+
+// Validate checks that all the fields the filter refers to exist on v's type
+// and that the values they're compared to are of the right types, so that
+// mistakes can be reported before fetching anything.
+func (f Filter) Validate(v interface{}) error {
+	return f.root.validate(reflect.TypeOf(v))
+}
+
+// Apply returns a new slice containing only the items in slice which match
+// the filter. slice must be a slice.
+func (f Filter) Apply(slice interface{}) (interface{}, error) {
+	in := reflect.ValueOf(slice)
+	out := reflect.MakeSlice(in.Type(), 0, in.Len())
+	for i := 0; i < in.Len(); i++ {
+		ok, err := f.root.eval(in.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = reflect.Append(out, in.Index(i))
+		}
+	}
+	return out.Interface(), nil
+}
+
+// Parse parses a filter expression.
+func Parse(expr string) (Filter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return Filter{}, err
+	}
+	p := parser{expr: expr, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return Filter{}, err
+	}
+	if tok := p.peek(); tok.typ != tEOF {
+		return Filter{}, p.errorf(tok, "unexpected %s", tok)
+	}
+	return Filter{expr: expr, root: root}, nil
+}
+
+// parser is a recursive-descent parser for filter expressions. The grammar is
+//
+//	or      := and { ("or" | "||") and }
+//	and     := not { ("and" | "&&") not }
+//	not     := ("not" | "!") not | primary
+//	primary := "(" or ")" | field [ comparison value ]
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.typ != tEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return &Error{Expression: p.expr, Position: tok.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.isKeyword("or") || tok.value == "||" && tok.typ == tOperator; tok = p.peek() {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok.isKeyword("and") || tok.value == "&&" && tok.typ == tOperator; tok = p.peek() {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if tok := p.peek(); tok.isKeyword("not") || tok.value == "!" && tok.typ == tOperator {
+		p.next()
+		n, err := p.parseNot()
+		return notNode{n}, err
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch {
+	case tok.typ == tLeftParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.typ != tRightParen {
+			return nil, p.errorf(closing, "expected ')' but got %s", closing)
+		}
+		return n, nil
+	case tok.typ != tWord || tok.isKeyword("and") || tok.isKeyword("or"):
+		return nil, p.errorf(tok, "expected a field name but got %s", tok)
+	}
+	path := strings.Split(tok.value, ".")
+
+	op := p.peek()
+	if op.typ != tOperator || !comparisons[op.value] {
+		return truthyNode{path}, nil
+	}
+	p.next()
+	value := p.next()
+	if value.typ != tWord && value.typ != tString {
+		return nil, p.errorf(value, "expected a value after %s but got %s", op, value)
+	}
+	n := comparisonNode{path: path, op: op.value, value: value.value}
+	if op.value == "~" || op.value == "!~" {
+		re, err := regexp.Compile(value.value)
+		if err != nil {
+			return nil, p.errorf(value, "invalid regular expression: %s", err)
+		}
+		n.re = re
+	}
+	return n, nil
+}
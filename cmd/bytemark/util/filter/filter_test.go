@@ -0,0 +1,148 @@
+package filter_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util/filter"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+var servers = brain.VirtualMachines{
+	{
+		Name:     "web1",
+		Hostname: "web1.web.test-account.uk0.bigv.io",
+		Cores:    2,
+		Memory:   2048,
+		PowerOn:  true,
+		ZoneName: "manchester",
+		Discs: brain.Discs{
+			{Label: "disc-1", Size: 25600, StorageGrade: "sata"},
+		},
+		NetworkInterfaces: []brain.NetworkInterface{
+			{IPs: brain.IPs{net.ParseIP("192.168.1.16")}},
+		},
+	}, {
+		Name:     "db1",
+		Hostname: "db1.web.test-account.uk0.bigv.io",
+		Cores:    8,
+		Memory:   16384,
+		ZoneName: "Manchester",
+		Discs: brain.Discs{
+			{Label: "disc-1", Size: 25600, StorageGrade: "sata"},
+			{Label: "disc-2", Size: 1024000, StorageGrade: "archive"},
+		},
+	}, {
+		Name:     "mail",
+		Hostname: "mail.web.test-account.uk0.bigv.io",
+		Cores:    8,
+		Memory:   4096,
+		ZoneName: "york",
+		Deleted:  true,
+	},
+}
+
+func names(vms brain.VirtualMachines) (names []string) {
+	for _, vm := range vms {
+		names = append(names, vm.Name)
+	}
+	return
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+		parseErr bool
+		applyErr bool
+	}{
+		{expr: "power_on", expected: []string{"web1"}},
+		{expr: "not PowerOn and cores > 4 and zone_name = manchester", expected: []string{"db1"}},
+		{expr: "discs.size > 500GiB", expected: []string{"db1"}},
+		{expr: "discs.storage_grade = archive or deleted", expected: []string{"db1", "mail"}},
+		{expr: "!(cores >= 8) || memory < 4G", expected: []string{"web1"}},
+		{expr: "hostname ~ '^(web|mail)'", expected: []string{"web1", "mail"}},
+		{expr: "name !~ \"1$\"", expected: []string{"mail"}},
+		{expr: "network_interfaces.ips = 192.168.1.16", expected: []string{"web1"}},
+		{expr: "discs", expected: []string{"web1", "db1"}},
+		{expr: "deleted = no", expected: []string{"web1", "db1"}},
+		{expr: "zone != york and memory <= 4096", applyErr: true},
+		{expr: "cores > lots", applyErr: true},
+		{expr: "cores >", parseErr: true},
+		{expr: "(cores > 4", parseErr: true},
+		{expr: "cores > 4 cores", parseErr: true},
+		{expr: "name = 'unterminated", parseErr: true},
+		{expr: "name ~ '('", parseErr: true},
+		{expr: "and", parseErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			f, err := filter.Parse(test.expr)
+			if test.parseErr {
+				if err == nil {
+					t.Fatal("expected a parse error but didn't get one")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected parse error: %s", err)
+			}
+			if err := f.Validate(brain.VirtualMachine{}); (err != nil) != test.applyErr {
+				t.Errorf("unexpected Validate result: %v", err)
+			}
+			filtered, err := f.Apply(servers)
+			if test.applyErr {
+				if err == nil {
+					t.Fatal("expected an error from Apply but didn't get one")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error from Apply: %s", err)
+			}
+			got := names(filtered.(brain.VirtualMachines))
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %v but got %v", test.expected, got)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("expected %v but got %v", test.expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		spec      string
+		expected  []string
+		shouldErr bool
+	}{
+		{spec: "name", expected: []string{"db1", "mail", "web1"}},
+		{spec: "-cores, memory", expected: []string{"mail", "db1", "web1"}},
+		{spec: "cores,-memory", expected: []string{"web1", "db1", "mail"}},
+		{spec: "zone_name", expected: []string{"web1", "db1", "mail"}},
+		{spec: "discs.size", shouldErr: true},
+		{spec: "nonsense", shouldErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			vms := make(brain.VirtualMachines, len(servers))
+			copy(vms, servers)
+			err := filter.Sort(vms, test.spec)
+			if test.shouldErr {
+				if err == nil {
+					t.Fatal("expected an error but didn't get one")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := names(vms)
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Fatalf("expected %v but got %v", test.expected, got)
+				}
+			}
+		})
+	}
+}
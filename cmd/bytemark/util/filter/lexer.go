@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tEOF tokenType = iota
+	// a field name, keyword or unquoted value
+	tWord
+	// a quoted value
+	tString
+	tOperator
+	tLeftParen
+	tRightParen
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	pos   int
+}
+
+// String returns a description of the token for use in error messages
+func (t token) String() string {
+	if t.typ == tEOF {
+		return "end of filter"
+	}
+	return "'" + t.value + "'"
+}
+
+// isKeyword returns true if the token is the given keyword, which should be
+// lowercase.
+func (t token) isKeyword(keyword string) bool {
+	return t.typ == tWord && strings.ToLower(t.value) == keyword
+}
+
+// operators are all the operators, longest first so that e.g. <= isn't lexed as
+// < followed by =
+var operators = []string{"==", "!=", "<=", ">=", "!~", "&&", "||", "=", "<", ">", "~", "!"}
+
+// comparisons are the operators which compare a field to a value
+var comparisons = map[string]bool{
+	"=":  true,
+	"==": true,
+	"!=": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+	"~":  true,
+	"!~": true,
+}
+
+// isWordRune returns true if r may be part of a word
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && !strings.ContainsRune(`()=!<>~&|'"`, r)
+}
+
+// lex splits expr into tokens
+func lex(expr string) (tokens []token, err error) {
+	runes := []rune(expr)
+	pos := 0
+	for pos < len(runes) {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{tLeftParen, "(", pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{tRightParen, ")", pos})
+			pos++
+		case r == '\'' || r == '"':
+			start := pos
+			pos++
+			for pos < len(runes) && runes[pos] != r {
+				pos++
+			}
+			if pos >= len(runes) {
+				return nil, &Error{Expression: expr, Position: start, Message: "unterminated string"}
+			}
+			tokens = append(tokens, token{tString, string(runes[start+1 : pos]), start})
+			pos++
+		case isWordRune(r):
+			start := pos
+			for pos < len(runes) && isWordRune(runes[pos]) {
+				pos++
+			}
+			tokens = append(tokens, token{tWord, string(runes[start:pos]), start})
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[pos:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Expression: expr, Position: pos, Message: "unexpected '" + string(r) + "'"}
+			}
+			tokens = append(tokens, token{tOperator, op, pos})
+			pos += len([]rune(op))
+		}
+	}
+	tokens = append(tokens, token{tEOF, "", len(runes)})
+	return tokens, nil
+}
@@ -0,0 +1,152 @@
+package filter
+
+import (
+	"reflect"
+	"regexp"
+)
+
+// node is a part of a parsed filter expression
+type node interface {
+	// eval returns whether v matches this part of the expression
+	eval(v reflect.Value) (bool, error)
+	// validate checks that the fields and values used by this part of the
+	// expression make sense for type t
+	validate(t reflect.Type) error
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.left.eval(v)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.right.eval(v)
+}
+
+func (n orNode) validate(t reflect.Type) error {
+	if err := n.left.validate(t); err != nil {
+		return err
+	}
+	return n.right.validate(t)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.left.eval(v)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return n.right.eval(v)
+}
+
+func (n andNode) validate(t reflect.Type) error {
+	if err := n.left.validate(t); err != nil {
+		return err
+	}
+	return n.right.validate(t)
+}
+
+type notNode struct {
+	n node
+}
+
+func (n notNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.n.eval(v)
+	return !ok, err
+}
+
+func (n notNode) validate(t reflect.Type) error {
+	return n.n.validate(t)
+}
+
+// truthyNode is a field on its own, which is true if any of its values are
+// non-zero.
+type truthyNode struct {
+	path []string
+}
+
+func (n truthyNode) eval(v reflect.Value) (bool, error) {
+	values, err := resolve(v, n.path)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range values {
+		if !isZero(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (n truthyNode) validate(t reflect.Type) error {
+	_, _, err := resolveType(t, n.path)
+	return err
+}
+
+// comparisonNode compares a field to a value, and is true if the comparison is
+// true for any of the field's values.
+type comparisonNode struct {
+	path  []string
+	op    string
+	value string
+	// re is the compiled value for the ~ and !~ operators
+	re *regexp.Regexp
+}
+
+func (n comparisonNode) eval(v reflect.Value) (bool, error) {
+	values, err := resolve(v, n.path)
+	if err != nil {
+		return false, err
+	}
+	for _, value := range values {
+		ok, err := n.compare(value)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+func (n comparisonNode) validate(t reflect.Type) error {
+	leaf, _, err := resolveType(t, n.path)
+	if err != nil || n.re != nil {
+		return err
+	}
+	_, err = n.compare(reflect.Zero(leaf))
+	return err
+}
+
+// compare compares a single value of the field to the node's value
+func (n comparisonNode) compare(v reflect.Value) (bool, error) {
+	switch n.op {
+	case "~":
+		return n.re.MatchString(stringOf(v)), nil
+	case "!~":
+		return !n.re.MatchString(stringOf(v)), nil
+	}
+	cmp, err := compareToString(v, n.value)
+	if err != nil {
+		return false, err
+	}
+	switch n.op {
+	case "=", "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sortKey is one of the fields to sort by
+type sortKey struct {
+	path       []string
+	descending bool
+}
+
+// Sort sorts slice in place by spec, a comma-separated list of fields named
+// the same way as in filter expressions. Fields prefixed with - are sorted in
+// descending order. Later fields are used to sort items which are equal by the
+// earlier ones, and the order of items which are equal by every field is
+// preserved. slice must be a slice, and the fields must not be lists.
+func Sort(slice interface{}, spec string) error {
+	elemType := reflect.TypeOf(slice).Elem()
+	keys := []sortKey{}
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key := sortKey{}
+		if strings.HasPrefix(field, "-") {
+			key.descending = true
+			field = field[1:]
+		}
+		key.path = strings.Split(field, ".")
+		_, multi, err := resolveType(elemType, key.path)
+		if err != nil {
+			return err
+		}
+		if multi {
+			return fmt.Errorf("Can't sort by %s since there can be more than one of it", field)
+		}
+		keys = append(keys, key)
+	}
+
+	v := reflect.ValueOf(slice)
+	sort.SliceStable(slice, func(i, j int) bool {
+		for _, key := range keys {
+			cmp := compareValues(first(v.Index(i), key.path), first(v.Index(j), key.path))
+			if key.descending {
+				cmp = -cmp
+			}
+			if cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+	return nil
+}
+
+// first returns the value of the field at path in v, or an invalid
+// reflect.Value if it has none.
+func first(v reflect.Value, path []string) reflect.Value {
+	values, err := resolve(v, path)
+	if err != nil || len(values) == 0 {
+		return reflect.Value{}
+	}
+	return values[0]
+}
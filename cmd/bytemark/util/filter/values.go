@@ -0,0 +1,211 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/cmd/bytemark/util/sizespec"
+)
+
+// isList returns true if values of type t should be treated as lists of
+// values. Byte slices like net.IP are treated as single values.
+func isList(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map:
+		return true
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// findField finds the field of struct type t called name - matching the
+// field's Go name or JSON name, ignoring case and underscores.
+func findField(t reflect.Type, name string) (reflect.StructField, bool) {
+	simplified := strings.Replace(name, "_", "", -1)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if strings.EqualFold(field.Name, simplified) || strings.EqualFold(jsonName, name) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// resolveType finds the type of the field at path in t, and whether there may
+// be more than one value for it (because it is or is inside a list)
+func resolveType(t reflect.Type, path []string) (leaf reflect.Type, multi bool, err error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isList(t) {
+		// paths through maps go through their values, but a map on its own
+		// means its keys.
+		if t.Kind() == reflect.Map && len(path) == 0 {
+			return t.Key(), true, nil
+		}
+		leaf, _, err = resolveType(t.Elem(), path)
+		return leaf, true, err
+	}
+	if len(path) == 0 {
+		return t, false, nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false, fmt.Errorf("%s doesn't have any fields, so can't have a field called '%s'", t, path[0])
+	}
+	field, ok := findField(t, path[0])
+	if !ok {
+		return nil, false, fmt.Errorf("%s doesn't have a field called '%s'", t, path[0])
+	}
+	return resolveType(field.Type, path[1:])
+}
+
+// resolve finds all the values of the field at path in v.
+func resolve(v reflect.Value, path []string) (values []reflect.Value, err error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if isList(v.Type()) {
+		if v.Kind() == reflect.Map {
+			if len(path) == 0 {
+				return v.MapKeys(), nil
+			}
+			for _, key := range v.MapKeys() {
+				more, err := resolve(v.MapIndex(key), path)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, more...)
+			}
+			return values, nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			more, err := resolve(v.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, more...)
+		}
+		return values, nil
+	}
+	if len(path) == 0 {
+		return []reflect.Value{v}, nil
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s doesn't have any fields, so can't have a field called '%s'", v.Type(), path[0])
+	}
+	field, ok := findField(v.Type(), path[0])
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't have a field called '%s'", v.Type(), path[0])
+	}
+	return resolve(v.FieldByIndex(field.Index), path[1:])
+}
+
+// isZero returns true if v is its type's zero value
+func isZero(v reflect.Value) bool {
+	if v.Kind() == reflect.Slice {
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// stringOf returns v as a string, using its String method if it has one.
+func stringOf(v reflect.Value) string {
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// parseBool is like strconv.ParseBool but also understands yes, no, on and
+// off.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("'%s' is not true or false", s)
+	}
+	return b, nil
+}
+
+// parseNumber parses s as an integer or, failing that, as a size in MiB
+func parseNumber(s string) (int64, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	size, err := sizespec.Parse(s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is neither a number nor a size", s)
+	}
+	return int64(size), nil
+}
+
+// sign returns -1, 0 or 1 depending on whether a is less than, equal to or
+// greater than b.
+func sign(less bool, equal bool) int {
+	switch {
+	case equal:
+		return 0
+	case less:
+		return -1
+	}
+	return 1
+}
+
+// compareToString compares v to the value s, returning -1, 0 or 1 if v is less
+// than, equal to or greater than it. s is parsed according to v's type.
+func compareToString(v reflect.Value, s string) (int, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := parseBool(s)
+		return sign(!v.Bool() && b, v.Bool() == b), err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := parseNumber(s)
+		return sign(v.Int() < n, v.Int() == n), err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := parseNumber(s)
+		return sign(int64(v.Uint()) < n, int64(v.Uint()) == n), err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", s)
+		}
+		return sign(v.Float() < f, v.Float() == f), nil
+	}
+	return strings.Compare(strings.ToLower(stringOf(v)), strings.ToLower(s)), nil
+}
+
+// compareValues compares a and b, which must be of the same type, returning
+// -1, 0 or 1 if a is less than, equal to or greater than b. Invalid values
+// (missing because a list was empty) are less than everything else.
+func compareValues(a, b reflect.Value) int {
+	if !a.IsValid() || !b.IsValid() {
+		return sign(!a.IsValid(), a.IsValid() == b.IsValid())
+	}
+	switch a.Kind() {
+	case reflect.Bool:
+		return sign(!a.Bool() && b.Bool(), a.Bool() == b.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sign(a.Int() < b.Int(), a.Int() == b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return sign(a.Uint() < b.Uint(), a.Uint() == b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return sign(a.Float() < b.Float(), a.Float() == b.Float())
+	}
+	return strings.Compare(strings.ToLower(stringOf(a)), strings.ToLower(stringOf(b)))
+}
@@ -1,16 +1,17 @@
 package lib
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 
 	auth3 "github.com/BytemarkHosting/auth-client"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 )
 
 // AuthWithCredentials attempts to authenticate with the given credentials. Returns nil on success or an error otherwise.
 func (c *bytemarkClient) AuthWithCredentials(credentials auth3.Credentials) error {
-	session, err := c.auth.CreateSession(context.TODO(), credentials)
+	session, err := c.auth.CreateSession(c.context(), credentials)
 	if err == nil {
 		c.authSession = session
 	}
@@ -24,18 +25,10 @@ func (c *bytemarkClient) AuthWithToken(token string) error {
 	}
 
 	if strings.HasPrefix(token, "apikey.") {
-		c.authSession = &auth3.SessionData{
-			Username: "apikeyuser",
-			Factors: []string{
-				"apikey",
-			},
-			Token: token,
-		}
-		c.urls.Billing = ""
-		return nil
+		return c.authWithAPIKey(token)
 	}
 
-	session, err := c.auth.ReadSession(context.TODO(), token)
+	session, err := c.auth.ReadSession(c.context(), token)
 	if err == nil {
 		c.authSession = session
 	}
@@ -45,7 +38,97 @@ func (c *bytemarkClient) AuthWithToken(token string) error {
 
 // Impersonate creates a session for the given user (assuming the client has already authenticated as someone who can)
 func (c *bytemarkClient) Impersonate(user string) (err error) {
-	c.authSession, err = c.auth.CreateImpersonatedSession(context.TODO(), c.authSession.Token, user)
+	c.authSession, err = c.auth.CreateImpersonatedSession(c.context(), c.authSession.Token, user)
 
 	return
 }
+
+// apiKeyExpiredCode is the code the brain gives in the body of its 401
+// response when an API key has expired, as opposed to not existing at all.
+const apiKeyExpiredCode = "api_key_expired"
+
+// authWithAPIKey sets up a session for the given 'apikey.'-prefixed token,
+// then checks the key is valid by fetching its record from the brain, which
+// also tells us which user it belongs to.
+func (c *bytemarkClient) authWithAPIKey(token string) error {
+	secret := strings.TrimPrefix(token, "apikey.")
+	c.authSession = &auth3.SessionData{
+		Factors: []string{
+			"apikey",
+		},
+		Token: token,
+	}
+	c.apiKey = nil
+	// bmbilling doesn't accept API keys
+	c.urls.Billing = ""
+
+	r, err := c.BuildRequest("GET", BrainEndpoint, "/api_keys?view=overview")
+	if err != nil {
+		return err
+	}
+	keys := brain.APIKeys{}
+	_, _, err = r.Run(nil, &keys)
+	if err != nil {
+		c.authSession = nil
+		if unauthErr, ok := err.(UnauthorizedError); ok {
+			body := struct {
+				Code string `json:"code"`
+			}{}
+			if json.Unmarshal([]byte(unauthErr.ResponseBody), &body) == nil && body.Code == apiKeyExpiredCode {
+				return APIKeyExpiredError{}
+			}
+			return APIKeyRejectedError{unauthErr.APIError}
+		}
+		return err
+	}
+
+	username, key, err := sessionAPIKey(keys, secret)
+	if err != nil {
+		c.authSession = nil
+		return err
+	}
+	if key != nil && key.Expired() {
+		c.authSession = nil
+		return APIKeyExpiredError{Label: key.Label, ExpiresAt: key.ExpiresAt}
+	}
+	c.authSession.Username = username
+	c.apiKey = key
+	return nil
+}
+
+// sessionAPIKey works out which user is authenticated with the API key
+// secret, and which of keys is the record for it. When authenticated with an
+// API key the brain only returns that key's record, but it doesn't return the
+// secret part of keys, so if it returns more than one (all belonging to the
+// same user) there's no telling which is in use. In that case key is nil -
+// the username is still known, and since the brain accepted the key it can't
+// have expired.
+func sessionAPIKey(keys brain.APIKeys, secret string) (username string, key *brain.APIKey, err error) {
+	if len(keys) == 0 {
+		return "", nil, errors.New("The brain accepted the API key but didn't return a record of it")
+	}
+	if len(keys) == 1 {
+		return keys[0].Username, &keys[0], nil
+	}
+	username = keys[0].Username
+	for i := range keys {
+		if keys[i].Username != username || keys[i].Username == "" {
+			return "", nil, errors.New("The brain accepted the API key but returned records of keys belonging to several users")
+		}
+		if keys[i].APIKey != "" && keys[i].APIKey == secret {
+			key = &keys[i]
+		}
+	}
+	return username, key, nil
+}
+
+// SessionAPIKey returns the brain's record of the API key client is
+// authenticated with, and false if it was authenticated some other way (or
+// isn't a client made by this package).
+func SessionAPIKey(client Client) (brain.APIKey, bool) {
+	c, ok := client.(*bytemarkClient)
+	if !ok || c.apiKey == nil {
+		return brain.APIKey{}, false
+	}
+	return *c.apiKey, true
+}
@@ -0,0 +1,109 @@
+package lib_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+)
+
+func TestAuthWithAPIKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		response    interface{}
+		username    string
+		label       string
+		shouldErr   bool
+		expectedErr func(error) bool
+	}{
+		{
+			name:     "one record",
+			response: brain.APIKeys{{ID: 3, Username: "bob", Label: "ci"}},
+			username: "bob",
+			label:    "ci",
+		}, {
+			name: "several records without secrets",
+			response: brain.APIKeys{
+				{ID: 3, Username: "bob", Label: "ci"},
+				{ID: 4, Username: "bob", Label: "deploy"},
+			},
+			username: "bob",
+		}, {
+			name: "several records with secrets",
+			response: brain.APIKeys{
+				{ID: 3, Username: "bob", Label: "ci", APIKey: "other"},
+				{ID: 4, Username: "bob", Label: "deploy", APIKey: "secret"},
+			},
+			username: "bob",
+			label:    "deploy",
+		}, {
+			name: "several users",
+			response: brain.APIKeys{
+				{ID: 3, Username: "bob", Label: "ci"},
+				{ID: 4, Username: "alice", Label: "deploy"},
+			},
+			shouldErr: true,
+		}, {
+			name:        "expired record",
+			response:    brain.APIKeys{{ID: 3, Username: "bob", Label: "ci", ExpiresAt: "2001-01-01T00:00:00+0000"}},
+			shouldErr:   true,
+			expectedErr: isExpired,
+		}, {
+			name:        "rejected as expired",
+			status:      http.StatusUnauthorized,
+			response:    map[string]string{"error": "api key has expired", "code": "api_key_expired"},
+			shouldErr:   true,
+			expectedErr: isExpired,
+		}, {
+			name:        "rejected",
+			status:      http.StatusUnauthorized,
+			response:    map[string]string{"error": "invalid api key - has it expired?"},
+			shouldErr:   true,
+			expectedErr: isRejected,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:     "GET",
+				Endpoint:   lib.BrainEndpoint,
+				URL:        "/api_keys",
+				StatusCode: test.status,
+				Response:   test.response,
+			}
+			rts.Run(t, test.name, false, func(client lib.Client) {
+				err := client.AuthWithToken("apikey.secret")
+				if !test.shouldErr && err != nil {
+					t.Fatal(err)
+				} else if test.shouldErr {
+					if err == nil {
+						t.Fatal("expected an error")
+					} else if test.expectedErr != nil && !test.expectedErr(err) {
+						t.Fatalf("unexpected error %T: %v", err, err)
+					}
+					return
+				}
+				if user := client.GetSessionUser(); user != test.username {
+					t.Errorf("expected to be logged in as %s, got %s", test.username, user)
+				}
+				key, ok := lib.SessionAPIKey(client)
+				if ok != (test.label != "") || key.Label != test.label {
+					t.Errorf("expected the session's API key to be %q, got %#v", test.label, key)
+				}
+			})
+		})
+	}
+}
+
+func isExpired(err error) bool {
+	_, ok := err.(lib.APIKeyExpiredError)
+	return ok
+}
+
+func isRejected(err error) bool {
+	_, ok := err.(lib.APIKeyRejectedError)
+	return ok
+}
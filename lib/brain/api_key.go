@@ -39,19 +39,44 @@ func (key APIKey) DefaultFields(f output.Format) string {
 	return "ID, Username, Label, Expired, ExpiresAt, Privileges"
 }
 
-// Expired returns true if ExpiresAt is in the past.
+// ExpiresAtTime parses ExpiresAt, returning false if the key never expires
+// (or ExpiresAt couldn't be parsed).
 // This assumes ExpiresAt is in ISO8601 format, which it will be if the APIKey
 // was set by a unmarshalling a response from the brain.
-func (key APIKey) Expired() bool {
+func (key APIKey) ExpiresAtTime() (time.Time, bool) {
 	if key.ExpiresAt == "" {
-		return false
+		return time.Time{}, false
 	}
 	// TODO(telyn): not keen on this ad-hoc iso8601 parsing
-	expiresAt, err := time.Parse("2006-01-02T15:04:05-0700", key.ExpiresAt)
-	if err != nil {
-		return false
+	for _, layout := range []string{"2006-01-02T15:04:05-0700", time.RFC3339} {
+		expiresAt, err := time.Parse(layout, key.ExpiresAt)
+		if err == nil {
+			return expiresAt, true
+		}
 	}
-	return expiresAt.Before(time.Now())
+	return time.Time{}, false
+}
+
+// Expired returns true if ExpiresAt is in the past.
+func (key APIKey) Expired() bool {
+	expiresAt, ok := key.ExpiresAtTime()
+	return ok && expiresAt.Before(time.Now())
+}
+
+// ExpiresWithin returns true if the key expires less than d from now, or has
+// already expired.
+func (key APIKey) ExpiresWithin(d time.Duration) bool {
+	expiresAt, ok := key.ExpiresAtTime()
+	return ok && expiresAt.Before(time.Now().Add(d))
+}
+
+// APIKeyModification is used to change the label and expiry of an existing
+// API key. Nil fields are left as they are, and setting ExpiresAt to a blank
+// string makes the key never expire. Privileges are changed with
+// GrantPrivilege and RevokePrivilege instead.
+type APIKeyModification struct {
+	Label     *string
+	ExpiresAt *string
 }
 
 // PrettyPrint outputs a nice human-readable overview of the api key, including
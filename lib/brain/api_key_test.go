@@ -3,6 +3,7 @@ package brain_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
@@ -91,3 +92,47 @@ func TestAPIKeyPrettyPrint(t *testing.T) {
 	}
 
 }
+
+func TestAPIKeyExpiresWithin(t *testing.T) {
+	soon := time.Now().Add(48 * time.Hour)
+	tests := []struct {
+		name     string
+		in       brain.APIKey
+		within   time.Duration
+		expected bool
+	}{
+		{
+			name:     "never expires",
+			in:       brain.APIKey{},
+			within:   24 * time.Hour,
+			expected: false,
+		}, {
+			name:     "expired",
+			in:       brain.APIKey{ExpiresAt: "2006-01-01T01:01:01.000-0000"},
+			within:   24 * time.Hour,
+			expected: true,
+		}, {
+			name:     "expires after the window",
+			in:       brain.APIKey{ExpiresAt: soon.Format("2006-01-02T15:04:05-0700")},
+			within:   24 * time.Hour,
+			expected: false,
+		}, {
+			name:     "expires within the window",
+			in:       brain.APIKey{ExpiresAt: soon.Format("2006-01-02T15:04:05-0700")},
+			within:   72 * time.Hour,
+			expected: true,
+		}, {
+			name:     "RFC3339",
+			in:       brain.APIKey{ExpiresAt: soon.UTC().Format(time.RFC3339)},
+			within:   72 * time.Hour,
+			expected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.in.ExpiresWithin(test.within); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
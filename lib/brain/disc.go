@@ -29,6 +29,11 @@ type Disc struct {
 
 	NewStorageGrade string `json:"new_storage_grade,omitempty"`
 	NewStoragePool  string `json:"new_storage_pool,omitempty"`
+
+	// BackupID is only used when creating a disc - the new disc starts out as
+	// a copy of the backup with this ID rather than blank. The backup can be
+	// of any disc the user can see, and the new disc can't be smaller than it.
+	BackupID int `json:"backup_id,omitempty"`
 }
 
 // DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
@@ -0,0 +1,67 @@
+package brain
+
+import (
+	"io"
+	"net"
+	"sort"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+)
+
+// ExtraIP is an extra IP along with the server IP it's routed to. It's not
+// returned by the brain as-is - see VirtualMachine.ExtraIPs.
+type ExtraIP struct {
+	IP       net.IP `json:"ip"`
+	RoutedTo net.IP `json:"routed_to"`
+	// Server is the full name of the server that RoutedTo belongs to
+	Server string `json:"server"`
+}
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (ip ExtraIP) DefaultFields(f output.Format) string {
+	return "IP, RoutedTo, Server"
+}
+
+// PrettyPrint outputs the extra IP and where it's routed to on a single line.
+func (ip ExtraIP) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "extraip_sgl" }}{{ .IP }} -> {{ .RoutedTo }}{{ if .Server }} ({{ .Server }}){{ end }}{{ end }}
+{{ define "extraip_medium" }}{{ template "extraip_sgl" . }}{{ end }}
+{{ define "extraip_full" }}{{ template "extraip_sgl" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "extraip"+string(detail), ip)
+}
+
+// ExtraIPs is a list of extra IPs.
+type ExtraIPs []ExtraIP
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as ExtraIP.DefaultFields.
+func (ips ExtraIPs) DefaultFields(f output.Format) string {
+	return (ExtraIP{}).DefaultFields(f)
+}
+
+// PrettyPrint outputs each extra IP on its own line.
+func (ips ExtraIPs) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "extraips_sgl" }}{{ len . }} extra IPs{{ end }}
+{{ define "extraips_medium" }}{{ range . }}{{ prettysprint . "_sgl" }}
+{{ end }}{{ end }}
+{{ define "extraips_full" }}{{ template "extraips_medium" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "extraips"+string(detail), ips)
+}
+
+// ExtraIPs returns all the extra IPs routed to the server's network
+// interfaces, sorted by IP.
+func (vm VirtualMachine) ExtraIPs() (ips ExtraIPs) {
+	for _, nic := range vm.NetworkInterfaces {
+		for ip, routedTo := range nic.ExtraIPs {
+			ips = append(ips, ExtraIP{
+				IP:       net.ParseIP(ip),
+				RoutedTo: routedTo,
+				Server:   vm.FullName(),
+			})
+		}
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		return IPs{ips[i].IP, ips[j].IP}.Less(0, 1)
+	})
+	return
+}
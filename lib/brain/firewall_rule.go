@@ -0,0 +1,146 @@
+package brain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+)
+
+// FirewallRule allows incoming traffic to the servers it applies to. Rules
+// are attached to either a group - in which case they apply to every server
+// in it - or a single server. Once a server has any rules, incoming traffic
+// which isn't allowed by one of them is dropped.
+type FirewallRule struct {
+	// Protocol is one of tcp, udp or icmp.
+	Protocol string `json:"protocol"`
+	// Ports is a single port like "22", a range like "8000-8080", or blank
+	// for all ports. It must be blank for icmp.
+	Ports string `json:"ports,omitempty"`
+	// Source is the CIDR the traffic must come from, or blank for anywhere.
+	Source string `json:"source,omitempty"`
+	Label  string `json:"label,omitempty"`
+
+	// the following cannot be set
+	ID               int `json:"id,omitempty"`
+	GroupID          int `json:"group_id,omitempty"`
+	VirtualMachineID int `json:"virtual_machine_id,omitempty"`
+}
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (r FirewallRule) DefaultFields(f output.Format) string {
+	return "ID, Protocol, Ports, Source, Label"
+}
+
+// PrettyPrint outputs the rule on a single line.
+func (r FirewallRule) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "firewallrule_sgl" -}}
+#{{ .ID }}: allow {{ .Protocol }}
+{{- if .Ports }} port {{ .Ports }}{{ else if ne .Protocol "icmp" }} all ports{{ end }} from
+{{- if .Source }} {{ .Source }}{{ else }} anywhere{{ end }}
+{{- if .Label }} ({{ .Label }}){{ end }}
+{{- end }}
+{{ define "firewallrule_medium" }}{{ template "firewallrule_sgl" . }}{{ end }}
+{{ define "firewallrule_full" }}{{ template "firewallrule_sgl" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "firewallrule"+string(detail), r)
+}
+
+// String returns the rule formatted as a string (the same as PrettyPrint with prettyprint.SingleLine detail)
+func (r FirewallRule) String() string {
+	buf := new(bytes.Buffer)
+	_ = r.PrettyPrint(buf, prettyprint.SingleLine)
+	return buf.String()
+}
+
+// Validate checks the rule's protocol, ports and source, returning a copy
+// of the rule with them normalised - the protocol lowercased and a single IP
+// source turned into a CIDR.
+func (r FirewallRule) Validate() (*FirewallRule, error) {
+	r.Protocol = strings.ToLower(r.Protocol)
+	switch r.Protocol {
+	case "tcp", "udp":
+		if r.Ports != "" {
+			ports, err := validatePorts(r.Ports)
+			if err != nil {
+				return nil, err
+			}
+			r.Ports = ports
+		}
+	case "icmp":
+		if r.Ports != "" {
+			return nil, fmt.Errorf("icmp rules can't have ports")
+		}
+	default:
+		return nil, fmt.Errorf("%q isn't a protocol firewall rules can use. It should be tcp, udp or icmp", r.Protocol)
+	}
+	if r.Source != "" {
+		source, err := validateSource(r.Source)
+		if err != nil {
+			return nil, err
+		}
+		r.Source = source
+	}
+	return &r, nil
+}
+
+// validatePorts checks that ports is a port or a range of ports like
+// 8000-8080, returning it without any whitespace.
+func validatePorts(ports string) (string, error) {
+	bits := strings.Split(ports, "-")
+	if len(bits) > 2 {
+		return "", fmt.Errorf("%q isn't a port or range of ports", ports)
+	}
+	nums := make([]int, len(bits))
+	for i, bit := range bits {
+		num, err := strconv.Atoi(strings.TrimSpace(bit))
+		if err != nil || num < 1 || num > 65535 {
+			return "", fmt.Errorf("%q isn't a port or range of ports", ports)
+		}
+		nums[i] = num
+	}
+	if len(nums) == 1 {
+		return strconv.Itoa(nums[0]), nil
+	}
+	if nums[0] > nums[1] {
+		return "", fmt.Errorf("the range of ports %q is backwards", ports)
+	}
+	return fmt.Sprintf("%d-%d", nums[0], nums[1]), nil
+}
+
+// validateSource checks that source is an IP or CIDR, returning it as a CIDR.
+func validateSource(source string) (string, error) {
+	source = strings.TrimSpace(source)
+	if ip := net.ParseIP(source); ip != nil {
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+	_, ipnet, err := net.ParseCIDR(source)
+	if err != nil {
+		return "", fmt.Errorf("%q isn't an IP or CIDR", source)
+	}
+	return ipnet.String(), nil
+}
+
+// FirewallRules represents multiple FirewallRule objects in output.Outputtable form.
+type FirewallRules []FirewallRule
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as FirewallRule.DefaultFields.
+func (rs FirewallRules) DefaultFields(f output.Format) string {
+	return (FirewallRule{}).DefaultFields(f)
+}
+
+// PrettyPrint outputs each rule on its own line.
+func (rs FirewallRules) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	const template = `{{ define "firewallrules_sgl" }}{{ len . }} firewall rules{{ end }}
+{{ define "firewallrules_medium" }}{{ range . }}{{ prettysprint . "_sgl" }}
+{{ end }}{{ end }}
+{{ define "firewallrules_full" }}{{ template "firewallrules_medium" . }}{{ end }}`
+	return prettyprint.Run(wr, template, "firewallrules"+string(detail), rs)
+}
@@ -0,0 +1,95 @@
+package brain_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestFirewallRulePrettyPrint(t *testing.T) {
+	prettyprint.RunTests(t, []prettyprint.Test{
+		{
+			Object:   brain.FirewallRule{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", Label: "office"},
+			Detail:   prettyprint.SingleLine,
+			Expected: "#12: allow tcp port 22 from 192.0.2.0/24 (office)",
+		},
+		{
+			Object:   brain.FirewallRule{ID: 13, Protocol: "udp"},
+			Detail:   prettyprint.Full,
+			Expected: "#13: allow udp all ports from anywhere",
+		},
+		{
+			Object:   brain.FirewallRule{ID: 14, Protocol: "icmp"},
+			Detail:   prettyprint.Medium,
+			Expected: "#14: allow icmp from anywhere",
+		},
+		{
+			Object: brain.FirewallRules{
+				{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24"},
+				{ID: 13, Protocol: "tcp", Ports: "443"},
+			},
+			Detail:   prettyprint.Medium,
+			Expected: "#12: allow tcp port 22 from 192.0.2.0/24\n#13: allow tcp port 443 from anywhere\n",
+		},
+	})
+}
+
+func TestFirewallRuleValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      brain.FirewallRule
+		expected  brain.FirewallRule
+		shouldErr bool
+	}{
+		{
+			name:     "ssh from the office",
+			rule:     brain.FirewallRule{Protocol: "TCP", Ports: "22", Source: "192.0.2.7/24"},
+			expected: brain.FirewallRule{Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24"},
+		}, {
+			name:     "single IPs",
+			rule:     brain.FirewallRule{Protocol: "udp", Ports: "8000 - 8080", Source: "2001:db8::7"},
+			expected: brain.FirewallRule{Protocol: "udp", Ports: "8000-8080", Source: "2001:db8::7/128"},
+		}, {
+			name:     "icmp from anywhere",
+			rule:     brain.FirewallRule{Protocol: "icmp"},
+			expected: brain.FirewallRule{Protocol: "icmp"},
+		}, {
+			name:      "icmp with a port",
+			rule:      brain.FirewallRule{Protocol: "icmp", Ports: "22"},
+			shouldErr: true,
+		}, {
+			name:      "unknown protocol",
+			rule:      brain.FirewallRule{Protocol: "sctp"},
+			shouldErr: true,
+		}, {
+			name:      "port out of range",
+			rule:      brain.FirewallRule{Protocol: "tcp", Ports: "65536"},
+			shouldErr: true,
+		}, {
+			name:      "backwards range",
+			rule:      brain.FirewallRule{Protocol: "tcp", Ports: "8080-8000"},
+			shouldErr: true,
+		}, {
+			name:      "invalid source",
+			rule:      brain.FirewallRule{Protocol: "tcp", Source: "office"},
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := test.rule.Validate()
+			if test.shouldErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.name, test.expected, *rule)
+		})
+	}
+}
@@ -16,6 +16,9 @@ type Group struct {
 	AccountID       int              `json:"account_id"`
 	ID              int              `json:"id"`
 	VirtualMachines []VirtualMachine `json:"virtual_machines"`
+	// VlanNum is the private VLAN which belongs to this group, if it has
+	// one. Servers can be attached to it with an extra network interface.
+	VlanNum int `json:"vlan_num,omitempty"`
 }
 
 // CountVirtualMachines returns the number of virtual machines in this group
@@ -9,6 +9,10 @@ import (
 	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
 )
 
+// FirstbootScriptMaxLength is the length, in bytes, of the longest firstboot
+// script the brain will accept.
+const FirstbootScriptMaxLength = 65535
+
 // ImageInstall represents what image was most recently installed on a VM along with its root password.
 // This might only be returned when creating a VM.
 type ImageInstall struct {
@@ -27,3 +27,26 @@ func (ip IP) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) (err erro
 	_, err = fmt.Fprintf(wr, "%s: %s", ip.IP, ip.RDns)
 	return
 }
+
+// IPRecords is a list of IPs along with their reverse DNS.
+type IPRecords []IP
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as IP.DefaultFields.
+func (ips IPRecords) DefaultFields(f output.Format) string {
+	return (IP{}).DefaultFields(f)
+}
+
+// PrettyPrint outputs each IP and its reverse DNS on its own line.
+func (ips IPRecords) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	for _, ip := range ips {
+		err := ip.PrettyPrint(wr, detail)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(wr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
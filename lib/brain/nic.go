@@ -53,8 +53,10 @@ func (nic NetworkInterface) PrettyPrint(wr io.Writer, detail prettyprint.DetailL
 {{ define "nic_medium" }}{{ template "nic_sgl" . }}{{ end }}
 {{ define "nic_full" -}}
 {{- template "nic_medium" . }}
-IPs directly attached: {{ join .IPs ", " }}
-
+VLAN: {{ .VlanNum }}
+{{ if .IPs }}IPs directly attached: {{ .IPs.StringSep ", " }}
+{{ end }}{{ if .ExtraIPs }}Extra IPs: {{ join .ExtraIPStrings ", " }}
+{{ end }}{{ end }}
 `
 
 	return prettyprint.Run(wr, nicTpl, "nic"+string(detail), nic)
@@ -64,3 +66,31 @@ IPs directly attached: {{ join .IPs ", " }}
 func (nic NetworkInterface) String() string {
 	return fmt.Sprintf("%s - %s - %d IPs", nic.Label, nic.Mac, len(nic.IPs)+len(nic.ExtraIPs))
 }
+
+// NetworkInterfaces represents more than one NetworkInterface in output.Outputtable form.
+type NetworkInterfaces []NetworkInterface
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as NetworkInterface.DefaultFields
+func (nics NetworkInterfaces) DefaultFields(f output.Format) string {
+	return (NetworkInterface{}).DefaultFields(f)
+}
+
+// PrettyPrint writes a human-readable summary of the network interfaces to wr at the given detail level.
+func (nics NetworkInterfaces) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	nicsTpl := `
+{{ define "nics_sgl" }}{{ len . }} network interfaces{{ end }}
+
+{{ define "nics_medium" -}}
+{{- range . -}}
+{{- prettysprint . "_sgl" }}
+{{ end -}}
+{{- end }}
+
+{{ define "nics_full" -}}
+{{- range . -}}
+{{- prettysprint . "_full" }}
+{{ end -}}
+{{- end }}
+`
+	return prettyprint.Run(wr, nicsTpl, "nics"+string(detail), nics)
+}
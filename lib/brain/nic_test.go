@@ -0,0 +1,64 @@
+package brain
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	"github.com/cheekybits/is"
+)
+
+func TestFormatNetworkInterfaces(t *testing.T) {
+	is := is.New(t)
+	b := new(bytes.Buffer)
+	nics := NetworkInterfaces{
+		{
+			Label:    "eth0",
+			Mac:      "fe:ff:00:00:00:01",
+			VlanNum:  1,
+			IPs:      IPs{net.ParseIP("192.168.1.16"), net.ParseIP("fe80::10")},
+			ExtraIPs: map[string]net.IP{"192.168.1.50": net.ParseIP("192.168.1.16")},
+		}, {
+			Label:    "eth1",
+			Mac:      "fe:ff:00:00:00:02",
+			VlanNum:  1042,
+			ExtraIPs: map[string]net.IP{},
+		},
+	}
+
+	tests := []struct {
+		detail prettyprint.DetailLevel
+		expt   string
+	}{
+		{
+			detail: prettyprint.SingleLine,
+			expt:   "2 network interfaces",
+		}, {
+			detail: prettyprint.Medium,
+			expt: `eth0 - fe:ff:00:00:00:01 - 3 IPs
+eth1 - fe:ff:00:00:00:02 - 0 IPs
+`,
+		}, {
+			detail: prettyprint.Full,
+			expt: `eth0 - fe:ff:00:00:00:01 - 3 IPs
+VLAN: 1
+IPs directly attached: 192.168.1.16, fe80::10
+Extra IPs: 192.168.1.50 -> 192.168.1.16
+
+eth1 - fe:ff:00:00:00:02 - 0 IPs
+VLAN: 1042
+
+`,
+		},
+	}
+
+	for _, test := range tests {
+		b.Truncate(0)
+		err := nics.PrettyPrint(b, test.detail)
+		if err != nil {
+			t.Error(err)
+		}
+		is.Equal(test.expt, b.String())
+	}
+}
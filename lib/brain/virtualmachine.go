@@ -35,8 +35,7 @@ type VirtualMachine struct {
 	Hostname          string             `json:"hostname,omitempty"`
 	Head              string             `json:"head,omitempty"`
 	NetworkInterfaces []NetworkInterface `json:"network_interfaces,omitempty"`
-
-	// TODO(telyn): new fields (last_imaged_with and there is another but I forgot)
+	LastImagedWith    string             `json:"last_imaged_with,omitempty"`
 }
 
 // DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
@@ -118,6 +117,42 @@ func (vm VirtualMachine) FullName() string {
 	return strings.Join(bits[0:end], ".")
 }
 
+// Spec returns a VirtualMachineSpec which would make a server like this one -
+// with the same cores, memory, zone, hardware profile, discs, backup
+// schedules and image - but without its name, IPs, root password or any IDs.
+// It's used to capture servers as VM defaults.
+func (vm VirtualMachine) Spec() VirtualMachineSpec {
+	spec := VirtualMachineSpec{
+		VirtualMachine: VirtualMachine{
+			Cores:                 vm.Cores,
+			Memory:                vm.Memory,
+			ZoneName:              vm.ZoneName,
+			HardwareProfile:       vm.HardwareProfile,
+			HardwareProfileLocked: vm.HardwareProfileLocked,
+			CdromURL:              vm.CdromURL,
+		},
+	}
+	for _, disc := range vm.Discs {
+		specDisc := Disc{
+			Label:        disc.Label,
+			StorageGrade: disc.StorageGrade,
+			Size:         disc.Size,
+		}
+		for _, bs := range disc.BackupSchedules {
+			// the start date is left for whoever uses the spec to decide
+			specDisc.BackupSchedules = append(specDisc.BackupSchedules, BackupSchedule{
+				Interval: bs.Interval,
+				Capacity: bs.Capacity,
+			})
+		}
+		spec.Discs = append(spec.Discs, specDisc)
+	}
+	if vm.LastImagedWith != "" {
+		spec.Reimage = &ImageInstall{Distribution: vm.LastImagedWith}
+	}
+	return spec
+}
+
 // AllIPv4Addresses flattens all the IPs for a VM into a single IPs (a []*net.IP with some convenience methods)
 func (vm VirtualMachine) AllIPv4Addresses() (ips IPs) {
 	for _, nic := range vm.NetworkInterfaces {
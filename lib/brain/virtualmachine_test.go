@@ -142,6 +142,38 @@ func TestNames(t *testing.T) {
 	is.Equal("valid-vm.default.account", vm.FullName())
 }
 
+func TestVirtualMachineSpec(t *testing.T) {
+	is := is.New(t)
+	vm := getFixtureVM()
+	vm.LastImagedWith = "stretch"
+	vm.Discs[0].BackupSchedules = BackupSchedules{{
+		ID:        4,
+		StartDate: "2018-01-01 00:00:00",
+		Interval:  86400,
+		Capacity:  2,
+	}}
+	is.Equal(VirtualMachineSpec{
+		VirtualMachine: VirtualMachine{
+			Cores:           1,
+			Memory:          1,
+			HardwareProfile: "fake-hardwareprofile",
+			ZoneName:        "default",
+		},
+		Discs: []Disc{{
+			StorageGrade: "sata",
+			Size:         26400,
+			BackupSchedules: BackupSchedules{{
+				Interval: 86400,
+				Capacity: 2,
+			}},
+		}},
+		Reimage: &ImageInstall{Distribution: "stretch"},
+	}, vm.Spec())
+
+	vm.LastImagedWith = ""
+	is.Nil(vm.Spec().Reimage)
+}
+
 func TestDiscLabelOffset(t *testing.T) {
 	is := is.New(t)
 	vm := getFixtureVM()
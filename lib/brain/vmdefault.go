@@ -21,6 +21,10 @@ type VirtualMachineDefault struct {
 
 // DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
 func (vmd VirtualMachineDefault) DefaultFields(f output.Format) string {
+	switch f {
+	case output.List:
+		return "ID, AccountID, Name, Public"
+	}
 	return "ID, AccountID, Name, Public, ServerSettings"
 }
 
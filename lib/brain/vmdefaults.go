@@ -0,0 +1,36 @@
+package brain
+
+import (
+	"io"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+)
+
+// VirtualMachineDefaults is a slice of VirtualMachineDefault implementing
+// output.Outputtable
+type VirtualMachineDefaults []VirtualMachineDefault
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (vmds VirtualMachineDefaults) DefaultFields(f output.Format) string {
+	return (VirtualMachineDefault{}).DefaultFields(f)
+}
+
+// PrettyPrint writes a human-readable summary of the VM defaults to the given
+// writer.
+func (vmds VirtualMachineDefaults) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	vmdsTpl := `
+{{ define "vmds_sgl" }}{{ len . }} VM defaults{{ end }}
+{{ define "vmds_medium" }}
+{{- range . -}}
+{{- prettysprint . "_sgl" }}
+{{ end -}}
+{{ end }}
+{{ define "vmds_full" }}
+{{- range . -}}
+{{- prettysprint . "_full" }}
+{{ end -}}
+{{ end }}
+`
+	return prettyprint.Run(wr, vmdsTpl, "vmds"+string(detail), vmds)
+}
@@ -0,0 +1,286 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redacted replaces anything secret before it's written to a cassette.
+const redacted = "REDACTED"
+
+// sessionTokenInPath matches the session tokens that appear in auth URLs
+var sessionTokenInPath = regexp.MustCompile(`/session/[^/?]+`)
+
+// secretFields are the names of the fields in JSON request and response
+// bodies whose values are redacted wherever they appear - root passwords for
+// new and reimaged servers, and the secrets of API keys.
+var secretFields = map[string]bool{
+	"api_key":       true,
+	"root_password": true,
+}
+
+// Cassette is a recording of HTTP requests and the responses to them, which
+// can be saved to a file and replayed later in place of talking to the real
+// APIs. Cassettes are made with RecordCassette or ReplayCassette, and used by
+// passing them to WithCassette.
+//
+// Authorization headers, session tokens, credentials sent to the auth
+// server, card details sent to SPP, and root passwords and API key secrets
+// in JSON bodies are all redacted before being recorded, so cassettes are
+// safe to share and check in as test fixtures.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	mu        sync.Mutex
+	path      string
+	replaying bool
+	played    []bool
+}
+
+// Interaction is a single request and response stored in a Cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request half of an Interaction.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response half of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// CassetteMissError is returned when replaying a Cassette which doesn't have
+// a response recorded for a request.
+type CassetteMissError struct {
+	Method string
+	URL    string
+}
+
+func (e CassetteMissError) Error() string {
+	return fmt.Sprintf("No response was recorded for %s %s", e.Method, e.URL)
+}
+
+// RecordCassette creates a Cassette which records every request made through
+// it to the file at path, overwriting anything already there. The file is
+// rewritten after each request, so it's complete even if the program exits
+// without warning.
+func RecordCassette(path string) (*Cassette, error) {
+	c := &Cassette{
+		Interactions: []Interaction{},
+		path:         path,
+	}
+	return c, c.save()
+}
+
+// ReplayCassette loads the Cassette saved at path so that its responses can be
+// replayed. Each recorded response is served once, in the order they were
+// recorded, to a request with the same method and URL. Requests which have
+// no response left in the cassette fail with a CassetteMissError rather than
+// going out to the network.
+func ReplayCassette(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{replaying: true}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s is not a valid cassette: %s", path, err)
+	}
+	c.played = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+// RoundTripper returns an http.RoundTripper which records requests made with
+// next into the Cassette, or replays them from it without using next at
+// all. If next is nil, http.DefaultTransport is used.
+func (c *Cassette) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return cassetteTransport{cassette: c, next: next}
+}
+
+// save writes the cassette out to its file
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, data, 0600)
+}
+
+// record adds an interaction to the cassette and saves it
+func (c *Cassette) record(interaction Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, interaction)
+	return c.save()
+}
+
+// replay finds the first interaction matching req which hasn't been played yet
+func (c *Cassette) replay(req RecordedRequest) (RecordedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.Interactions {
+		if c.played[i] {
+			continue
+		}
+		if interaction.Request.Method == req.Method && interaction.Request.URL == req.URL {
+			c.played[i] = true
+			return interaction.Response, nil
+		}
+	}
+	return RecordedResponse{}, CassetteMissError{Method: req.Method, URL: req.URL}
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+// RoundTrip records or replays a single request.
+func (t cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	recReq := redactRequest(req, reqBody)
+
+	if t.cassette.replaying {
+		recRes, err := t.cassette.replay(recReq)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recRes.StatusCode, http.StatusText(recRes.StatusCode)),
+			StatusCode:    recRes.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recRes.Header,
+			Body:          ioutil.NopCloser(strings.NewReader(recRes.Body)),
+			ContentLength: int64(len(recRes.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	recRes := RecordedResponse{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       redactJSON(resBody),
+	}
+	if req.Method == "POST" && strings.Contains(req.URL.Path, "/session") {
+		// creating a session (or impersonating someone) returns its token
+		recRes.Body = redacted
+	}
+	err = t.cassette.record(Interaction{Request: recReq, Response: recRes})
+	return res, err
+}
+
+// redactRequest makes a RecordedRequest out of req with all the secrets
+// removed. The same redaction is used when replaying, so that requests
+// containing different secrets still match.
+func redactRequest(req *http.Request, body []byte) RecordedRequest {
+	header := http.Header{}
+	for k, v := range req.Header {
+		header[k] = v
+	}
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", redacted)
+	}
+	recReq := RecordedRequest{
+		Method: req.Method,
+		URL:    sessionTokenInPath.ReplaceAllString(req.URL.String(), "/session/"+redacted),
+		Header: header,
+		Body:   redactJSON(body),
+	}
+	// these carry passwords, yubikey OTPs and card numbers
+	if strings.HasSuffix(req.URL.Path, "/session") || strings.HasSuffix(req.URL.Path, "/card.ref") {
+		recReq.Body = redacted
+	}
+	if len(body) == 0 {
+		recReq.Body = ""
+	}
+	return recReq
+}
+
+// redactJSON returns body with the values of any secretFields redacted, at
+// any depth. Bodies which aren't JSON objects or arrays are returned as-is.
+func redactJSON(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return string(body)
+	}
+	if !redactValue(v) {
+		return string(body)
+	}
+	redactedBody, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(redactedBody)
+}
+
+// redactValue redacts the secretFields in v in place, returning true if
+// there were any.
+func redactValue(v interface{}) (found bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if secretFields[key] {
+				if value != nil && value != "" {
+					v[key] = redacted
+					found = true
+				}
+				continue
+			}
+			if redactValue(value) {
+				found = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactValue(value) {
+				found = true
+			}
+		}
+	}
+	return
+}
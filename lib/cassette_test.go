@@ -0,0 +1,111 @@
+package lib_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/fake"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	server := fake.NewServer()
+	server.AddUser("alice", "hunter2")
+	if _, err := server.AddAccount("alice", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	vmName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: pathers.GroupName{Group: "default", Account: "alice"}}
+
+	// record a login and a couple of brain requests
+	cassette, err := lib.RecordCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := server.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = lib.WithCassette(client, cassette)
+	if err := client.AuthWithCredentials(map[string]string{"username": "alice", "password": "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	token := client.GetSessionToken()
+	_, err = client.CreateVirtualMachine(vmName.GroupName, brain.VirtualMachineSpec{
+		VirtualMachine: brain.VirtualMachine{Name: "web1", Cores: 1, Memory: 1024},
+		Discs:          []brain.Disc{{Size: 25600}},
+		Reimage:        &brain.ImageInstall{Distribution: "stretch", RootPassword: "correct-horse"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := client.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKey, err := brainRequests.CreateAPIKey(client, "alice", brain.APIKey{Label: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiKey.APIKey == "" {
+		t.Fatal("the new API key's secret wasn't returned")
+	}
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", token, "correct-horse", apiKey.APIKey} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains the secret %q:\n%s", secret, data)
+		}
+	}
+
+	// replay it with the server gone
+	cassette, err = lib.ReplayCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err = lib.NewWithURLs(server.URLs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AllowInsecureRequests()
+	client = lib.WithCassette(client, cassette)
+	if err := client.AuthWithCredentials(map[string]string{"username": "alice", "password": "a different password"}); err != nil {
+		t.Fatal(err)
+	}
+	if client.GetSessionUser() != "alice" {
+		t.Errorf("expected to be logged in as alice, but was %q", client.GetSessionUser())
+	}
+	if _, err := client.CreateVirtualMachine(vmName.GroupName, brain.VirtualMachineSpec{}); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := client.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.ID != recorded.ID || replayed.Hostname != recorded.Hostname {
+		t.Errorf("replayed server %#v doesn't match recorded server %#v", replayed, recorded)
+	}
+
+	_, err = client.GetVirtualMachine(vmName)
+	if err == nil {
+		t.Error("a request was answered after the cassette ran out")
+	} else if !strings.Contains(err.Error(), "No response was recorded") {
+		t.Errorf("unexpected error once the cassette ran out: %s", err)
+	}
+}
@@ -1,7 +1,11 @@
 package lib
 
 import (
+	"context"
+	"time"
+
 	auth3 "github.com/BytemarkHosting/auth-client"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
 	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
 	"github.com/BytemarkHosting/bytemark-client/util/log"
 )
@@ -33,7 +37,12 @@ type bytemarkClient struct {
 	allowInsecure bool
 	auth          *auth3.Client
 	authSession   *auth3.SessionData
+	apiKey        *brain.APIKey
+	cassette      *Cassette
+	ctx           context.Context
 	debugLevel    int
+	retryPolicy   RetryPolicy
+	timeout       time.Duration
 	urls          EndpointURLs
 }
 
@@ -53,9 +62,10 @@ func NewWithURLs(urls EndpointURLs) (c Client, err error) {
 		return nil, err
 	}
 	client := bytemarkClient{
-		urls:       urls,
-		auth:       auth,
-		debugLevel: 0,
+		urls:        urls,
+		auth:        auth,
+		debugLevel:  0,
+		retryPolicy: DefaultRetryPolicy(),
 	}
 	return &client, nil
 }
@@ -0,0 +1,77 @@
+package lib
+
+import (
+	"context"
+	"time"
+)
+
+// WithContext returns a copy of client which makes all its requests using
+// ctx, allowing them to be cancelled or given a deadline. Clients which
+// weren't made by this package are returned unchanged.
+//
+// The copy has its own authentication session, so it should be authenticated
+// after WithContext is called, or not at all if client has been already.
+func WithContext(client Client, ctx context.Context) Client {
+	c, ok := client.(*bytemarkClient)
+	if !ok {
+		return client
+	}
+	newClient := *c
+	newClient.ctx = ctx
+	return &newClient
+}
+
+// WithTimeout returns a copy of client which gives up on any HTTP request
+// (including authentication) that takes longer than timeout. A timeout of 0
+// means requests can take forever. Clients which weren't made by this package
+// are returned unchanged.
+func WithTimeout(client Client, timeout time.Duration) Client {
+	c, ok := client.(*bytemarkClient)
+	if !ok {
+		return client
+	}
+	newClient := *c
+	newClient.timeout = timeout
+	auth := *c.auth
+	auth.HTTP.Timeout = timeout
+	newClient.auth = &auth
+	return &newClient
+}
+
+// WithRetryPolicy returns a copy of client which retries requests according
+// to policy. Clients which weren't made by this package are returned
+// unchanged.
+func WithRetryPolicy(client Client, policy RetryPolicy) Client {
+	c, ok := client.(*bytemarkClient)
+	if !ok {
+		return client
+	}
+	newClient := *c
+	newClient.retryPolicy = policy
+	return &newClient
+}
+
+// WithCassette returns a copy of client which records all its requests
+// (including authentication) to cassette, or replays them from it, depending
+// on whether cassette was made by RecordCassette or ReplayCassette. Clients
+// which weren't made by this package are returned unchanged.
+func WithCassette(client Client, cassette *Cassette) Client {
+	c, ok := client.(*bytemarkClient)
+	if !ok {
+		return client
+	}
+	newClient := *c
+	newClient.cassette = cassette
+	auth := *c.auth
+	auth.HTTP.Transport = cassette.RoundTripper(auth.HTTP.Transport)
+	newClient.auth = &auth
+	return &newClient
+}
+
+// context returns the context requests should be made with.
+func (c *bytemarkClient) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
@@ -0,0 +1,151 @@
+package lib_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := lib.RetryPolicy{
+		Backoff:    time.Second,
+		MaxBackoff: 5 * time.Second,
+	}
+	expected := []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	}
+	for retry, delay := range expected {
+		assert.Equal(t, testutil.Name(retry), delay, policy.Delay(retry))
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		statuses      []int
+		retries       int
+		expectedCalls int
+		shouldErr     bool
+	}{
+		{
+			name:          "succeeds after 503s",
+			method:        "GET",
+			statuses:      []int{503, 502, 200},
+			retries:       2,
+			expectedCalls: 3,
+		}, {
+			name:          "runs out of retries",
+			method:        "GET",
+			statuses:      []int{504, 504, 504},
+			retries:       1,
+			expectedCalls: 2,
+			shouldErr:     true,
+		}, {
+			name:          "doesn't retry other errors",
+			method:        "GET",
+			statuses:      []int{500, 200},
+			retries:       2,
+			expectedCalls: 1,
+			shouldErr:     true,
+		}, {
+			name:          "doesn't retry POSTs",
+			method:        "POST",
+			statuses:      []int{503, 200},
+			retries:       2,
+			expectedCalls: 1,
+			shouldErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			rts := testutil.RequestTestSpec{
+				MuxHandlers: &testutil.MuxHandlers{
+					Brain: testutil.Mux{
+						"/retry": func(w http.ResponseWriter, r *http.Request) {
+							w.WriteHeader(test.statuses[calls])
+							calls++
+							testutil.WriteJSON(t, w, map[string]string{})
+						},
+					},
+				},
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				client = lib.WithRetryPolicy(client, lib.RetryPolicy{
+					Retries: test.retries,
+					Backoff: time.Millisecond,
+				})
+				r, err := client.BuildRequest(test.method, lib.BrainEndpoint, "/retry")
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, _, err = r.Run(nil, nil)
+				if test.shouldErr && err == nil {
+					t.Error("expected an error but didn't get one")
+				} else if !test.shouldErr && err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			})
+			assert.Equal(t, test.name, test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	rts := testutil.RequestTestSpec{
+		MuxHandlers: &testutil.MuxHandlers{
+			Brain: testutil.Mux{
+				"/slow": func(w http.ResponseWriter, r *http.Request) {
+					time.Sleep(200 * time.Millisecond)
+					testutil.WriteJSON(t, w, map[string]string{})
+				},
+			},
+		},
+	}
+	rts.Run(t, "timeout", true, func(client lib.Client) {
+		client = lib.WithTimeout(client, 10*time.Millisecond)
+		r, err := client.BuildRequest("GET", lib.BrainEndpoint, "/slow")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = r.Run(nil, nil)
+		if err == nil {
+			t.Error("expected the request to time out, but it didn't")
+		}
+	})
+}
+
+func TestWithContext(t *testing.T) {
+	rts := testutil.RequestTestSpec{
+		MuxHandlers: &testutil.MuxHandlers{
+			Brain: testutil.Mux{
+				"/cancelled": func(w http.ResponseWriter, r *http.Request) {
+					t.Error("request was made despite the context being cancelled")
+				},
+			},
+		},
+	}
+	rts.Run(t, "cancelled", true, func(client lib.Client) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		client = lib.WithContext(client, ctx)
+		r, err := client.BuildRequest("GET", lib.BrainEndpoint, "/cancelled")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = r.Run(nil, nil)
+		if err == nil {
+			t.Error("expected an error from the cancelled context, but didn't get one")
+		}
+	})
+}
@@ -82,6 +82,30 @@ func (e UnauthorizedError) Error() string {
 
 }
 
+// APIKeyRejectedError is returned when authenticating with an API key which
+// the brain doesn't recognise - it may have been deleted, or mistyped.
+type APIKeyRejectedError struct {
+	APIError
+}
+
+func (e APIKeyRejectedError) Error() string {
+	return "The API key was rejected by the brain - check it hasn't been deleted with `bytemark show api keys`, or log in with a different one with `bytemark login --api-key`."
+}
+
+// APIKeyExpiredError is returned when authenticating with an API key which
+// has expired.
+type APIKeyExpiredError struct {
+	Label     string
+	ExpiresAt string
+}
+
+func (e APIKeyExpiredError) Error() string {
+	if e.Label == "" {
+		return "The API key has expired. Make a new one with `bytemark add api key`, or extend it with `bytemark update api key`."
+	}
+	return fmt.Sprintf("The API key %s expired at %s. Make a new one with `bytemark add api key`, or extend it with `bytemark update api key`.", e.Label, e.ExpiresAt)
+}
+
 // UnknownStatusCodeError is returned when an action caused API to return a strange status code that the client library wasn't expecting. Perhaps it's a protocol mismatch - try updating to the latest version of the library, otherwise file a bug report.
 type UnknownStatusCodeError struct {
 	APIError
@@ -0,0 +1,216 @@
+package fake
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// authInfo is who a request is authenticated as.
+type authInfo struct {
+	user *user
+	// apiKey is set when the request was authenticated with an API key, in
+	// which case only the privileges attached to the key apply.
+	apiKey *brain.APIKey
+}
+
+// privileges returns all the privileges that apply to the request.
+func (a *authInfo) privileges(c *Cluster) (privs []*brain.Privilege) {
+	for _, p := range c.privileges {
+		if p.Username != a.user.Username {
+			continue
+		}
+		if a.apiKey == nil && p.APIKeyID == 0 || a.apiKey != nil && p.APIKeyID == a.apiKey.ID {
+			privs = append(privs, p)
+		}
+	}
+	return
+}
+
+// isClusterAdmin returns true if the request has cluster_admin
+func (a *authInfo) isClusterAdmin(c *Cluster) bool {
+	for _, p := range a.privileges(c) {
+		if p.Level == brain.ClusterAdminPrivilege {
+			return true
+		}
+	}
+	return false
+}
+
+// canAdminAccount returns true if the request has account_admin on acc
+func (a *authInfo) canAdminAccount(c *Cluster, acc *brain.Account) bool {
+	for _, p := range a.privileges(c) {
+		if p.Level == brain.ClusterAdminPrivilege || p.Level == brain.AccountAdminPrivilege && p.AccountID == acc.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// canAdminGroup returns true if the request has group_admin on group, or
+// account_admin on its account.
+func (a *authInfo) canAdminGroup(c *Cluster, group *brain.Group) bool {
+	if a.canAdminAccount(c, c.groupAccount(group)) {
+		return true
+	}
+	for _, p := range a.privileges(c) {
+		if p.Level == brain.GroupAdminPrivilege && p.GroupID == group.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// canAdminVM returns true if the request has vm_admin on vm, or a higher
+// privilege on its group or account.
+func (a *authInfo) canAdminVM(c *Cluster, vm *brain.VirtualMachine) bool {
+	if a.canAdminGroup(c, c.groupByID(vm.GroupID)) {
+		return true
+	}
+	for _, p := range a.privileges(c) {
+		if p.Level == brain.VMAdminPrivilege && p.VirtualMachineID == vm.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// canSeeVM returns true if the request has any privilege on vm.
+func (a *authInfo) canSeeVM(c *Cluster, vm *brain.VirtualMachine) bool {
+	if a.canAdminVM(c, vm) {
+		return true
+	}
+	for _, p := range a.privileges(c) {
+		if p.VirtualMachineID == vm.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// canSeeGroup returns true if the request has any privilege on group or a
+// server in it.
+func (a *authInfo) canSeeGroup(c *Cluster, group *brain.Group) bool {
+	if a.canAdminGroup(c, group) {
+		return true
+	}
+	for _, vm := range c.vms {
+		if vm.GroupID == group.ID && a.canSeeVM(c, vm) {
+			return true
+		}
+	}
+	return false
+}
+
+// canSeeAccount returns true if the request has any privilege on acc or
+// anything in it.
+func (a *authInfo) canSeeAccount(c *Cluster, acc *brain.Account) bool {
+	if a.canAdminAccount(c, acc) {
+		return true
+	}
+	for _, group := range c.groups {
+		if group.AccountID == acc.ID && a.canSeeGroup(c, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateToken finds out who the token in the Authorization header
+// belongs to. Both "Bearer <token>" and "Token token=<token>" are accepted,
+// and tokens may be session tokens or 'apikey.' followed by an API key.
+func authenticateToken(c *Cluster, r *http.Request) (*authInfo, error) {
+	header := r.Header.Get("Authorization")
+	token := ""
+	switch {
+	case strings.HasPrefix(header, "Bearer "):
+		token = strings.TrimPrefix(header, "Bearer ")
+	case strings.HasPrefix(header, "Token token="):
+		token = strings.TrimPrefix(header, "Token token=")
+	default:
+		return nil, httpError{status: http.StatusUnauthorized, message: "missing Authorization header"}
+	}
+
+	if strings.HasPrefix(token, "apikey.") {
+		secret := strings.TrimPrefix(token, "apikey.")
+		for _, key := range c.apiKeys {
+			if key.APIKey != secret {
+				continue
+			}
+			if key.Expired() {
+				return nil, httpError{status: http.StatusUnauthorized, message: "api key has expired", code: "api_key_expired"}
+			}
+			for _, u := range c.users {
+				if u.ID == key.UserID {
+					return &authInfo{user: u, apiKey: key}, nil
+				}
+			}
+		}
+		return nil, httpError{status: http.StatusUnauthorized, message: "invalid api key"}
+	}
+	if u := c.findUser(c.sessions[token]); u != nil {
+		return &authInfo{user: u}, nil
+	}
+	return nil, httpError{status: http.StatusUnauthorized, message: "invalid session token"}
+}
+
+// sessionData is what auth returns when reading a session
+type sessionData struct {
+	Username         string   `json:"username"`
+	Factors          []string `json:"factors"`
+	GroupMemberships []string `json:"group_memberships"`
+}
+
+// AuthHandler returns an http.Handler which behaves like Bytemark's auth
+// server.
+func (c *Cluster) AuthHandler() http.Handler {
+	rt := &router{cluster: c}
+	rt.handleNoAuth("POST", "/session", createSession)
+	rt.handleNoAuth("GET", "/session/:token", readSession)
+	rt.handleNoAuth("POST", "/session/:token", impersonate)
+	return rt
+}
+
+func createSession(c *Cluster, req *request) (interface{}, error) {
+	credentials := map[string]string{}
+	if err := req.decode(&credentials); err != nil {
+		return nil, err
+	}
+	u := c.findUser(credentials["username"])
+	if u == nil || u.Password != credentials["password"] {
+		return nil, httpError{status: http.StatusUnauthorized, message: "Incorrect username or password"}
+	}
+	return c.newSession(u.Username), nil
+}
+
+func readSession(c *Cluster, req *request) (interface{}, error) {
+	username, ok := c.sessions[req.param("token")]
+	if !ok {
+		return nil, notFound("no such session")
+	}
+	return sessionData{
+		Username:         username,
+		Factors:          []string{"username", "password"},
+		GroupMemberships: []string{},
+	}, nil
+}
+
+func impersonate(c *Cluster, req *request) (interface{}, error) {
+	u := c.findUser(c.sessions[req.param("token")])
+	if u == nil {
+		return nil, notFound("no such session")
+	}
+	if !(&authInfo{user: u}).isClusterAdmin(c) {
+		return nil, forbidden("%s is not allowed to impersonate", u.Username)
+	}
+	credentials := map[string]string{}
+	if err := req.decode(&credentials); err != nil {
+		return nil, err
+	}
+	target := c.findUser(credentials["username"])
+	if target == nil {
+		return nil, notFound("no such user %s", credentials["username"])
+	}
+	return c.newSession(target.Username), nil
+}
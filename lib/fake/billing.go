@@ -0,0 +1,158 @@
+package fake
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/billing"
+)
+
+// BillingHandler returns an http.Handler which behaves like bmbilling.
+func (c *Cluster) BillingHandler() http.Handler {
+	rt := &router{cluster: c, authenticate: authenticateToken}
+	rt.handle("GET", "/api/v1/accounts", getBillingAccounts)
+	rt.handleNoAuth("POST", "/api/v1/accounts", registerAccount)
+	rt.handleNoAuth("POST", "/api/v1/accounts/spp_token", createSPPToken)
+	rt.handle("GET", "/api/v1/definitions/:name", getBillingDefinition)
+	rt.handle("PUT", "/api/v1/definitions/:name", updateBillingDefinition)
+	rt.handle("GET", "/api/v1/people", getPeople)
+	rt.handle("POST", "/api/v1/agreements/:id/assents", assentToAgreement)
+	return rt
+}
+
+// SPPHandler returns an http.Handler which behaves like SPP, the service which
+// stores credit card details.
+func (c *Cluster) SPPHandler() http.Handler {
+	rt := &router{cluster: c}
+	rt.handleNoAuth("POST", "/card.ref", createCardReference)
+	return rt
+}
+
+func getBillingAccounts(c *Cluster, req *request) (interface{}, error) {
+	admin := req.auth.isClusterAdmin(c)
+	accounts := []billing.Account{}
+	for _, acc := range c.billingAccounts {
+		mine := acc.OwnerID == req.auth.user.ID || acc.TechnicalContactID == req.auth.user.ID
+		if name := req.query("bigv_account_name"); name != "" && name != acc.Name {
+			continue
+		}
+		if admin || mine {
+			accounts = append(accounts, *acc)
+		}
+	}
+	return accounts, nil
+}
+
+// registerAccount signs up a new customer, creating a user for the owner and
+// an account for them.
+func registerAccount(c *Cluster, req *request) (interface{}, error) {
+	spec := billing.Account{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	owner := spec.Owner
+	if owner.Username == "" || owner.Password == "" {
+		return nil, badRequest("the owner's username and password must be set")
+	}
+	if c.findUser(owner.Username) != nil {
+		return nil, badRequest("the username %s is already taken", owner.Username)
+	}
+	if spec.Name == "" {
+		spec.Name = owner.Username
+	}
+	if c.findAccount(spec.Name) != nil {
+		return nil, badRequest("the account name %s is already taken", spec.Name)
+	}
+	c.addUser(owner.Username, owner.Password, owner)
+	if _, err := c.addAccount(spec.Name, owner.Username); err != nil {
+		return nil, badRequest("%s", err)
+	}
+	acc := c.billingAccounts[len(c.billingAccounts)-1]
+	acc.CardReference = spec.CardReference
+	return *acc, nil
+}
+
+func createSPPToken(c *Cluster, req *request) (interface{}, error) {
+	spec := billing.SPPTokenRequest{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	if spec.Owner == nil {
+		if _, err := authenticateToken(c, req.r); err != nil {
+			return nil, err
+		}
+	}
+	token := "spp." + strconv.Itoa(c.nextID())
+	c.cardReferences[token] = ""
+	return map[string]string{"token": token}, nil
+}
+
+func createCardReference(c *Cluster, req *request) (interface{}, error) {
+	if err := req.r.ParseForm(); err != nil {
+		return nil, badRequest("%s", err)
+	}
+	token := req.r.PostForm.Get("token")
+	if _, ok := c.cardReferences[token]; !ok {
+		return nil, httpError{status: http.StatusUnauthorized, message: "invalid token"}
+	}
+	if len(req.r.PostForm.Get("account_number")) < 12 {
+		return nil, badRequest("invalid card number")
+	}
+	ref := "card." + strconv.Itoa(c.nextID())
+	c.cardReferences[token] = ref
+	return ref, nil
+}
+
+func findBillingDefinition(c *Cluster, name string) (*billing.Definition, error) {
+	for _, def := range c.billingDefinitions {
+		if def.Name == name {
+			return def, nil
+		}
+	}
+	return nil, notFound("no such definition %s", name)
+}
+
+func getBillingDefinition(c *Cluster, req *request) (interface{}, error) {
+	def, err := findBillingDefinition(c, req.param("name"))
+	if err != nil {
+		return nil, err
+	}
+	return *def, nil
+}
+
+func updateBillingDefinition(c *Cluster, req *request) (interface{}, error) {
+	def, err := findBillingDefinition(c, req.param("name"))
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.isClusterAdmin(c) {
+		return nil, forbidden("you need to be in %s to update %s", def.UpdateGroupReq, def.Name)
+	}
+	spec := billing.Definition{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	def.Value = spec.Value
+	return nil, nil
+}
+
+func getPeople(c *Cluster, req *request) (interface{}, error) {
+	admin := req.auth.isClusterAdmin(c)
+	people := []billing.Person{}
+	for _, u := range c.users {
+		if u.Username == req.query("username") && (admin || u == req.auth.user) {
+			people = append(people, u.Person)
+		}
+	}
+	return people, nil
+}
+
+func assentToAgreement(c *Cluster, req *request) (interface{}, error) {
+	assent := billing.Assent{}
+	if err := req.decode(&assent); err != nil {
+		return nil, err
+	}
+	assent.AgreementID = req.param("id")
+	c.assents = append(c.assents, assent)
+	return nil, nil
+}
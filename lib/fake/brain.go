@@ -0,0 +1,274 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+const vmPath = "/accounts/:account/groups/:group/virtual_machines/:vm"
+const discPath = vmPath + "/discs/:disc"
+
+// BrainHandler returns an http.Handler which behaves like the brain - the API
+// for managing servers, discs, privileges and so on.
+func (c *Cluster) BrainHandler() http.Handler {
+	rt := &router{cluster: c, authenticate: authenticateToken}
+
+	rt.handleNoAuth("GET", "/definitions", getDefinitions)
+
+	rt.handle("GET", "/accounts", getAccounts)
+	rt.handle("GET", "/accounts/:account", getAccount)
+	rt.handle("POST", "/accounts/:account/groups", createGroup)
+	rt.handle("GET", "/accounts/:account/groups/:group", getGroup)
+	rt.handle("DELETE", "/accounts/:account/groups/:group", deleteGroup)
+	rt.handle("GET", "/accounts/:account/groups/:group/firewall_rules", getGroupFirewallRules)
+	rt.handle("POST", "/accounts/:account/groups/:group/firewall_rules", createGroupFirewallRule)
+
+	rt.handle("POST", "/accounts/:account/groups/:group/vm_create", createVM)
+	rt.handle("GET", vmPath, getVM)
+	rt.handle("GET", "/virtual_machines/:vm", getVMByID)
+	rt.handle("PUT", vmPath, updateVM)
+	rt.handle("DELETE", vmPath, deleteVM)
+	rt.handle("POST", vmPath+"/signal", signalVM)
+	rt.handle("POST", vmPath+"/reimage", reimageVM)
+	rt.handle("GET", vmPath+"/firewall_rules", getVMFirewallRules)
+	rt.handle("POST", vmPath+"/firewall_rules", createVMFirewallRule)
+	rt.handle("GET", "/firewall_rules/:id", getFirewallRule)
+	rt.handle("DELETE", "/firewall_rules/:id", deleteFirewallRule)
+	rt.handle("POST", vmPath+"/nics", createNIC)
+	rt.handle("DELETE", vmPath+"/nics/:nic", deleteNIC)
+	rt.handle("POST", vmPath+"/nics/:nic/ip_create", createIPs)
+	rt.handle("POST", "/ips/swap_virtual_machine_ips", swapIPs)
+	rt.handle("GET", "/ips/:ip", getIP)
+	rt.handle("PUT", "/ips/:ip", updateIP)
+	rt.handle("DELETE", "/ips/:ip", deleteIP)
+
+	rt.handle("POST", vmPath+"/discs", createDisc)
+	rt.handle("GET", discPath, getDisc)
+	rt.handle("PUT", discPath, updateDisc)
+	rt.handle("DELETE", discPath, deleteDisc)
+	rt.handle("GET", "/discs/:id", getDiscByID)
+	rt.handle("DELETE", "/discs/:id", deleteDiscByID)
+	rt.handle("GET", discPath+"/backups", getBackups)
+	rt.handle("POST", discPath+"/backups", createBackup)
+	rt.handle("PUT", discPath+"/backups/:backup", restoreBackup)
+	rt.handle("DELETE", discPath+"/backups/:backup", deleteBackup)
+	rt.handle("POST", discPath+"/backup_schedules", createBackupSchedule)
+	rt.handle("DELETE", discPath+"/backup_schedules/:id", deleteBackupSchedule)
+
+	rt.handle("GET", "/privileges", getPrivileges)
+	rt.handle("GET", "/users/:user/privileges", getUserPrivileges)
+	rt.handle("POST", "/users/:user/privileges", grantPrivilege)
+	rt.handle("DELETE", "/privileges/:id", revokePrivilege)
+	rt.handle("GET", "/accounts/:account/privileges", getAccountPrivileges)
+	rt.handle("GET", "/accounts/:account/groups/:group/privileges", getGroupPrivileges)
+	rt.handle("GET", vmPath+"/privileges", getVMPrivileges)
+
+	rt.handle("GET", "/users/:user", getUser)
+	rt.handle("PUT", "/users/:user", updateUser)
+	rt.handle("GET", "/api_keys", getAPIKeys)
+	rt.handle("POST", "/api_keys", createAPIKey)
+	rt.handle("PUT", "/api_keys/:id", updateAPIKey)
+	rt.handle("DELETE", "/api_keys/:id", deleteAPIKey)
+	rt.handle("GET", "/vm_defaults", getVMDefaults)
+	rt.handle("POST", "/vm_defaults", createVMDefault)
+
+	c.addAdminRoutes(rt)
+	return rt
+}
+
+// patch overlays the fields in body onto obj, as the brain does for PUT
+// requests. Only the fields named in allowed may be changed, unless allowed
+// is nil.
+func patch(obj interface{}, body map[string]json.RawMessage, allowed ...string) error {
+	if allowed != nil {
+		for field := range body {
+			if !contains(allowed, field) {
+				return badRequest("%s can't be changed", field)
+			}
+		}
+	}
+	js, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	merged := map[string]json.RawMessage{}
+	if err := json.Unmarshal(js, &merged); err != nil {
+		return err
+	}
+	for field, value := range body {
+		merged[field] = value
+	}
+	js, err = json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(js, obj); err != nil {
+		return badRequest("invalid value: %s", err)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func getDefinitions(c *Cluster, req *request) (interface{}, error) {
+	d := c.Definitions
+	defs := map[string]interface{}{
+		"distributions":              d.Distributions,
+		"storage_grades":             d.StorageGrades,
+		"zone_names":                 d.ZoneNames,
+		"hardware_profiles":          d.HardwareProfiles,
+		"keymaps":                    d.Keymaps,
+		"sendkeys":                   d.Sendkeys,
+		"distribution_descriptions":  d.DistributionDescriptions,
+		"storage_grade_descriptions": d.StorageGradeDescriptions,
+	}
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := lib.JSONDefinitions{}
+	for _, id := range ids {
+		data, err := json.Marshal(defs[id])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &lib.JSONDefinition{ID: id, Data: data})
+	}
+	return out, nil
+}
+
+// lookupAccount finds the account named in the path, which the request must
+// be able to see.
+func lookupAccount(c *Cluster, req *request) (*brain.Account, error) {
+	acc := c.findAccount(req.param("account"))
+	if acc == nil || !req.auth.canSeeAccount(c, acc) {
+		return nil, notFound("no such account %s", req.param("account"))
+	}
+	return acc, nil
+}
+
+// lookupGroup finds the group named in the path, which the request must be
+// able to see.
+func lookupGroup(c *Cluster, req *request) (*brain.Group, error) {
+	acc, err := lookupAccount(c, req)
+	if err != nil {
+		return nil, err
+	}
+	group := c.findGroup(acc, req.param("group"))
+	if group == nil || !req.auth.canSeeGroup(c, group) {
+		return nil, notFound("no such group %s", req.param("group"))
+	}
+	return group, nil
+}
+
+// renderAccountFor renders acc with only the groups and servers the request
+// can see.
+func renderAccountFor(c *Cluster, req *request, acc *brain.Account) brain.Account {
+	out := c.renderAccount(acc)
+	groups := brain.Groups{}
+	for _, group := range out.Groups {
+		if !req.auth.canSeeGroup(c, c.groupByID(group.ID)) {
+			continue
+		}
+		group.VirtualMachines = filterVMs(c, req, group.VirtualMachines)
+		groups = append(groups, group)
+	}
+	out.Groups = groups
+	return out
+}
+
+func filterVMs(c *Cluster, req *request, vms []brain.VirtualMachine) []brain.VirtualMachine {
+	out := []brain.VirtualMachine{}
+	for _, vm := range vms {
+		if req.auth.canSeeVM(c, c.vmByID(vm.ID)) {
+			out = append(out, vm)
+		}
+	}
+	return out
+}
+
+func getAccounts(c *Cluster, req *request) (interface{}, error) {
+	accounts := []brain.Account{}
+	for _, acc := range c.accounts {
+		if req.auth.canSeeAccount(c, acc) {
+			accounts = append(accounts, renderAccountFor(c, req, acc))
+		}
+	}
+	return accounts, nil
+}
+
+func getAccount(c *Cluster, req *request) (interface{}, error) {
+	acc, err := lookupAccount(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return renderAccountFor(c, req, acc), nil
+}
+
+func createGroup(c *Cluster, req *request) (interface{}, error) {
+	acc, err := lookupAccount(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminAccount(c, acc) {
+		return nil, forbidden("you need account_admin on %s to create groups", acc.Name)
+	}
+	spec := brain.Group{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	if spec.Name == "" {
+		return nil, badRequest("name can't be blank")
+	}
+	if c.findGroup(acc, spec.Name) != nil {
+		return nil, badRequest("a group called %s already exists in %s", spec.Name, acc.Name)
+	}
+	group := &brain.Group{ID: c.nextID(), AccountID: acc.ID, Name: spec.Name}
+	c.groups = append(c.groups, group)
+	return c.renderGroup(group), nil
+}
+
+func getGroup(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	out := c.renderGroup(group)
+	out.VirtualMachines = filterVMs(c, req, out.VirtualMachines)
+	return out, nil
+}
+
+func deleteGroup(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminAccount(c, c.groupAccount(group)) {
+		return nil, forbidden("you need account_admin to delete groups")
+	}
+	for _, vm := range c.vms {
+		if vm.GroupID == group.ID {
+			return nil, badRequest("group %s still has servers in it", group.Name)
+		}
+	}
+	for i, g := range c.groups {
+		if g == group {
+			c.groups = append(c.groups[:i], c.groups[i+1:]...)
+			break
+		}
+	}
+	c.removeFirewallRules(func(r *brain.FirewallRule) bool { return r.GroupID == group.ID })
+	return nil, nil
+}
@@ -0,0 +1,568 @@
+package fake
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/billing"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// addAdminRoutes adds the /admin routes, which all require cluster_admin.
+// Migrations in the fake cluster happen instantly, so nothing is ever seen to
+// be migrating.
+func (c *Cluster) addAdminRoutes(rt *router) {
+	admin := func(method, pattern string, handler handlerFunc) {
+		rt.handle(method, "/admin"+pattern, func(c *Cluster, req *request) (interface{}, error) {
+			if !req.auth.isClusterAdmin(c) {
+				return nil, forbidden("you need cluster_admin to do that")
+			}
+			return handler(c, req)
+		})
+	}
+	admin("GET", "/vlans", getVLANs)
+	admin("GET", "/vlans/:num", getVLAN)
+	admin("DELETE", "/vlans/:id", deleteVLAN)
+	admin("GET", "/ip_ranges", getIPRanges)
+	admin("GET", "/ip_ranges/:id", getIPRange)
+	admin("POST", "/ip_ranges", createIPRange)
+
+	admin("GET", "/heads", getHeads)
+	admin("GET", "/heads/:id", getHead)
+	admin("PUT", "/heads/:id", updateHead)
+	admin("POST", "/heads/:id/empty", emptyHead)
+	admin("GET", "/heads/:id/virtual_machines", getHeadVMs)
+	admin("GET", "/tails", getTails)
+	admin("GET", "/tails/:id", getTail)
+	admin("PUT", "/tails/:id", updateTail)
+	admin("GET", "/tails/:id/virtual_machines", getTailVMs)
+	admin("GET", "/tails/:id/discs", getTailDiscs)
+	admin("GET", "/storage_pools", getStoragePools)
+	admin("GET", "/storage_pools/:id", getStoragePool)
+	admin("PUT", "/storage_pools/:id", updateStoragePool)
+	admin("POST", "/storage_pools/:id/empty", emptyStoragePool)
+	admin("GET", "/storage_pools/:id/virtual_machines", getStoragePoolVMs)
+	admin("GET", "/storage_pools/:id/discs", getStoragePoolDiscs)
+
+	admin("GET", "/migrating_vms", getNoVMs)
+	admin("GET", "/migrating_discs", getNoDiscs)
+	admin("GET", "/stopped_eligible_vms", getStoppedEligibleVMs)
+	admin("GET", "/recent_vms", getRecentVMs)
+	admin("POST", "/discs/:id/migrate", migrateDisc)
+	admin("POST", "/discs/:id/regrade", regradeDisc)
+	admin("POST", "/discs/:id/cancel_migration", notMigrating)
+	admin("POST", "/vms/:id/migrate", migrateVM)
+	admin("PUT", "/vms/:id/migrate", notMigrating)
+	admin("POST", "/vms/:id/cancel_migration", notMigrating)
+	admin("GET", "/migration_jobs", getMigrationJobs)
+	admin("GET", "/migration_jobs/:id", getMigrationJob)
+	admin("GET", "/migration_jobs/:id/migrations", getMigrationJobMigrations)
+	admin("POST", "/migration_jobs", createMigrationJob)
+	admin("PUT", "/migration_jobs/:id", updateMigrationJob)
+
+	admin("POST", "/groups", adminCreateGroup)
+	admin("POST", "/users", adminCreateUser)
+}
+
+func (c *Cluster) renderVLAN(vlan *brain.VLAN) brain.VLAN {
+	out := *vlan
+	out.IPRanges = brain.IPRanges{}
+	for _, ipr := range c.ipRanges {
+		if ipr.VLANNum == vlan.Num {
+			out.IPRanges = append(out.IPRanges, *ipr)
+		}
+	}
+	return out
+}
+
+func getVLANs(c *Cluster, req *request) (interface{}, error) {
+	vlans := brain.VLANs{}
+	for _, vlan := range c.vlans {
+		vlans = append(vlans, c.renderVLAN(vlan))
+	}
+	return vlans, nil
+}
+
+func getVLAN(c *Cluster, req *request) (interface{}, error) {
+	num, _ := strconv.Atoi(req.param("num"))
+	if vlan := c.vlanByNum(num); vlan != nil {
+		return c.renderVLAN(vlan), nil
+	}
+	return nil, notFound("no such vlan %s", req.param("num"))
+}
+
+func deleteVLAN(c *Cluster, req *request) (interface{}, error) {
+	for i, vlan := range c.vlans {
+		if strconv.Itoa(vlan.ID) != req.param("id") {
+			continue
+		}
+		if len(c.renderVLAN(vlan).IPRanges) > 0 {
+			return nil, badRequest("vlan %d still has ip ranges", vlan.Num)
+		}
+		c.vlans = append(c.vlans[:i], c.vlans[i+1:]...)
+		return nil, nil
+	}
+	return nil, notFound("no such vlan %s", req.param("id"))
+}
+
+func getIPRanges(c *Cluster, req *request) (interface{}, error) {
+	ipRanges := brain.IPRanges{}
+	for _, ipr := range c.ipRanges {
+		if cidr := req.query("cidr"); cidr == "" || cidr == ipr.Spec {
+			ipRanges = append(ipRanges, *ipr)
+		}
+	}
+	return ipRanges, nil
+}
+
+func getIPRange(c *Cluster, req *request) (interface{}, error) {
+	for _, ipr := range c.ipRanges {
+		if strconv.Itoa(ipr.ID) == req.param("id") {
+			return *ipr, nil
+		}
+	}
+	return nil, notFound("no such ip range %s", req.param("id"))
+}
+
+func createIPRange(c *Cluster, req *request) (interface{}, error) {
+	body := struct {
+		IPRange string `json:"ip_range"`
+		VLANNum int    `json:"vlan_num"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	_, ipnet, err := net.ParseCIDR(body.IPRange)
+	if err != nil {
+		return nil, badRequest("%s isn't a valid ip range", body.IPRange)
+	}
+	found := false
+	for _, vlan := range c.vlans {
+		found = found || vlan.Num == body.VLANNum
+	}
+	if !found {
+		return nil, badRequest("no such vlan %d", body.VLANNum)
+	}
+	ones, bits := ipnet.Mask.Size()
+	ipr := &brain.IPRange{
+		ID:        c.nextID(),
+		Spec:      ipnet.String(),
+		VLANNum:   body.VLANNum,
+		Zones:     []string{},
+		Available: new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)),
+	}
+	c.ipRanges = append(c.ipRanges, ipr)
+	return nil, nil
+}
+
+func findHead(c *Cluster, idOrLabel string) (*brain.Head, error) {
+	for _, head := range c.heads {
+		if head.Label == idOrLabel || strconv.Itoa(head.ID) == idOrLabel {
+			return head, nil
+		}
+	}
+	return nil, notFound("no such head %s", idOrLabel)
+}
+
+func findTail(c *Cluster, idOrLabel string) (*brain.Tail, error) {
+	for _, tail := range c.tails {
+		if tail.Label == idOrLabel || strconv.Itoa(tail.ID) == idOrLabel {
+			return tail, nil
+		}
+	}
+	return nil, notFound("no such tail %s", idOrLabel)
+}
+
+func findStoragePool(c *Cluster, nameOrLabel string) (*brain.StoragePool, error) {
+	for _, pool := range c.storagePools {
+		if pool.Label == nameOrLabel || pool.Name == nameOrLabel {
+			return pool, nil
+		}
+	}
+	return nil, notFound("no such storage pool %s", nameOrLabel)
+}
+
+func getHeads(c *Cluster, req *request) (interface{}, error) {
+	heads := brain.Heads{}
+	for _, head := range c.heads {
+		heads = append(heads, *head)
+	}
+	return heads, nil
+}
+
+func getHead(c *Cluster, req *request) (interface{}, error) {
+	head, err := findHead(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return *head, nil
+}
+
+func updateHead(c *Cluster, req *request) (interface{}, error) {
+	head, err := findHead(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	return nil, patch(head, body, "label", "usage_strategy", "overcommit_ratio")
+}
+
+func emptyHead(c *Cluster, req *request) (interface{}, error) {
+	_, err := findHead(c, req.param("id"))
+	return nil, err
+}
+
+func getHeadVMs(c *Cluster, req *request) (interface{}, error) {
+	head, err := findHead(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return c.vmsWhere(func(vm *brain.VirtualMachine) bool {
+		return vm.Head == head.Label
+	}), nil
+}
+
+func getTails(c *Cluster, req *request) (interface{}, error) {
+	tails := brain.Tails{}
+	for _, tail := range c.tails {
+		tails = append(tails, *tail)
+	}
+	return tails, nil
+}
+
+func getTail(c *Cluster, req *request) (interface{}, error) {
+	tail, err := findTail(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return *tail, nil
+}
+
+func updateTail(c *Cluster, req *request) (interface{}, error) {
+	tail, err := findTail(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	return nil, patch(tail, body, "label", "usage_strategy", "overcommit_ratio")
+}
+
+func getTailVMs(c *Cluster, req *request) (interface{}, error) {
+	tail, err := findTail(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return c.vmsWithDiscsWhere(func(disc *brain.Disc) bool {
+		return contains(tail.StoragePools, disc.StoragePool)
+	}), nil
+}
+
+func getTailDiscs(c *Cluster, req *request) (interface{}, error) {
+	tail, err := findTail(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return c.discsWhere(func(disc *brain.Disc) bool {
+		return contains(tail.StoragePools, disc.StoragePool)
+	}), nil
+}
+
+func getStoragePools(c *Cluster, req *request) (interface{}, error) {
+	pools := brain.StoragePools{}
+	for _, pool := range c.storagePools {
+		pools = append(pools, *pool)
+	}
+	return pools, nil
+}
+
+func getStoragePool(c *Cluster, req *request) (interface{}, error) {
+	pool, err := findStoragePool(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return *pool, nil
+}
+
+func updateStoragePool(c *Cluster, req *request) (interface{}, error) {
+	pool, err := findStoragePool(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	return nil, patch(pool, body)
+}
+
+func emptyStoragePool(c *Cluster, req *request) (interface{}, error) {
+	_, err := findStoragePool(c, req.param("id"))
+	return nil, err
+}
+
+func getStoragePoolVMs(c *Cluster, req *request) (interface{}, error) {
+	pool, err := findStoragePool(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return c.vmsWithDiscsWhere(func(disc *brain.Disc) bool {
+		return disc.StoragePool == pool.Label
+	}), nil
+}
+
+func getStoragePoolDiscs(c *Cluster, req *request) (interface{}, error) {
+	pool, err := findStoragePool(c, req.param("id"))
+	if err != nil {
+		return nil, err
+	}
+	return c.discsWhere(func(disc *brain.Disc) bool {
+		return disc.StoragePool == pool.Label
+	}), nil
+}
+
+func (c *Cluster) vmsWhere(fn func(vm *brain.VirtualMachine) bool) brain.VirtualMachines {
+	vms := brain.VirtualMachines{}
+	for _, vm := range c.vms {
+		if fn(vm) {
+			vms = append(vms, c.renderVM(vm))
+		}
+	}
+	return vms
+}
+
+func (c *Cluster) vmsWithDiscsWhere(fn func(disc *brain.Disc) bool) brain.VirtualMachines {
+	return c.vmsWhere(func(vm *brain.VirtualMachine) bool {
+		for _, disc := range c.discs {
+			if disc.VirtualMachineID == vm.ID && fn(disc) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (c *Cluster) discsWhere(fn func(disc *brain.Disc) bool) brain.Discs {
+	discs := brain.Discs{}
+	for _, disc := range c.discs {
+		if fn(disc) {
+			discs = append(discs, c.renderDisc(disc))
+		}
+	}
+	return discs
+}
+
+func getNoVMs(c *Cluster, req *request) (interface{}, error) {
+	return brain.VirtualMachines{}, nil
+}
+
+func getNoDiscs(c *Cluster, req *request) (interface{}, error) {
+	return brain.Discs{}, nil
+}
+
+func notMigrating(c *Cluster, req *request) (interface{}, error) {
+	return nil, badRequest("%s isn't migrating", req.param("id"))
+}
+
+func getStoppedEligibleVMs(c *Cluster, req *request) (interface{}, error) {
+	return c.vmsWhere(func(vm *brain.VirtualMachine) bool {
+		return !vm.PowerOn && !vm.Deleted
+	}), nil
+}
+
+// getRecentVMs returns the ten most recently created servers
+func getRecentVMs(c *Cluster, req *request) (interface{}, error) {
+	vms := brain.VirtualMachines{}
+	for i := len(c.vms) - 1; i >= 0 && len(vms) < 10; i-- {
+		vms = append(vms, c.renderVM(c.vms[i]))
+	}
+	return vms, nil
+}
+
+func lookupDiscForAdmin(c *Cluster, req *request) (*brain.Disc, error) {
+	id, _ := strconv.Atoi(req.param("id"))
+	disc := c.discByID(id)
+	if disc == nil {
+		return nil, notFound("no such disc %s", req.param("id"))
+	}
+	return disc, nil
+}
+
+func migrateDisc(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDiscForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]string{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if spec := body["new_pool_spec"]; spec != "" {
+		pool, err := findStoragePool(c, spec)
+		if err != nil {
+			return nil, err
+		}
+		disc.StoragePool = pool.Label
+	}
+	return nil, nil
+}
+
+func regradeDisc(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDiscForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]string{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if !contains(c.Definitions.StorageGrades, body["new_grade"]) {
+		return nil, badRequest("no such storage grade %s", body["new_grade"])
+	}
+	disc.StorageGrade = body["new_grade"]
+	return nil, nil
+}
+
+func migrateVM(c *Cluster, req *request) (interface{}, error) {
+	id, _ := strconv.Atoi(req.param("id"))
+	vm := c.vmByID(id)
+	if vm == nil {
+		return nil, notFound("no such server %s", req.param("id"))
+	}
+	body := map[string]string{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if spec := body["new_head_spec"]; spec != "" {
+		head, err := findHead(c, spec)
+		if err != nil {
+			return nil, err
+		}
+		vm.Head = head.Label
+	}
+	return nil, nil
+}
+
+func getMigrationJobs(c *Cluster, req *request) (interface{}, error) {
+	jobs := brain.MigrationJobs{}
+	for _, job := range c.migrationJobs {
+		if req.query("unfinished") == "" || job.FinishedAt == "" {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func lookupMigrationJob(c *Cluster, req *request) (*brain.MigrationJob, error) {
+	for _, job := range c.migrationJobs {
+		if strconv.Itoa(job.ID) == req.param("id") {
+			return job, nil
+		}
+	}
+	return nil, notFound("no such migration job %s", req.param("id"))
+}
+
+func getMigrationJob(c *Cluster, req *request) (interface{}, error) {
+	job, err := lookupMigrationJob(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return *job, nil
+}
+
+func getMigrationJobMigrations(c *Cluster, req *request) (interface{}, error) {
+	job, err := lookupMigrationJob(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return job.Active, nil
+}
+
+// createMigrationJob creates a migration job, which is finished straight away.
+func createMigrationJob(c *Cluster, req *request) (interface{}, error) {
+	spec := brain.MigrationJobSpec{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	job := &brain.MigrationJob{
+		ID:         c.nextID(),
+		Args:       spec,
+		Priority:   spec.Options.Priority,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		StartedAt:  now,
+		FinishedAt: now,
+	}
+	for _, disc := range spec.Sources.Discs {
+		if id, err := strconv.Atoi(disc.String()); err == nil {
+			job.Status.Discs.Done = append(job.Status.Discs.Done, id)
+		}
+	}
+	c.migrationJobs = append(c.migrationJobs, job)
+	return *job, nil
+}
+
+func updateMigrationJob(c *Cluster, req *request) (interface{}, error) {
+	job, err := lookupMigrationJob(c, req)
+	if err != nil {
+		return nil, err
+	}
+	mod := brain.MigrationJobModification{}
+	if err := req.decode(&mod); err != nil {
+		return nil, err
+	}
+	if mod.Options.Priority != 0 {
+		job.Priority = mod.Options.Priority
+	}
+	job.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return nil, nil
+}
+
+func adminCreateGroup(c *Cluster, req *request) (interface{}, error) {
+	body := struct {
+		AccountSpec string `json:"account_spec"`
+		GroupName   string `json:"group_name"`
+		VLANNum     int    `json:"vlan_num"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	acc := c.findAccount(body.AccountSpec)
+	if acc == nil {
+		return nil, notFound("no such account %s", body.AccountSpec)
+	}
+	if body.GroupName == "" || c.findGroup(acc, body.GroupName) != nil {
+		return nil, badRequest("group name must be set and unique within the account")
+	}
+	if body.VLANNum != 0 && c.vlanByNum(body.VLANNum) == nil {
+		c.vlans = append(c.vlans, &brain.VLAN{ID: c.nextID(), Num: body.VLANNum, UsageType: "private"})
+	}
+	c.groups = append(c.groups, &brain.Group{ID: c.nextID(), AccountID: acc.ID, Name: body.GroupName, VlanNum: body.VLANNum})
+	return nil, nil
+}
+
+func adminCreateUser(c *Cluster, req *request) (interface{}, error) {
+	body := map[string]string{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if body["username"] == "" || c.findUser(body["username"]) != nil {
+		return nil, badRequest("username must be set and not already taken")
+	}
+	u := c.addUser(body["username"], "", billing.Person{})
+	if body["priv_spec"] != "" {
+		if _, err := c.grantPrivilege(brain.Privilege{Username: u.Username, Level: brain.PrivilegeLevel(body["priv_spec"])}); err != nil {
+			return nil, badRequest("%s", err)
+		}
+	}
+	return nil, nil
+}
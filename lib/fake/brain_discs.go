@@ -0,0 +1,289 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// lookupDisc finds the disc named in the path, which must be on a server the
+// request can administer.
+func lookupDisc(c *Cluster, req *request) (*brain.Disc, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	disc := c.findDisc(vm, req.param("disc"))
+	if disc == nil {
+		return nil, notFound("no such disc %s", req.param("disc"))
+	}
+	return disc, nil
+}
+
+// lookupDiscByID finds the disc with the ID in the path, which must be on a
+// server the request can administer.
+func lookupDiscByID(c *Cluster, req *request) (*brain.Disc, error) {
+	id, _ := strconv.Atoi(req.param("id"))
+	disc := c.discByID(id)
+	if disc == nil || !req.auth.canAdminVM(c, c.vmByID(disc.VirtualMachineID)) {
+		return nil, notFound("no such disc %s", req.param("id"))
+	}
+	return disc, nil
+}
+
+func createDisc(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	spec := brain.Disc{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	if err := c.seedFromBackup(req.auth, &spec); err != nil {
+		return nil, err
+	}
+	disc, err := c.addDisc(vm, spec)
+	if err != nil {
+		return nil, err
+	}
+	return c.renderDisc(disc), nil
+}
+
+// seedFromBackup checks that a new disc which is to be copied from a backup
+// can be. A disc with no size is made the same size as the backup.
+func (c *Cluster) seedFromBackup(auth *authInfo, disc *brain.Disc) error {
+	if disc.BackupID == 0 {
+		return nil
+	}
+	backup := c.backupByID(disc.BackupID)
+	var parent *brain.Disc
+	if backup != nil {
+		parent = c.discByID(backup.ParentDiscID)
+	}
+	if parent == nil || !auth.canSeeVM(c, c.vmByID(parent.VirtualMachineID)) {
+		return badRequest("no such backup %d", disc.BackupID)
+	}
+	if disc.Size == 0 {
+		disc.Size = backup.Size
+	} else if disc.Size < backup.Size {
+		return badRequest("the disc can't be smaller than backup %d, which is %dMiB", backup.ID, backup.Size)
+	}
+	// the brain copies the backup in the background - the fake has no
+	// contents to copy.
+	disc.BackupID = 0
+	return nil
+}
+
+func getDisc(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.renderDisc(disc), nil
+}
+
+func getDiscByID(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDiscByID(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.renderDisc(disc), nil
+}
+
+func updateDisc(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	// there's nowhere to keep the iops limit, but it's accepted so that
+	// setting it works.
+	delete(body, "iops_limit")
+
+	updated := *disc
+	if err := patch(&updated, body, "size", "storage_grade", "label", "virtual_machine_id"); err != nil {
+		return nil, err
+	}
+	if updated.Size < disc.Size {
+		return nil, badRequest("discs can't be shrunk")
+	}
+	if err := c.validateDisc(&updated); err != nil {
+		return nil, err
+	}
+	if updated.VirtualMachineID != disc.VirtualMachineID {
+		vm := c.vmByID(updated.VirtualMachineID)
+		if vm == nil || !req.auth.canAdminVM(c, vm) {
+			return nil, badRequest("no such server %d", updated.VirtualMachineID)
+		}
+		if vm.PowerOn || c.vmByID(disc.VirtualMachineID).PowerOn {
+			return nil, badRequest("both servers must be powered off to move a disc")
+		}
+		if c.findDisc(vm, updated.Label) != nil {
+			updated.Label = c.nextDiscLabel(vm)
+		}
+	} else if updated.Label != disc.Label && c.findDisc(c.vmByID(disc.VirtualMachineID), updated.Label) != nil {
+		return nil, badRequest("there's already a disc called %s", updated.Label)
+	}
+	*disc = updated
+	return nil, nil
+}
+
+func (c *Cluster) removeDisc(disc *brain.Disc) {
+	for i, d := range c.discs {
+		if d == disc {
+			c.discs = append(c.discs[:i], c.discs[i+1:]...)
+			break
+		}
+	}
+	c.removeBackupsOf(disc)
+}
+
+func (c *Cluster) removeBackupsOf(disc *brain.Disc) {
+	backups := c.backups[:0]
+	for _, backup := range c.backups {
+		if backup.ParentDiscID != disc.ID {
+			backups = append(backups, backup)
+		}
+	}
+	c.backups = backups
+}
+
+func deleteDisc(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	c.removeDisc(disc)
+	return nil, nil
+}
+
+func deleteDiscByID(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDiscByID(c, req)
+	if err != nil {
+		return nil, err
+	}
+	c.removeDisc(disc)
+	return nil, nil
+}
+
+func getBackups(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	backups := brain.Backups{}
+	for _, backup := range c.backups {
+		if backup.ParentDiscID == disc.ID {
+			backups = append(backups, *backup)
+		}
+	}
+	return backups, nil
+}
+
+// createBackup takes a backup of a disc. Backups are finished (i.e. moved to
+// cold storage) instantly.
+func createBackup(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	backup := &brain.Backup{
+		Disc: brain.Disc{
+			ID:           c.nextID(),
+			Label:        fmt.Sprintf("backup.%s.%d", time.Now().UTC().Format("2006-01-02T15:04:05Z"), c.lastID),
+			Size:         disc.Size,
+			StorageGrade: brain.ColdStorageGrade,
+			StoragePool:  disc.StoragePool,
+		},
+		ParentDiscID: disc.ID,
+		Manual:       true,
+	}
+	c.backups = append(c.backups, backup)
+	return *backup, nil
+}
+
+func lookupBackup(c *Cluster, req *request) (*brain.Backup, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	backup := c.findBackup(disc, req.param("backup"))
+	if backup == nil {
+		return nil, notFound("no such backup %s", req.param("backup"))
+	}
+	return backup, nil
+}
+
+func restoreBackup(c *Cluster, req *request) (interface{}, error) {
+	if _, err := lookupBackup(c, req); err != nil {
+		return nil, err
+	}
+	body := struct {
+		Restore bool `json:"restore"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if !body.Restore {
+		return nil, badRequest("the only thing a backup can be updated with is restore: true")
+	}
+	return nil, nil
+}
+
+func deleteBackup(c *Cluster, req *request) (interface{}, error) {
+	backup, err := lookupBackup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range c.backups {
+		if b == backup {
+			c.backups = append(c.backups[:i], c.backups[i+1:]...)
+			break
+		}
+	}
+	return nil, nil
+}
+
+func createBackupSchedule(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	sched := brain.BackupSchedule{}
+	if err := req.decode(&sched); err != nil {
+		return nil, err
+	}
+	if sched.Interval <= 0 {
+		return nil, badRequest("interval_seconds must be greater than 0")
+	}
+	if sched.StartDate == "" {
+		sched.StartDate = time.Now().UTC().Format("2006-01-02 15:04:05")
+	}
+	if sched.Capacity <= 0 {
+		sched.Capacity = 1
+	}
+	sched.ID = c.nextID()
+	disc.BackupSchedules = append(disc.BackupSchedules, sched)
+	return sched, nil
+}
+
+func deleteBackupSchedule(c *Cluster, req *request) (interface{}, error) {
+	disc, err := lookupDisc(c, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, sched := range disc.BackupSchedules {
+		if strconv.Itoa(sched.ID) == req.param("id") {
+			disc.BackupSchedules = append(disc.BackupSchedules[:i], disc.BackupSchedules[i+1:]...)
+			return nil, nil
+		}
+	}
+	return nil, notFound("no such backup schedule %s", req.param("id"))
+}
@@ -0,0 +1,122 @@
+package fake
+
+import (
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+func getGroupFirewallRules(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.firewallRulesWhere(func(r *brain.FirewallRule) bool { return r.GroupID == group.ID }), nil
+}
+
+func getVMFirewallRules(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVM(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.firewallRulesWhere(func(r *brain.FirewallRule) bool { return r.VirtualMachineID == vm.ID }), nil
+}
+
+func createGroupFirewallRule(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminGroup(c, group) {
+		return nil, forbidden("you need group_admin on %s to change its firewall", group.Name)
+	}
+	return c.addFirewallRule(req, brain.FirewallRule{GroupID: group.ID})
+}
+
+func createVMFirewallRule(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.addFirewallRule(req, brain.FirewallRule{VirtualMachineID: vm.ID})
+}
+
+// addFirewallRule decodes a rule from the request and adds it to the cluster,
+// attached to whatever target is attached to.
+func (c *Cluster) addFirewallRule(req *request, target brain.FirewallRule) (interface{}, error) {
+	spec := brain.FirewallRule{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	rule, err := spec.Validate()
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	rule.ID = c.nextID()
+	rule.GroupID = target.GroupID
+	rule.VirtualMachineID = target.VirtualMachineID
+	c.firewallRules = append(c.firewallRules, rule)
+	return *rule, nil
+}
+
+// lookupFirewallRule finds the firewall rule given in the URL, which must be
+// attached to a group or server that allowed(c, auth, rule) is true for.
+func lookupFirewallRule(c *Cluster, req *request, allowed func(c *Cluster, auth *authInfo, rule *brain.FirewallRule) bool) (int, *brain.FirewallRule, error) {
+	for i, rule := range c.firewallRules {
+		if strconv.Itoa(rule.ID) == req.param("id") && allowed(c, req.auth, rule) {
+			return i, rule, nil
+		}
+	}
+	return 0, nil, notFound("no such firewall rule %s", req.param("id"))
+}
+
+func canSeeFirewallRule(c *Cluster, auth *authInfo, rule *brain.FirewallRule) bool {
+	if rule.GroupID != 0 {
+		return auth.canSeeGroup(c, c.groupByID(rule.GroupID))
+	}
+	return auth.canSeeVM(c, c.vmByID(rule.VirtualMachineID))
+}
+
+func canAdminFirewallRule(c *Cluster, auth *authInfo, rule *brain.FirewallRule) bool {
+	if rule.GroupID != 0 {
+		return auth.canAdminGroup(c, c.groupByID(rule.GroupID))
+	}
+	return auth.canAdminVM(c, c.vmByID(rule.VirtualMachineID))
+}
+
+func getFirewallRule(c *Cluster, req *request) (interface{}, error) {
+	_, rule, err := lookupFirewallRule(c, req, canSeeFirewallRule)
+	if err != nil {
+		return nil, err
+	}
+	return *rule, nil
+}
+
+func deleteFirewallRule(c *Cluster, req *request) (interface{}, error) {
+	i, _, err := lookupFirewallRule(c, req, canAdminFirewallRule)
+	if err != nil {
+		return nil, err
+	}
+	c.firewallRules = append(c.firewallRules[:i], c.firewallRules[i+1:]...)
+	return nil, nil
+}
+
+func (c *Cluster) firewallRulesWhere(fn func(r *brain.FirewallRule) bool) brain.FirewallRules {
+	rules := brain.FirewallRules{}
+	for _, rule := range c.firewallRules {
+		if fn(rule) {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules
+}
+
+func (c *Cluster) removeFirewallRules(fn func(r *brain.FirewallRule) bool) {
+	rules := c.firewallRules[:0]
+	for _, rule := range c.firewallRules {
+		if !fn(rule) {
+			rules = append(rules, rule)
+		}
+	}
+	c.firewallRules = rules
+}
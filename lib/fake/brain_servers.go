@@ -0,0 +1,546 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// lookupVM finds the server named in the path, which the request must be
+// able to see.
+func lookupVM(c *Cluster, req *request) (*brain.VirtualMachine, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	vm := c.findVM(group, req.param("vm"))
+	if vm == nil || !req.auth.canSeeVM(c, vm) {
+		return nil, notFound("no such server %s", req.param("vm"))
+	}
+	return vm, nil
+}
+
+// lookupVMForAdmin is like lookupVM but also requires that the request can
+// administer the server.
+func lookupVMForAdmin(c *Cluster, req *request) (*brain.VirtualMachine, error) {
+	vm, err := lookupVM(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminVM(c, vm) {
+		return nil, forbidden("you need vm_admin on %s to do that", vm.Name)
+	}
+	return vm, nil
+}
+
+func (c *Cluster) validateDisc(disc *brain.Disc) error {
+	if disc.Size <= 0 {
+		return badRequest("disc size must be greater than 0")
+	}
+	if disc.StorageGrade == "" {
+		disc.StorageGrade = "sata"
+	}
+	if !contains(c.Definitions.StorageGrades, disc.StorageGrade) {
+		return badRequest("no such storage grade %s", disc.StorageGrade)
+	}
+	return nil
+}
+
+// nextDiscLabel returns the first disc-N label not used on vm.
+func (c *Cluster) nextDiscLabel(vm *brain.VirtualMachine) string {
+	for i := 1; ; i++ {
+		label := fmt.Sprintf("disc-%d", i)
+		if c.findDisc(vm, label) == nil {
+			return label
+		}
+	}
+}
+
+// validateImageInstall checks that image is for a distribution the cluster
+// has, and that its firstboot script isn't too long.
+func (c *Cluster) validateImageInstall(image brain.ImageInstall) error {
+	if !contains(c.Definitions.Distributions, image.Distribution) {
+		return badRequest("no such distribution %s", image.Distribution)
+	}
+	if len(image.FirstbootScript) > brain.FirstbootScriptMaxLength {
+		return badRequest("firstboot_script is too long (maximum is %d bytes)", brain.FirstbootScriptMaxLength)
+	}
+	return nil
+}
+
+// addDisc validates disc and attaches it to vm
+func (c *Cluster) addDisc(vm *brain.VirtualMachine, disc brain.Disc) (*brain.Disc, error) {
+	if err := c.validateDisc(&disc); err != nil {
+		return nil, err
+	}
+	if disc.Label == "" {
+		disc.Label = c.nextDiscLabel(vm)
+	} else if c.findDisc(vm, disc.Label) != nil {
+		return nil, badRequest("%s already has a disc called %s", vm.Name, disc.Label)
+	}
+	disc.ID = c.nextID()
+	disc.VirtualMachineID = vm.ID
+	disc.StoragePool = c.storagePools[0].Label
+	disc.BackupSchedules = nil
+	d := &disc
+	c.discs = append(c.discs, d)
+	return d, nil
+}
+
+func createVM(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminGroup(c, group) {
+		return nil, forbidden("you need group_admin on %s to create servers", group.Name)
+	}
+	spec := brain.VirtualMachineSpec{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+
+	vm := spec.VirtualMachine
+	if vm.Name == "" {
+		return nil, badRequest("name can't be blank")
+	}
+	if c.findVM(group, vm.Name) != nil {
+		return nil, badRequest("a server called %s already exists in %s", vm.Name, group.Name)
+	}
+	if vm.Cores <= 0 {
+		vm.Cores = 1
+	}
+	if vm.Memory <= 0 {
+		vm.Memory = 1024
+	}
+	if vm.ZoneName == "" {
+		vm.ZoneName = c.Definitions.ZoneNames[0]
+	} else if !contains(c.Definitions.ZoneNames, vm.ZoneName) {
+		return nil, badRequest("no such zone %s", vm.ZoneName)
+	}
+	if vm.HardwareProfile == "" {
+		vm.HardwareProfile = c.Definitions.HardwareProfiles[0]
+	} else if !contains(c.Definitions.HardwareProfiles, vm.HardwareProfile) {
+		return nil, badRequest("no such hardware profile %s", vm.HardwareProfile)
+	}
+	labels := map[string]bool{}
+	for i, disc := range spec.Discs {
+		if err := c.seedFromBackup(req.auth, &spec.Discs[i]); err != nil {
+			return nil, err
+		}
+		if err := c.validateDisc(&spec.Discs[i]); err != nil {
+			return nil, err
+		}
+		if disc.Label != "" && labels[disc.Label] {
+			return nil, badRequest("there's more than one disc called %s", disc.Label)
+		}
+		labels[disc.Label] = true
+	}
+	if spec.Reimage != nil {
+		if err := c.validateImageInstall(*spec.Reimage); err != nil {
+			return nil, err
+		}
+		vm.LastImagedWith = spec.Reimage.Distribution
+	}
+
+	vm.ID = c.nextID()
+	vm.GroupID = group.ID
+	vm.Head = c.heads[0].Label
+	vm.Deleted = false
+	vm.Discs = nil
+	vm.NetworkInterfaces = nil
+	c.vms = append(c.vms, &vm)
+
+	for _, disc := range spec.Discs {
+		if _, err := c.addDisc(&vm, disc); err != nil {
+			return nil, err
+		}
+	}
+
+	ipv4, ipv6 := c.nextIPs()
+	if spec.IPs != nil {
+		if ip := net.ParseIP(spec.IPs.IPv4); ip != nil {
+			ipv4 = ip
+		}
+		if ip := net.ParseIP(spec.IPs.IPv6); ip != nil {
+			ipv6 = ip
+		}
+	}
+	c.nics = append(c.nics, &brain.NetworkInterface{
+		ID:               c.nextID(),
+		Label:            "eth0",
+		Mac:              fmt.Sprintf("fe:ff:00:00:%02x:%02x", byte(vm.ID>>8), byte(vm.ID)),
+		VlanNum:          c.vlans[0].Num,
+		IPs:              brain.IPs{ipv4, ipv6},
+		ExtraIPs:         map[string]net.IP{},
+		VirtualMachineID: vm.ID,
+	})
+	return c.renderVM(&vm), nil
+}
+
+func getVM(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVM(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.renderVM(vm), nil
+}
+
+func getVMByID(c *Cluster, req *request) (interface{}, error) {
+	for _, vm := range c.vms {
+		if fmt.Sprint(vm.ID) == req.param("vm") && req.auth.canSeeVM(c, vm) {
+			return c.renderVM(vm), nil
+		}
+	}
+	return nil, notFound("no such server %s", req.param("vm"))
+}
+
+// vmFields are the fields of a server which can be changed with PUT
+var vmFields = []string{"power_on", "autoreboot_on", "deleted", "hardware_profile", "hardware_profile_locked", "memory", "cores", "cdrom_url", "name", "group_id", "appliance"}
+
+func updateVM(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	// appliance isn't a real field of the server - booting into an appliance
+	// just means it gets powered on.
+	delete(body, "appliance")
+
+	updated := *vm
+	if err := patch(&updated, body, vmFields...); err != nil {
+		return nil, err
+	}
+	if updated.Memory <= 0 || updated.Cores <= 0 {
+		return nil, badRequest("memory and cores must be greater than 0")
+	}
+	if updated.HardwareProfile != vm.HardwareProfile {
+		if vm.HardwareProfileLocked && updated.HardwareProfileLocked {
+			return nil, badRequest("the hardware profile of %s is locked", vm.Name)
+		}
+		if !contains(c.Definitions.HardwareProfiles, updated.HardwareProfile) {
+			return nil, badRequest("no such hardware profile %s", updated.HardwareProfile)
+		}
+	}
+	if updated.GroupID != vm.GroupID {
+		group := c.groupByID(updated.GroupID)
+		if group == nil || !req.auth.canAdminGroup(c, group) {
+			return nil, badRequest("no such group %d", updated.GroupID)
+		}
+	}
+	if updated.Name != vm.Name || updated.GroupID != vm.GroupID {
+		if updated.Name == "" {
+			return nil, badRequest("name can't be blank")
+		}
+		if c.findVM(c.groupByID(updated.GroupID), updated.Name) != nil {
+			return nil, badRequest("a server called %s already exists there", updated.Name)
+		}
+	}
+	*vm = updated
+	return nil, nil
+}
+
+func deleteVM(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if req.query("purge") != "true" {
+		vm.Deleted = true
+		vm.PowerOn = false
+		return nil, nil
+	}
+	for i, v := range c.vms {
+		if v == vm {
+			c.vms = append(c.vms[:i], c.vms[i+1:]...)
+			break
+		}
+	}
+	discs := c.discs[:0]
+	for _, disc := range c.discs {
+		if disc.VirtualMachineID == vm.ID {
+			c.removeBackupsOf(disc)
+		} else {
+			discs = append(discs, disc)
+		}
+	}
+	c.discs = discs
+	nics := c.nics[:0]
+	for _, nic := range c.nics {
+		if nic.VirtualMachineID != vm.ID {
+			nics = append(nics, nic)
+		}
+	}
+	c.nics = nics
+	privs := c.privileges[:0]
+	for _, p := range c.privileges {
+		if p.VirtualMachineID != vm.ID {
+			privs = append(privs, p)
+		}
+	}
+	c.privileges = privs
+	c.removeFirewallRules(func(r *brain.FirewallRule) bool { return r.VirtualMachineID == vm.ID })
+	return nil, nil
+}
+
+func signalVM(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Signal string `json:"signal"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	switch body.Signal {
+	case "powerdown":
+		vm.PowerOn = false
+	case "reset":
+		if !vm.PowerOn {
+			return nil, badRequest("%s is not powered on", vm.Name)
+		}
+	default:
+		return nil, badRequest("unknown signal %s", body.Signal)
+	}
+	return nil, nil
+}
+
+func reimageVM(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	image := brain.ImageInstall{}
+	if err := req.decode(&image); err != nil {
+		return nil, err
+	}
+	if err := c.validateImageInstall(image); err != nil {
+		return nil, err
+	}
+	vm.LastImagedWith = image.Distribution
+	return nil, nil
+}
+
+func createIPs(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	nic := c.findNIC(vm, req.param("nic"))
+	if nic == nil {
+		return nil, notFound("no such network interface %s", req.param("nic"))
+	}
+	spec := brain.IPCreateRequest{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	if spec.Addresses <= 0 {
+		spec.Addresses = 1
+	}
+	if spec.Family != "ipv4" && spec.Family != "ipv6" {
+		return nil, badRequest("family must be ipv4 or ipv6")
+	}
+	if spec.Reason == "" {
+		return nil, badRequest("reason can't be blank")
+	}
+	target := nic.IPs[0]
+	if spec.Family == "ipv6" {
+		target = nic.IPs[1]
+	}
+	spec.IPs = brain.IPs{}
+	for i := 0; i < spec.Addresses; i++ {
+		ipv4, ipv6 := c.nextIPs()
+		ip := ipv4
+		if spec.Family == "ipv6" {
+			ip = ipv6
+		}
+		spec.IPs = append(spec.IPs, ip)
+		nic.ExtraIPs[ip.String()] = target
+	}
+	return spec, nil
+}
+
+func createNIC(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		VLANGroupID int `json:"vlan_group_id"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	group := c.groupByID(body.VLANGroupID)
+	if group == nil || group.AccountID != c.groupByID(vm.GroupID).AccountID {
+		return nil, badRequest("vlan_group_id must be a group in the same account as %s", vm.Name)
+	}
+	if group.VlanNum == 0 {
+		return nil, badRequest("%s doesn't have a private VLAN", group.Name)
+	}
+	count := 0
+	for _, nic := range c.nics {
+		if nic.VirtualMachineID == vm.ID {
+			if nic.VlanNum == group.VlanNum {
+				return nil, badRequest("%s is already attached to %s's VLAN", vm.Name, group.Name)
+			}
+			count++
+		}
+	}
+	nic := &brain.NetworkInterface{
+		ID:               c.nextID(),
+		Label:            fmt.Sprintf("eth%d", count),
+		VlanNum:          group.VlanNum,
+		IPs:              brain.IPs{},
+		ExtraIPs:         map[string]net.IP{},
+		VirtualMachineID: vm.ID,
+	}
+	nic.Mac = fmt.Sprintf("fe:ff:00:%02x:%02x:%02x", byte(nic.ID>>16), byte(nic.ID>>8), byte(nic.ID))
+	c.nics = append(c.nics, nic)
+	return *nic, nil
+}
+
+func deleteNIC(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	for i, nic := range c.nics {
+		if nic.VirtualMachineID != vm.ID || strconv.Itoa(nic.ID) != req.param("nic") {
+			continue
+		}
+		if len(nic.IPs) > 0 || len(nic.ExtraIPs) > 0 {
+			return nil, badRequest("network interfaces with IPs can't be removed")
+		}
+		c.nics = append(c.nics[:i], c.nics[i+1:]...)
+		return nil, nil
+	}
+	return nil, notFound("no such network interface %s", req.param("nic"))
+}
+
+func swapIPs(c *Cluster, req *request) (interface{}, error) {
+	body := struct {
+		VM1               int  `json:"virtual_machine_1_id"`
+		VM2               int  `json:"virtual_machine_2_id"`
+		MoveAdditionalIPs bool `json:"move_additional_ips"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	var nics [2]*brain.NetworkInterface
+	for i, id := range []int{body.VM1, body.VM2} {
+		vm := c.vmByID(id)
+		if vm == nil || !req.auth.canAdminVM(c, vm) {
+			return nil, notFound("no such server %d", id)
+		}
+		for _, nic := range c.nics {
+			if nic.VirtualMachineID == id {
+				nics[i] = nic
+				break
+			}
+		}
+	}
+	nics[0].IPs, nics[1].IPs = nics[1].IPs, nics[0].IPs
+	if body.MoveAdditionalIPs {
+		nics[0].ExtraIPs, nics[1].ExtraIPs = nics[1].ExtraIPs, nics[0].ExtraIPs
+	}
+	return nil, nil
+}
+
+// lookupIP finds the IP given in the URL, which must be on a server that
+// allowed(auth, c, server) is true for.
+func lookupIP(c *Cluster, req *request, allowed func(*authInfo, *Cluster, *brain.VirtualMachine) bool) (net.IP, error) {
+	ip := net.ParseIP(req.param("ip"))
+	if ip == nil {
+		return nil, badRequest("%s isn't an IP address", req.param("ip"))
+	}
+	vm := c.ipVM(ip)
+	if vm == nil || !allowed(req.auth, c, vm) {
+		return nil, notFound("no such IP %s", ip)
+	}
+	return ip, nil
+}
+
+func getIP(c *Cluster, req *request) (interface{}, error) {
+	ip, err := lookupIP(c, req, (*authInfo).canSeeVM)
+	if err != nil {
+		return nil, err
+	}
+	return brain.IP{IP: ip, RDns: c.rdns[ip.String()]}, nil
+}
+
+func updateIP(c *Cluster, req *request) (interface{}, error) {
+	ip, err := lookupIP(c, req, (*authInfo).canAdminVM)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		RDns     *string `json:"rdns"`
+		RoutedTo *string `json:"routed_to"`
+	}{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	if body.RDns == nil && body.RoutedTo == nil {
+		return nil, badRequest("only rdns and routed_to can be changed")
+	}
+	if body.RoutedTo != nil {
+		err = c.rerouteExtraIP(req.auth, ip, *body.RoutedTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if body.RDns != nil {
+		c.rdns[ip.String()] = *body.RDns
+	}
+	return nil, nil
+}
+
+// rerouteExtraIP moves the extra IP ip so that it's routed to routedTo, which
+// must be one of the IPs of a server that auth can administer.
+func (c *Cluster) rerouteExtraIP(auth *authInfo, ip net.IP, routedTo string) error {
+	from := c.extraIPNIC(ip)
+	if from == nil {
+		return badRequest("%s isn't an extra IP, so can't be rerouted", ip)
+	}
+	target := net.ParseIP(routedTo)
+	if target == nil {
+		return badRequest("routed_to %q isn't an IP address", routedTo)
+	}
+	if (target.To4() == nil) != (ip.To4() == nil) {
+		return badRequest("%s and %s aren't the same IP version", ip, target)
+	}
+	to := c.ipNIC(target)
+	if to == nil {
+		return badRequest("routed_to %s isn't the IP of a server", target)
+	}
+	if vm := c.vmByID(to.VirtualMachineID); vm == nil || !auth.canAdminVM(c, vm) {
+		return badRequest("routed_to %s isn't the IP of a server", target)
+	}
+	delete(from.ExtraIPs, ip.String())
+	to.ExtraIPs[ip.String()] = target
+	return nil
+}
+
+func deleteIP(c *Cluster, req *request) (interface{}, error) {
+	ip, err := lookupIP(c, req, (*authInfo).canAdminVM)
+	if err != nil {
+		return nil, err
+	}
+	nic := c.extraIPNIC(ip)
+	if nic == nil {
+		return nil, badRequest("%s isn't an extra IP - servers' own IPs can't be removed", ip)
+	}
+	delete(nic.ExtraIPs, ip.String())
+	delete(c.rdns, ip.String())
+	return nil, nil
+}
@@ -0,0 +1,323 @@
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// privilegesWhere returns copies of all the privileges matching fn
+func (c *Cluster) privilegesWhere(fn func(p *brain.Privilege) bool) brain.Privileges {
+	privs := brain.Privileges{}
+	for _, p := range c.privileges {
+		if fn(p) {
+			privs = append(privs, *p)
+		}
+	}
+	return privs
+}
+
+// lookupUser finds the user named in the path, who must be the user the
+// request is authenticated as unless it has cluster_admin.
+func lookupUser(c *Cluster, req *request) (*user, error) {
+	u := c.findUser(req.param("user"))
+	if u == nil || u != req.auth.user && !req.auth.isClusterAdmin(c) {
+		return nil, notFound("no such user %s", req.param("user"))
+	}
+	return u, nil
+}
+
+func getPrivileges(c *Cluster, req *request) (interface{}, error) {
+	if req.auth.isClusterAdmin(c) {
+		return c.privilegesWhere(func(p *brain.Privilege) bool { return true }), nil
+	}
+	return c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.Username == req.auth.user.Username
+	}), nil
+}
+
+func getUserPrivileges(c *Cluster, req *request) (interface{}, error) {
+	u, err := lookupUser(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.Username == u.Username
+	}), nil
+}
+
+// canGrant returns true if the request is allowed to grant or revoke priv
+func canGrant(c *Cluster, req *request, priv brain.Privilege) bool {
+	switch priv.TargetType() {
+	case brain.PrivilegeTargetTypeAccount:
+		acc := c.accountByID(priv.AccountID)
+		return acc != nil && req.auth.canAdminAccount(c, acc)
+	case brain.PrivilegeTargetTypeGroup:
+		group := c.groupByID(priv.GroupID)
+		return group != nil && req.auth.canAdminGroup(c, group)
+	case brain.PrivilegeTargetTypeVM:
+		vm := c.vmByID(priv.VirtualMachineID)
+		return vm != nil && req.auth.canAdminVM(c, vm)
+	}
+	return req.auth.isClusterAdmin(c)
+}
+
+func grantPrivilege(c *Cluster, req *request) (interface{}, error) {
+	priv := brain.Privilege{}
+	if err := req.decode(&priv); err != nil {
+		return nil, err
+	}
+	priv.Username = req.param("user")
+	switch priv.Level {
+	case brain.ClusterAdminPrivilege, brain.AccountAdminPrivilege, brain.GroupAdminPrivilege, brain.VMAdminPrivilege, brain.VMConsolePrivilege:
+	default:
+		return nil, badRequest("no such privilege level %s", priv.Level)
+	}
+	if !canGrant(c, req, priv) {
+		return nil, forbidden("you can't grant %s", priv)
+	}
+	if priv.APIKeyID != 0 {
+		key := c.apiKeyByID(priv.APIKeyID)
+		if key == nil || c.findUser(priv.Username) == nil || key.UserID != c.findUser(priv.Username).ID {
+			return nil, badRequest("api key %d doesn't belong to %s", priv.APIKeyID, priv.Username)
+		}
+	}
+	p, err := c.grantPrivilege(priv)
+	if err != nil {
+		return nil, badRequest("%s", err)
+	}
+	return *p, nil
+}
+
+func revokePrivilege(c *Cluster, req *request) (interface{}, error) {
+	for i, p := range c.privileges {
+		if strconv.Itoa(p.ID) != req.param("id") {
+			continue
+		}
+		if !canGrant(c, req, *p) {
+			return nil, forbidden("you can't revoke %s", p)
+		}
+		c.privileges = append(c.privileges[:i], c.privileges[i+1:]...)
+		return nil, nil
+	}
+	return nil, notFound("no such privilege %s", req.param("id"))
+}
+
+func getAccountPrivileges(c *Cluster, req *request) (interface{}, error) {
+	acc, err := lookupAccount(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminAccount(c, acc) {
+		return nil, forbidden("you need account_admin on %s to see its privileges", acc.Name)
+	}
+	return c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.AccountID == acc.ID
+	}), nil
+}
+
+func getGroupPrivileges(c *Cluster, req *request) (interface{}, error) {
+	group, err := lookupGroup(c, req)
+	if err != nil {
+		return nil, err
+	}
+	if !req.auth.canAdminGroup(c, group) {
+		return nil, forbidden("you need group_admin on %s to see its privileges", group.Name)
+	}
+	return c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.GroupID == group.ID
+	}), nil
+}
+
+func getVMPrivileges(c *Cluster, req *request) (interface{}, error) {
+	vm, err := lookupVMForAdmin(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.VirtualMachineID == vm.ID
+	}), nil
+}
+
+func getUser(c *Cluster, req *request) (interface{}, error) {
+	u, err := lookupUser(c, req)
+	if err != nil {
+		return nil, err
+	}
+	return u.User, nil
+}
+
+func updateUser(c *Cluster, req *request) (interface{}, error) {
+	u, err := lookupUser(c, req)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]json.RawMessage{}
+	if err := req.decode(&body); err != nil {
+		return nil, err
+	}
+	// clients send the whole user back, but only the keys can be changed.
+	delete(body, "id")
+	delete(body, "username")
+	delete(body, "email")
+	updated := u.User
+	updated.AuthorizedKeys = nil
+	if err := patch(&updated, body, "authorized_keys"); err != nil {
+		return nil, err
+	}
+	keys := brain.Keys{}
+	for _, key := range updated.AuthorizedKeys {
+		if key.Key != "" {
+			keys = append(keys, key)
+		}
+	}
+	updated.AuthorizedKeys = keys
+	u.User = updated
+	return nil, nil
+}
+
+// renderAPIKey returns a copy of the key with its username and privileges
+// filled in, but not the key itself - that's only returned when the key is
+// created.
+func (c *Cluster) renderAPIKey(key *brain.APIKey) brain.APIKey {
+	out := *key
+	out.APIKey = ""
+	for _, u := range c.users {
+		if u.ID == key.UserID {
+			out.Username = u.Username
+		}
+	}
+	out.Privileges = c.privilegesWhere(func(p *brain.Privilege) bool {
+		return p.APIKeyID == key.ID
+	})
+	return out
+}
+
+func getAPIKeys(c *Cluster, req *request) (interface{}, error) {
+	if req.auth.apiKey != nil {
+		// API keys can only see themselves
+		return brain.APIKeys{c.renderAPIKey(req.auth.apiKey)}, nil
+	}
+	admin := req.auth.isClusterAdmin(c)
+	keys := brain.APIKeys{}
+	for _, key := range c.apiKeys {
+		if admin || key.UserID == req.auth.user.ID {
+			keys = append(keys, c.renderAPIKey(key))
+		}
+	}
+	return keys, nil
+}
+
+func createAPIKey(c *Cluster, req *request) (interface{}, error) {
+	spec := brain.APIKey{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	if spec.UserID != req.auth.user.ID && !req.auth.isClusterAdmin(c) {
+		return nil, forbidden("you can only create api keys for yourself")
+	}
+	if len(spec.Privileges) > 0 {
+		return nil, badRequest("privileges can't be set when creating an api key")
+	}
+	if spec.Label == "" {
+		return nil, badRequest("label can't be blank")
+	}
+	for _, key := range c.apiKeys {
+		if key.UserID == spec.UserID && key.Label == spec.Label {
+			return nil, badRequest("there's already an api key called %s", spec.Label)
+		}
+	}
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	spec.ID = c.nextID()
+	spec.APIKey = hex.EncodeToString(secret)
+	key := &spec
+	c.apiKeys = append(c.apiKeys, key)
+
+	out := c.renderAPIKey(key)
+	out.APIKey = key.APIKey
+	return out, nil
+}
+
+func deleteAPIKey(c *Cluster, req *request) (interface{}, error) {
+	for i, key := range c.apiKeys {
+		if strconv.Itoa(key.ID) != req.param("id") {
+			continue
+		}
+		if key.UserID != req.auth.user.ID && !req.auth.isClusterAdmin(c) {
+			break
+		}
+		c.apiKeys = append(c.apiKeys[:i], c.apiKeys[i+1:]...)
+		privs := c.privileges[:0]
+		for _, p := range c.privileges {
+			if p.APIKeyID != key.ID {
+				privs = append(privs, p)
+			}
+		}
+		c.privileges = privs
+		return nil, nil
+	}
+	return nil, notFound("no such api key %s", req.param("id"))
+}
+
+func updateAPIKey(c *Cluster, req *request) (interface{}, error) {
+	for _, key := range c.apiKeys {
+		if strconv.Itoa(key.ID) != req.param("id") {
+			continue
+		}
+		if key.UserID != req.auth.user.ID && !req.auth.isClusterAdmin(c) {
+			break
+		}
+		body := map[string]*string{}
+		if err := req.decode(&body); err != nil {
+			return nil, err
+		}
+		if label, ok := body["label"]; ok {
+			if label == nil || *label == "" {
+				return nil, badRequest("label can't be blank")
+			}
+			key.Label = *label
+		}
+		if expiresAt, ok := body["expires_at"]; ok {
+			key.ExpiresAt = ""
+			if expiresAt != nil {
+				key.ExpiresAt = *expiresAt
+			}
+		}
+		return c.renderAPIKey(key), nil
+	}
+	return nil, notFound("no such api key %s", req.param("id"))
+}
+
+func getVMDefaults(c *Cluster, req *request) (interface{}, error) {
+	vmds := brain.VirtualMachineDefaults{}
+	for _, vmd := range c.vmDefaults {
+		acc := c.accountByID(vmd.AccountID)
+		if vmd.Public || (acc != nil && req.auth.canSeeAccount(c, acc)) {
+			vmds = append(vmds, *vmd)
+		}
+	}
+	return vmds, nil
+}
+
+func createVMDefault(c *Cluster, req *request) (interface{}, error) {
+	spec := brain.VirtualMachineDefault{}
+	if err := req.decode(&spec); err != nil {
+		return nil, err
+	}
+	acc := c.accountByID(spec.AccountID)
+	if acc == nil || !req.auth.canAdminAccount(c, acc) {
+		return nil, forbidden("you need account_admin on the account to create vm defaults")
+	}
+	if spec.Name == "" {
+		return nil, badRequest("name can't be blank")
+	}
+	spec.ID = c.nextID()
+	c.vmDefaults = append(c.vmDefaults, &spec)
+	return spec, nil
+}
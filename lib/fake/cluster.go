@@ -0,0 +1,471 @@
+package fake
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/billing"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// user is a brain user along with the things only auth and billing know
+// about them.
+type user struct {
+	brain.User
+	Password string
+	Person   billing.Person
+}
+
+// Cluster holds all the state of a fake Bytemark cluster. All its methods are
+// safe to call from multiple goroutines, including while its handlers are
+// serving requests.
+type Cluster struct {
+	mu     sync.Mutex
+	lastID int
+	lastIP int
+
+	// Definitions are returned by the brain's /definitions endpoint, and
+	// used to validate distributions, storage grades, zones and hardware
+	// profiles of new servers and discs.
+	Definitions lib.Definitions
+
+	users      []*user
+	sessions   map[string]string
+	accounts   []*brain.Account
+	groups     []*brain.Group
+	vms        []*brain.VirtualMachine
+	nics       []*brain.NetworkInterface
+	discs      []*brain.Disc
+	backups    []*brain.Backup
+	privileges []*brain.Privilege
+	apiKeys    []*brain.APIKey
+	vmDefaults []*brain.VirtualMachineDefault
+	// firewallRules are attached to either a group or a server
+	firewallRules []*brain.FirewallRule
+	// rdns is the reverse DNS of each IP which has had it set, by IP
+	rdns map[string]string
+
+	heads         []*brain.Head
+	tails         []*brain.Tail
+	storagePools  []*brain.StoragePool
+	vlans         []*brain.VLAN
+	ipRanges      []*brain.IPRange
+	migrationJobs []*brain.MigrationJob
+
+	billingAccounts    []*billing.Account
+	billingDefinitions []*billing.Definition
+	assents            []billing.Assent
+	cardReferences     map[string]string
+}
+
+// New creates a Cluster with a head, tail, storage pool and VLAN, a
+// sensible set of definitions and no users or accounts. Use AddUser and
+// AddAccount to set it up for testing.
+func New() *Cluster {
+	c := &Cluster{
+		Definitions: lib.Definitions{
+			Distributions:            []string{"stretch", "buster", "centos7", "none"},
+			DistributionDescriptions: map[string]string{"stretch": "Debian 9", "buster": "Debian 10", "centos7": "CentOS 7", "none": "No image"},
+			StorageGrades:            []string{"sata", "archive", "iceberg"},
+			StorageGradeDescriptions: map[string]string{"sata": "Standard SSD storage", "archive": "Cheap bulk storage", "iceberg": "Backup storage"},
+			ZoneNames:                []string{"york", "manchester"},
+			HardwareProfiles:         []string{"virtio2018", "virtio2013", "compatibility2013"},
+			Keymaps:                  []string{"en-gb", "en-us"},
+			Sendkeys:                 []string{"ctrl+alt+delete"},
+		},
+		sessions:       map[string]string{},
+		cardReferences: map[string]string{},
+		rdns:           map[string]string{},
+	}
+	c.heads = []*brain.Head{{ID: c.nextID(), Label: "head1", ZoneName: "york", IsOnline: true, TotalMemory: 131072, FreeMemory: 131072}}
+	c.tails = []*brain.Tail{{ID: c.nextID(), Label: "tail1", ZoneName: "york", IsOnline: true, StoragePools: []string{"pool1"}}}
+	c.storagePools = []*brain.StoragePool{{Name: "pool1", Label: "pool1", ZoneName: "york", StorageGrade: "sata", Size: 10485760, FreeSpace: 10485760}}
+	c.vlans = []*brain.VLAN{{ID: c.nextID(), Num: 1, UsageType: "public"}}
+	c.ipRanges = []*brain.IPRange{{ID: c.nextID(), Spec: "10.0.0.0/8", VLANNum: 1, Zones: []string{"york", "manchester"}, Available: big.NewInt(16777216)}}
+	c.billingDefinitions = []*billing.Definition{{ID: c.nextID(), Name: "trial_days", Value: "14", UpdateGroupReq: "staff"}}
+	return c
+}
+
+// nextID returns an ID not used by anything else in the cluster. Every object
+// gets a unique ID, which makes it harder for tests to pass by coincidence.
+func (c *Cluster) nextID() int {
+	c.lastID++
+	return c.lastID
+}
+
+// nextIPs allocates a new IPv4 and IPv6 address.
+func (c *Cluster) nextIPs() (ipv4 net.IP, ipv6 net.IP) {
+	c.lastIP++
+	ipv4 = net.IPv4(10, byte(c.lastIP>>16), byte(c.lastIP>>8), byte(c.lastIP)).To4()
+	ipv6 = net.ParseIP(fmt.Sprintf("2001:db8::%x", c.lastIP))
+	return
+}
+
+// AddUser creates a user who can log in with the given password.
+func (c *Cluster) AddUser(username, password string) brain.User {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.addUser(username, password, billing.Person{})
+	return u.User
+}
+
+func (c *Cluster) addUser(username, password string, person billing.Person) *user {
+	u := &user{
+		User: brain.User{
+			ID:       c.nextID(),
+			Username: username,
+			Email:    username + "@example.com",
+		},
+		Password: password,
+		Person:   person,
+	}
+	if person.Email != "" {
+		u.Email = person.Email
+	}
+	u.Person.ID = u.ID
+	u.Person.Username = username
+	u.Person.Email = u.Email
+	u.Person.Password = ""
+	c.users = append(c.users, u)
+	return u
+}
+
+// AddAccount creates an account owned by the named user, with a 'default'
+// group in it. The owner gets account_admin on it, and a billing account is
+// created for it - so the first account added for a user becomes their
+// default account.
+func (c *Cluster) AddAccount(name, owner string) (brain.Account, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	acc, err := c.addAccount(name, owner)
+	if err != nil {
+		return brain.Account{}, err
+	}
+	return c.renderAccount(acc), nil
+}
+
+func (c *Cluster) addAccount(name, owner string) (*brain.Account, error) {
+	u := c.findUser(owner)
+	if u == nil {
+		return nil, fmt.Errorf("no such user %s", owner)
+	}
+	if c.findAccount(name) != nil {
+		return nil, fmt.Errorf("account %s already exists", name)
+	}
+	acc := &brain.Account{ID: c.nextID(), Name: name}
+	c.accounts = append(c.accounts, acc)
+	c.groups = append(c.groups, &brain.Group{ID: c.nextID(), AccountID: acc.ID, Name: "default"})
+	c.privileges = append(c.privileges, &brain.Privilege{
+		ID:          c.nextID(),
+		Username:    owner,
+		Level:       brain.AccountAdminPrivilege,
+		AccountID:   acc.ID,
+		AccountName: acc.Name,
+	})
+	c.billingAccounts = append(c.billingAccounts, &billing.Account{
+		ID:                 c.nextID(),
+		Name:               name,
+		Owner:              u.Person,
+		OwnerID:            u.ID,
+		TechnicalContact:   u.Person,
+		TechnicalContactID: u.ID,
+		PaymentMethod:      "Credit Card",
+	})
+	return acc, nil
+}
+
+// GrantPrivilege gives a privilege to a user. Only the level, the user and
+// the name of the target need be filled in - IDs are looked up from the names.
+func (c *Cluster) GrantPrivilege(priv brain.Privilege) (brain.Privilege, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if priv.AccountID == 0 && priv.AccountName != "" {
+		if acc := c.findAccount(priv.AccountName); acc != nil {
+			priv.AccountID = acc.ID
+		}
+	}
+	p, err := c.grantPrivilege(priv)
+	if err != nil {
+		return brain.Privilege{}, err
+	}
+	return *p, nil
+}
+
+func (c *Cluster) grantPrivilege(priv brain.Privilege) (*brain.Privilege, error) {
+	if c.findUser(priv.Username) == nil {
+		return nil, fmt.Errorf("no such user %s", priv.Username)
+	}
+	switch priv.TargetType() {
+	case brain.PrivilegeTargetTypeAccount:
+		acc := c.accountByID(priv.AccountID)
+		if acc == nil {
+			return nil, fmt.Errorf("no such account %d", priv.AccountID)
+		}
+		priv.AccountName = acc.Name
+	case brain.PrivilegeTargetTypeGroup:
+		group := c.groupByID(priv.GroupID)
+		if group == nil {
+			return nil, fmt.Errorf("no such group %d", priv.GroupID)
+		}
+		priv.GroupName = group.Name
+	case brain.PrivilegeTargetTypeVM:
+		vm := c.vmByID(priv.VirtualMachineID)
+		if vm == nil {
+			return nil, fmt.Errorf("no such server %d", priv.VirtualMachineID)
+		}
+		priv.VirtualMachineName = vm.Name
+	}
+	if priv.APIKeyID != 0 && c.apiKeyByID(priv.APIKeyID) == nil {
+		return nil, fmt.Errorf("no such api key %d", priv.APIKeyID)
+	}
+	priv.ID = c.nextID()
+	p := &priv
+	c.privileges = append(c.privileges, p)
+	return p, nil
+}
+
+// CreateSession logs in as the named user without needing their password,
+// returning a token to use with lib.Client.AuthWithToken.
+func (c *Cluster) CreateSession(username string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.findUser(username) == nil {
+		return "", fmt.Errorf("no such user %s", username)
+	}
+	return c.newSession(username), nil
+}
+
+func (c *Cluster) newSession(username string) string {
+	token := "session." + strconv.Itoa(c.nextID()) + "." + strconv.FormatInt(time.Now().UnixNano(), 36)
+	c.sessions[token] = username
+	return token
+}
+
+func (c *Cluster) findUser(username string) *user {
+	for _, u := range c.users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) findAccount(nameOrID string) *brain.Account {
+	for _, acc := range c.accounts {
+		if acc.Name == nameOrID || strconv.Itoa(acc.ID) == nameOrID {
+			return acc
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) accountByID(id int) *brain.Account {
+	return c.findAccount(strconv.Itoa(id))
+}
+
+func (c *Cluster) findGroup(acc *brain.Account, nameOrID string) *brain.Group {
+	for _, group := range c.groups {
+		if group.AccountID == acc.ID && (group.Name == nameOrID || strconv.Itoa(group.ID) == nameOrID) {
+			return group
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) groupByID(id int) *brain.Group {
+	for _, group := range c.groups {
+		if group.ID == id {
+			return group
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) findVM(group *brain.Group, nameOrID string) *brain.VirtualMachine {
+	for _, vm := range c.vms {
+		if vm.GroupID == group.ID && (vm.Name == nameOrID || strconv.Itoa(vm.ID) == nameOrID) {
+			return vm
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) vmByID(id int) *brain.VirtualMachine {
+	for _, vm := range c.vms {
+		if vm.ID == id {
+			return vm
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) findDisc(vm *brain.VirtualMachine, labelOrID string) *brain.Disc {
+	for _, disc := range c.discs {
+		if disc.VirtualMachineID == vm.ID && (disc.Label == labelOrID || strconv.Itoa(disc.ID) == labelOrID) {
+			return disc
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) discByID(id int) *brain.Disc {
+	for _, disc := range c.discs {
+		if disc.ID == id {
+			return disc
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) findBackup(disc *brain.Disc, labelOrID string) *brain.Backup {
+	for _, backup := range c.backups {
+		if backup.ParentDiscID == disc.ID && (backup.Label == labelOrID || strconv.Itoa(backup.ID) == labelOrID) {
+			return backup
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) backupByID(id int) *brain.Backup {
+	for _, backup := range c.backups {
+		if backup.ID == id {
+			return backup
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) findNIC(vm *brain.VirtualMachine, id string) *brain.NetworkInterface {
+	for _, nic := range c.nics {
+		if nic.VirtualMachineID == vm.ID && strconv.Itoa(nic.ID) == id {
+			return nic
+		}
+	}
+	return nil
+}
+
+// extraIPNIC returns the network interface which has ip routed to it as an
+// extra IP.
+func (c *Cluster) extraIPNIC(ip net.IP) *brain.NetworkInterface {
+	for _, nic := range c.nics {
+		if _, ok := nic.ExtraIPs[ip.String()]; ok {
+			return nic
+		}
+	}
+	return nil
+}
+
+// ipNIC returns the network interface which has ip as one of its own IPs.
+func (c *Cluster) ipNIC(ip net.IP) *brain.NetworkInterface {
+	for _, nic := range c.nics {
+		for _, nicIP := range nic.IPs {
+			if nicIP.Equal(ip) {
+				return nic
+			}
+		}
+	}
+	return nil
+}
+
+// ipVM returns the server which has ip on one of its network interfaces -
+// either as one of its own IPs or routed to it as an extra IP.
+func (c *Cluster) ipVM(ip net.IP) *brain.VirtualMachine {
+	nic := c.extraIPNIC(ip)
+	if nic == nil {
+		nic = c.ipNIC(ip)
+	}
+	if nic == nil {
+		return nil
+	}
+	return c.vmByID(nic.VirtualMachineID)
+}
+
+func (c *Cluster) vlanByNum(num int) *brain.VLAN {
+	for _, vlan := range c.vlans {
+		if vlan.Num == num {
+			return vlan
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) apiKeyByID(id int) *brain.APIKey {
+	for _, key := range c.apiKeys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// groupAccount returns the account a group is in
+func (c *Cluster) groupAccount(group *brain.Group) *brain.Account {
+	return c.accountByID(group.AccountID)
+}
+
+// vmPath returns the group and account a server is in
+func (c *Cluster) vmPath(vm *brain.VirtualMachine) (*brain.Group, *brain.Account) {
+	group := c.groupByID(vm.GroupID)
+	return group, c.groupAccount(group)
+}
+
+// renderVM makes a copy of the server with its discs and network interfaces
+// filled in, as the brain returns with view=overview
+func (c *Cluster) renderVM(vm *brain.VirtualMachine) brain.VirtualMachine {
+	out := *vm
+	group, acc := c.vmPath(vm)
+	out.Hostname = fmt.Sprintf("%s.%s.%s.uk0.bigv.io", vm.Name, group.Name, acc.Name)
+	out.Discs = brain.Discs{}
+	for _, disc := range c.discs {
+		if disc.VirtualMachineID == vm.ID {
+			out.Discs = append(out.Discs, c.renderDisc(disc))
+		}
+	}
+	out.NetworkInterfaces = []brain.NetworkInterface{}
+	for _, nic := range c.nics {
+		if nic.VirtualMachineID == vm.ID {
+			out.NetworkInterfaces = append(out.NetworkInterfaces, *nic)
+		}
+	}
+	return out
+}
+
+// renderDisc makes a copy of the disc with its backup count filled in
+func (c *Cluster) renderDisc(disc *brain.Disc) brain.Disc {
+	out := *disc
+	out.BackupCount = 0
+	for _, backup := range c.backups {
+		if backup.ParentDiscID == disc.ID {
+			out.BackupCount++
+		}
+	}
+	out.BackupsEnabled = len(disc.BackupSchedules) > 0
+	return out
+}
+
+func (c *Cluster) renderGroup(group *brain.Group) brain.Group {
+	out := *group
+	out.VirtualMachines = []brain.VirtualMachine{}
+	for _, vm := range c.vms {
+		if vm.GroupID == group.ID {
+			out.VirtualMachines = append(out.VirtualMachines, c.renderVM(vm))
+		}
+	}
+	return out
+}
+
+func (c *Cluster) renderAccount(acc *brain.Account) brain.Account {
+	out := *acc
+	out.Groups = brain.Groups{}
+	for _, group := range c.groups {
+		if group.AccountID == acc.ID {
+			out.Groups = append(out.Groups, c.renderGroup(group))
+		}
+	}
+	return out
+}
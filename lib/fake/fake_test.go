@@ -0,0 +1,670 @@
+package fake_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/billing"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/fake"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/spp"
+)
+
+// setup starts a fake cluster with a user called alice who owns an account
+// called alice-account, and returns a client logged in as her.
+func setup(t *testing.T) (*fake.Server, lib.Client) {
+	s := fake.NewServer()
+	s.AddUser("alice", "secret")
+	if _, err := s.AddAccount("alice-account", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	return s, login(t, s, "alice", "secret")
+}
+
+func login(t *testing.T, s *fake.Server, username, password string) lib.Client {
+	client, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.AuthWithCredentials(map[string]string{"username": username, "password": password})
+	if err != nil {
+		t.Fatalf("couldn't log in as %s: %s", username, err)
+	}
+	return client
+}
+
+func createServer(t *testing.T, client lib.Client, name string) pathers.VirtualMachineName {
+	vmName := pathers.VirtualMachineName{VirtualMachine: name}
+	_, err := client.CreateVirtualMachine(vmName.GroupName, brain.VirtualMachineSpec{
+		VirtualMachine: brain.VirtualMachine{Name: name, Cores: 2, Memory: 2048, PowerOn: true},
+		Discs:          []brain.Disc{{Size: 25600}},
+		Reimage:        &brain.ImageInstall{Distribution: "stretch", RootPassword: "hunter2"},
+	})
+	if err != nil {
+		t.Fatalf("couldn't create %s: %s", name, err)
+	}
+	if err := client.EnsureVirtualMachineName(&vmName); err != nil {
+		t.Fatal(err)
+	}
+	return vmName
+}
+
+func TestAuth(t *testing.T) {
+	s, _ := setup(t)
+	defer s.Close()
+
+	client, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AuthWithCredentials(map[string]string{"username": "alice", "password": "wrong"}); err == nil {
+		t.Error("logged in with the wrong password")
+	}
+	token, err := s.CreateSession("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AuthWithToken(token); err != nil {
+		t.Fatalf("couldn't log in with a session token: %s", err)
+	}
+	if client.GetSessionUser() != "alice" {
+		t.Errorf("expected to be logged in as alice but was %s", client.GetSessionUser())
+	}
+
+	s.AddUser("bob", "bobpass")
+	if err := client.Impersonate("bob"); err == nil {
+		t.Error("alice could impersonate bob without cluster_admin")
+	}
+}
+
+func TestServerLifecycle(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	vmName := createServer(t, client, "web1")
+	vm, err := client.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm.Hostname != "web1.default.alice-account.uk0.bigv.io" || len(vm.Discs) != 1 || vm.Discs[0].Label != "disc-1" || len(vm.AllIPv4Addresses()) != 1 {
+		t.Errorf("server wasn't created as expected: %#v", vm)
+	}
+	if vm.LastImagedWith != "stretch" || !vm.PowerOn {
+		t.Errorf("server wasn't imaged and started: %#v", vm)
+	}
+	if _, err := client.GetVirtualMachine(pathers.VirtualMachineName{VirtualMachine: "web1", Group: "nope"}); err == nil {
+		t.Error("found web1 in a group that doesn't exist")
+	}
+
+	if err := client.StopVirtualMachine(vmName); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetVirtualMachineMemory(vmName, 4096); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetVirtualMachineHardwareProfile(vmName, "virtio2013", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetVirtualMachineHardwareProfile(vmName, "made-up"); err == nil {
+		t.Error("set a hardware profile that doesn't exist")
+	}
+	if err := client.ResizeDisc(vmName, "disc-1", 10240); err == nil {
+		t.Error("shrank a disc")
+	}
+	if err := client.CreateDisc(vmName, brain.Disc{Size: 51200, StorageGrade: "archive"}); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := client.CreateBackup(vmName, "disc-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateBackupSchedule(vmName, "disc-2", "00:00", 86400); err != nil {
+		t.Fatal(err)
+	}
+
+	vm, err = client.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm.PowerOn || vm.Memory != 4096 || vm.HardwareProfile != "virtio2013" || !vm.HardwareProfileLocked {
+		t.Errorf("server wasn't updated as expected: %#v", vm)
+	}
+	if len(vm.Discs) != 2 || vm.Discs[1].BackupCount != 1 || !vm.Discs[1].BackupsEnabled {
+		t.Errorf("discs weren't updated as expected: %#v", vm.Discs)
+	}
+	backups, err := client.GetBackups(vmName, "disc-2")
+	if err != nil || len(backups) != 1 || backups[0].ID != backup.ID {
+		t.Errorf("expected one backup with ID %d but got %#v (%v)", backup.ID, backups, err)
+	}
+
+	if err := client.DeleteVirtualMachine(vmName, false); err != nil {
+		t.Fatal(err)
+	}
+	if vm, err := client.GetVirtualMachine(vmName); err != nil || !vm.Deleted {
+		t.Errorf("server wasn't deleted: %#v (%v)", vm, err)
+	}
+	if err := client.UndeleteVirtualMachine(vmName); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DeleteVirtualMachine(vmName, true); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetVirtualMachine(vmName)
+	if _, ok := err.(lib.NotFoundError); !ok {
+		t.Errorf("expected a NotFoundError after purging the server, got %v", err)
+	}
+}
+
+func TestGroupsAndMoving(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	createServer(t, client, "web1")
+	if err := client.CreateGroup(pathers.GroupName{Group: "staging"}); err != nil {
+		t.Fatal(err)
+	}
+	old := pathers.VirtualMachineName{VirtualMachine: "web1"}
+	moved := pathers.VirtualMachineName{VirtualMachine: "web2", Group: "staging"}
+	if err := client.MoveVirtualMachine(old, moved); err != nil {
+		t.Fatal(err)
+	}
+	group, err := client.GetGroup(pathers.GroupName{Group: "staging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group.VirtualMachines) != 1 || group.VirtualMachines[0].Name != "web2" {
+		t.Errorf("expected web2 to be in staging, but got %#v", group.VirtualMachines)
+	}
+	if err := client.DeleteGroup(pathers.GroupName{Group: "staging"}); err == nil {
+		t.Error("deleted a group with a server in it")
+	}
+
+	acc, err := client.GetAccount("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Name != "alice-account" || len(acc.Groups) != 2 {
+		t.Errorf("unexpected default account %#v", acc)
+	}
+}
+
+func TestDiscsFromBackups(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	web1 := createServer(t, client, "web1")
+	backup, err := client.CreateBackup(web1, "disc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateVirtualMachine(web1.GroupName, brain.VirtualMachineSpec{
+		VirtualMachine: brain.VirtualMachine{Name: "web2"},
+		Discs:          []brain.Disc{{Label: "copy", BackupID: backup.ID}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	web2 := pathers.VirtualMachineName{VirtualMachine: "web2"}
+	if err := client.EnsureVirtualMachineName(&web2); err != nil {
+		t.Fatal(err)
+	}
+	vm, err := client.GetVirtualMachine(web2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vm.Discs) != 1 || vm.Discs[0].Label != "copy" || vm.Discs[0].Size != 25600 {
+		t.Errorf("expected a 25GiB disc called copy, got %#v", vm.Discs)
+	}
+
+	if err := client.CreateDisc(web2, brain.Disc{Size: 10240, BackupID: backup.ID}); err == nil {
+		t.Error("made a disc smaller than the backup it was copied from")
+	}
+	if err := client.CreateDisc(web2, brain.Disc{BackupID: backup.ID + 1000}); err == nil {
+		t.Error("made a disc from a backup that doesn't exist")
+	}
+	if err := client.CreateDisc(web2, brain.Disc{Size: 51200, BackupID: backup.ID}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMoveDisc(t *testing.T) {
+	s, client := setup(t)
+	defer s.Close()
+
+	web1 := createServer(t, client, "web1")
+	web2 := createServer(t, client, "web2")
+	if err := client.CreateDisc(web1, brain.Disc{Label: "data", Size: 10240, StorageGrade: "sata"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := brainRequests.MoveDisc(client, web1, "data", web2); err == nil {
+		t.Error("moved a disc between servers which are powered on")
+	}
+	for _, vm := range []pathers.VirtualMachineName{web1, web2} {
+		if err := client.StopVirtualMachine(vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := brainRequests.MoveDisc(client, web1, "data", web2); err != nil {
+		t.Fatal(err)
+	}
+	vm, err := client.GetVirtualMachine(web2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vm.Discs) != 2 || vm.Discs[1].Label != "data" {
+		t.Errorf("expected data to have moved to web2, got %#v", vm.Discs)
+	}
+}
+
+func TestReverseDNS(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	web1 := createServer(t, alice, "web1")
+	vm, err := alice.GetVirtualMachine(web1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := vm.AllIPv4Addresses()[0]
+	if err := brainRequests.UpdateIPRDNS(alice, ip, "mail.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := brainRequests.GetIP(alice, ip)
+	if err != nil || !got.IP.Equal(ip) || got.RDns != "mail.example.com" {
+		t.Errorf("expected %s to have rdns mail.example.com, got %#v (%v)", ip, got, err)
+	}
+
+	s.AddUser("bob", "hunter2")
+	bob := login(t, s, "bob", "hunter2")
+	if _, err := brainRequests.GetIP(bob, ip); err == nil {
+		t.Error("bob could see the rdns of alice's IP")
+	}
+	if err := brainRequests.UpdateIPRDNS(bob, ip, "bob.example.com"); err == nil {
+		t.Error("bob could change the rdns of alice's IP")
+	}
+}
+
+func TestExtraIPs(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	web1 := createServer(t, alice, "web1")
+	web2 := createServer(t, alice, "web2")
+	added, err := alice.AddIP(web1, brain.IPCreateRequest{Addresses: 1, Family: "ipv4", Reason: "failover"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := added[0]
+	vm2, err := alice.GetVirtualMachine(web2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := vm2.NetworkInterfaces[0].IPs[0]
+
+	if err := brainRequests.RouteExtraIP(alice, primary, extra); err == nil {
+		t.Error("rerouted web2's own IP")
+	}
+	if err := brainRequests.RouteExtraIP(alice, extra, vm2.NetworkInterfaces[0].IPs[1]); err == nil {
+		t.Error("routed an IPv4 address to an IPv6 address")
+	}
+	if err := brainRequests.RouteExtraIP(alice, extra, primary); err != nil {
+		t.Fatal(err)
+	}
+	vm2, err = alice.GetVirtualMachine(web2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extraIPs := vm2.ExtraIPs(); len(extraIPs) != 1 || !extraIPs[0].IP.Equal(extra) || !extraIPs[0].RoutedTo.Equal(primary) {
+		t.Errorf("expected %s to be routed to %s, got %v", extra, primary, extraIPs)
+	}
+	vm1, err := alice.GetVirtualMachine(web1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extraIPs := vm1.ExtraIPs(); len(extraIPs) != 0 {
+		t.Errorf("expected web1 to have no extra IPs, got %v", extraIPs)
+	}
+
+	s.AddUser("bob", "hunter2")
+	bob := login(t, s, "bob", "hunter2")
+	if err := brainRequests.DeleteIP(bob, extra); err == nil {
+		t.Error("bob could delete alice's extra IP")
+	}
+	if err := brainRequests.DeleteIP(alice, primary); err == nil {
+		t.Error("deleted web2's own IP")
+	}
+	if err := brainRequests.DeleteIP(alice, extra); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := brainRequests.GetIP(alice, extra); err == nil {
+		t.Errorf("%s still exists after being deleted", extra)
+	}
+}
+
+func TestPrivateNetworking(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	db1 := createServer(t, alice, "db1")
+	if _, err := s.GrantPrivilege(brain.Privilege{Username: "alice", Level: brain.ClusterAdminPrivilege}); err != nil {
+		t.Fatal(err)
+	}
+	privateName := pathers.GroupName{Group: "private", Account: "alice-account"}
+	if err := alice.AdminCreateGroup(privateName, 1042); err != nil {
+		t.Fatal(err)
+	}
+	publicName := pathers.GroupName{Group: "public", Account: "alice-account"}
+	if err := alice.CreateGroup(publicName); err != nil {
+		t.Fatal(err)
+	}
+	private, err := alice.GetGroup(privateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if private.VlanNum != 1042 {
+		t.Fatalf("expected the private group to have VLAN 1042, got %d", private.VlanNum)
+	}
+	public, err := alice.GetGroup(publicName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := brainRequests.CreateNIC(alice, db1, public.ID); err == nil {
+		t.Error("attached db1 to a group without a private VLAN")
+	}
+	nic, err := brainRequests.CreateNIC(alice, db1, private.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nic.VlanNum != 1042 || nic.Label != "eth1" {
+		t.Errorf("expected eth1 on VLAN 1042, got %#v", nic)
+	}
+	if _, err := brainRequests.CreateNIC(alice, db1, private.ID); err == nil {
+		t.Error("attached db1 to the same VLAN twice")
+	}
+	vm, err := alice.GetVirtualMachine(db1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vm.NetworkInterfaces) != 2 {
+		t.Fatalf("expected db1 to have 2 network interfaces, got %d", len(vm.NetworkInterfaces))
+	}
+
+	s.AddUser("bob", "hunter2")
+	bob := login(t, s, "bob", "hunter2")
+	if err := brainRequests.DeleteNIC(bob, db1, nic.ID); err == nil {
+		t.Error("bob could remove a network interface from alice's server")
+	}
+	if err := brainRequests.DeleteNIC(alice, db1, vm.NetworkInterfaces[0].ID); err == nil {
+		t.Error("removed db1's public network interface")
+	}
+	if err := brainRequests.DeleteNIC(alice, db1, nic.ID); err != nil {
+		t.Fatal(err)
+	}
+	vm, err = alice.GetVirtualMachine(db1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vm.NetworkInterfaces) != 1 {
+		t.Errorf("expected db1 to have 1 network interface, got %d", len(vm.NetworkInterfaces))
+	}
+}
+
+func TestFirewallRules(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	web1 := createServer(t, alice, "web1")
+	group := web1.GroupName
+	office, err := alice.CreateGroupFirewallRule(group, brain.FirewallRule{Protocol: "TCP", Ports: "22", Source: "192.0.2.7/24", Label: "office"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if office.Protocol != "tcp" || office.Source != "192.0.2.0/24" || office.GroupID == 0 {
+		t.Errorf("expected the rule to be normalised and attached to the group, got %#v", office)
+	}
+	if _, err := alice.CreateServerFirewallRule(web1, brain.FirewallRule{Protocol: "tcp", Ports: "443"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.CreateServerFirewallRule(web1, brain.FirewallRule{Protocol: "icmp", Ports: "1"}); err == nil {
+		t.Error("created an icmp rule with a port")
+	}
+
+	groupRules, err := alice.GetGroupFirewallRules(group)
+	if err != nil || len(groupRules) != 1 {
+		t.Errorf("expected 1 group rule, got %v (%v)", groupRules, err)
+	}
+	serverRules, err := alice.GetServerFirewallRules(web1)
+	if err != nil || len(serverRules) != 1 || serverRules[0].Ports != "443" {
+		t.Errorf("expected 1 server rule for 443, got %v (%v)", serverRules, err)
+	}
+
+	s.AddUser("bob", "hunter2")
+	bob := login(t, s, "bob", "hunter2")
+	if _, err := bob.GetFirewallRule(office.ID); err == nil {
+		t.Error("bob could see alice's firewall rule")
+	}
+	if err := bob.DeleteFirewallRule(office.ID); err == nil {
+		t.Error("bob could delete alice's firewall rule")
+	}
+	if err := alice.DeleteFirewallRule(office.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.GetFirewallRule(office.ID); err == nil {
+		t.Error("the firewall rule still exists after being deleted")
+	}
+}
+
+func TestPrivilegesAndAPIKeys(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	vmName := createServer(t, alice, "web1")
+	bobUser := s.AddUser("bob", "bobpass")
+	bob := login(t, s, "bob", "bobpass")
+	if _, err := bob.GetVirtualMachine(vmName); err == nil {
+		t.Fatal("bob could see alice's server without any privileges")
+	}
+
+	vm, err := alice.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = alice.GrantPrivilege(brain.Privilege{Username: "bob", Level: brain.VMAdminPrivilege, VirtualMachineID: vm.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bob.StopVirtualMachine(vmName); err != nil {
+		t.Errorf("bob couldn't stop web1 after being given vm_admin: %s", err)
+	}
+	if err := bob.CreateGroup(pathers.GroupName{Group: "bobs", Account: "alice-account"}); err == nil {
+		t.Error("bob created a group with only vm_admin")
+	}
+
+	key, err := brainRequests.CreateAPIKey(bob, "", brain.APIKey{UserID: bobUser.ID, Label: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyClient, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := keyClient.AuthWithToken("apikey." + key.APIKey); err != nil {
+		t.Fatal(err)
+	}
+	if keyClient.GetSessionUser() != "bob" {
+		t.Errorf("expected the api key to be bob's, got %q", keyClient.GetSessionUser())
+	}
+	if record, ok := lib.SessionAPIKey(keyClient); !ok || record.Label != "ci" {
+		t.Errorf("expected the session's api key record to be ci, got %#v", record)
+	}
+	oldKey, err := brainRequests.CreateAPIKey(bob, "", brain.APIKey{UserID: bobUser.ID, Label: "old", ExpiresAt: "2001-01-01T00:00:00+0000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKeyClient, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oldKeyClient.AuthWithToken("apikey." + oldKey.APIKey); err == nil {
+		t.Error("authenticated with an expired api key")
+	} else if _, ok := err.(lib.APIKeyExpiredError); !ok {
+		t.Errorf("expected an APIKeyExpiredError, got %#v", err)
+	}
+	if _, err := keyClient.GetVirtualMachine(vmName); err == nil {
+		t.Error("an api key without privileges could see web1")
+	}
+	err = bob.GrantPrivilege(brain.Privilege{Username: "bob", Level: brain.VMAdminPrivilege, VirtualMachineID: vm.ID, APIKeyID: key.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyClient.GetVirtualMachine(vmName); err != nil {
+		t.Errorf("the api key couldn't see web1 after being given vm_admin: %s", err)
+	}
+	past := "2001-01-01T00:00:00+0000"
+	if err := brainRequests.UpdateAPIKey(bob, key.ID, brain.APIKeyModification{ExpiresAt: &past}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyClient.GetVirtualMachine(vmName); err == nil {
+		t.Error("the api key could still see web1 after it expired")
+	}
+	never := ""
+	if err := brainRequests.UpdateAPIKey(bob, key.ID, brain.APIKeyModification{ExpiresAt: &never}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyClient.GetVirtualMachine(vmName); err != nil {
+		t.Errorf("the api key couldn't see web1 after it was made to never expire: %s", err)
+	}
+	if err := brainRequests.DeleteAPIKey(bob, "ci"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = keyClient.GetVirtualMachine(vmName)
+	if _, ok := err.(lib.UnauthorizedError); !ok {
+		t.Errorf("expected an UnauthorizedError after deleting the key, got %v", err)
+	}
+
+	privs, err := alice.GetPrivilegesForVirtualMachine(vmName)
+	if err != nil || len(privs) != 1 {
+		t.Fatalf("expected one privilege on web1 but got %#v (%v)", privs, err)
+	}
+	if err := alice.RevokePrivilege(privs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.GetVirtualMachine(vmName); err == nil {
+		t.Error("bob could still see web1 after his privilege was revoked")
+	}
+}
+
+func TestVMDefaults(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	vmName := createServer(t, alice, "web1")
+	vm, err := alice.GetVirtualMachine(vmName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := alice.GetAccount("alice-account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = brainRequests.CreateVMDefault(alice, brain.VirtualMachineDefault{AccountID: account.BrainID, Name: "web", ServerSettings: vm.Spec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = brainRequests.CreateVMDefault(alice, brain.VirtualMachineDefault{AccountID: account.BrainID, Name: "public-web", Public: true, ServerSettings: vm.Spec()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vmd, err := brainRequests.GetVMDefault(alice, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vmd.ServerSettings.Reimage == nil || vmd.ServerSettings.Reimage.Distribution != "stretch" || vmd.ServerSettings.VirtualMachine.Cores != 2 {
+		t.Errorf("web didn't have web1's spec: %#v", vmd.ServerSettings)
+	}
+
+	s.AddUser("bob", "bobpass")
+	bob := login(t, s, "bob", "bobpass")
+	vmds, err := brainRequests.GetVMDefaults(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vmds) != 1 || vmds[0].Name != "public-web" {
+		t.Errorf("expected bob to only see public-web, but he saw %#v", vmds)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s, alice := setup(t)
+	defer s.Close()
+
+	if _, err := alice.GetHeads(); err == nil {
+		t.Error("alice could list heads without cluster_admin")
+	}
+	if _, err := s.GrantPrivilege(brain.Privilege{Username: "alice", Level: brain.ClusterAdminPrivilege}); err != nil {
+		t.Fatal(err)
+	}
+	heads, err := alice.GetHeads()
+	if err != nil || len(heads) != 1 {
+		t.Fatalf("expected one head but got %#v (%v)", heads, err)
+	}
+	if err := alice.CreateIPRange("192.168.0.0/24", 1); err != nil {
+		t.Fatal(err)
+	}
+	vlan, err := alice.GetVLAN(1)
+	if err != nil || len(vlan.IPRanges) != 2 {
+		t.Errorf("expected the VLAN to have two ip ranges but got %#v (%v)", vlan, err)
+	}
+	if err := alice.CreateUser("carol", "cluster_su"); err != nil {
+		t.Fatal(err)
+	}
+	if err := alice.Impersonate("carol"); err != nil {
+		t.Fatal(err)
+	}
+	if alice.GetSessionUser() != "carol" {
+		t.Errorf("expected to be carol after impersonating her, but was %s", alice.GetSessionUser())
+	}
+}
+
+func TestSignup(t *testing.T) {
+	s := fake.NewServer()
+	defer s.Close()
+	client, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := billing.Person{Username: "dave", Password: "davepass", Email: "dave@example.com"}
+	cc := spp.CreditCard{Number: "4444333322221111", Name: "Dave", Expiry: "1230", CVV: "123"}
+	token, err := client.GetSPPToken(cc, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := client.CreateCreditCardWithToken(cc, token)
+	if err != nil || ref == "" {
+		t.Fatalf("couldn't create a card reference: %q (%v)", ref, err)
+	}
+	_, err = client.RegisterNewAccount(lib.Account{Name: "daves-account", Owner: owner, CardReference: ref})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dave := login(t, s, "dave", "davepass")
+	acc, err := dave.GetDefaultAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Name != "daves-account" || acc.CardReference != ref || acc.Owner.Email != "dave@example.com" {
+		t.Errorf("unexpected account after signing up %#v", acc)
+	}
+
+	defs, err := dave.ReadDefinitions()
+	if err != nil || len(defs.Distributions) == 0 {
+		t.Errorf("couldn't read definitions: %#v (%v)", defs, err)
+	}
+}
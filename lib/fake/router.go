@@ -0,0 +1,183 @@
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// request is passed to the handler for each route, and holds everything it
+// needs to know about the request it's handling.
+type request struct {
+	w http.ResponseWriter
+	r *http.Request
+	// params are the parts of the path matched by :name segments of the
+	// route's pattern
+	params map[string]string
+	// auth is who the request was authenticated as. It is nil for routes
+	// which don't require authentication.
+	auth *authInfo
+}
+
+// httpError is returned by handlers when the request couldn't be fulfilled
+type httpError struct {
+	status  int
+	message string
+	// code is an optional machine-readable code to go alongside message
+	code string
+}
+
+func (e httpError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return httpError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+func forbidden(format string, args ...interface{}) error {
+	return httpError{status: http.StatusForbidden, message: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return httpError{status: http.StatusNotFound, message: fmt.Sprintf(format, args...)}
+}
+
+// param returns the part of the path matched by :name in the route's pattern
+func (req *request) param(name string) string {
+	return req.params[name]
+}
+
+// query returns the value of the named query string parameter
+func (req *request) query(name string) string {
+	return req.r.URL.Query().Get(name)
+}
+
+// decode reads the request body as JSON into v
+func (req *request) decode(v interface{}) error {
+	body, err := ioutil.ReadAll(req.r.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return badRequest("couldn't parse request body: %s", err)
+	}
+	return nil
+}
+
+// handlerFunc handles a request, returning the object to respond with (or nil
+// for an empty response) or an error.
+type handlerFunc func(c *Cluster, req *request) (interface{}, error)
+
+type route struct {
+	method  string
+	pattern []string
+	auth    bool
+	handler handlerFunc
+}
+
+// match returns the path parameters if path matches the route's pattern.
+func (rt route) match(method string, path []string) (map[string]string, bool) {
+	if method != rt.method || len(path) != len(rt.pattern) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, part := range rt.pattern {
+		if strings.HasPrefix(part, ":") {
+			params[part[1:]] = path[i]
+		} else if part != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// router is an http.Handler which dispatches to the first matching route,
+// holding the cluster's lock while the handler runs.
+type router struct {
+	cluster *Cluster
+	routes  []route
+	// authenticate checks the request's Authorization header
+	authenticate func(c *Cluster, r *http.Request) (*authInfo, error)
+}
+
+// handle adds a route which requires authentication
+func (rt *router) handle(method, pattern string, handler handlerFunc) {
+	rt.routes = append(rt.routes, route{method: method, pattern: splitPath(pattern), auth: true, handler: handler})
+}
+
+// handleNoAuth adds a route which doesn't require authentication
+func (rt *router) handleNoAuth(method, pattern string, handler handlerFunc) {
+	rt.routes = append(rt.routes, route{method: method, pattern: splitPath(pattern), handler: handler})
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := splitPath(r.URL.Path)
+	for _, route := range rt.routes {
+		params, ok := route.match(r.Method, path)
+		if !ok {
+			continue
+		}
+		req := &request{w: w, r: r, params: params}
+
+		rt.cluster.mu.Lock()
+		obj, err := rt.serve(route, req)
+		rt.cluster.mu.Unlock()
+
+		respond(w, obj, err)
+		return
+	}
+	respond(w, nil, notFound("no route for %s %s", r.Method, r.URL.Path))
+}
+
+func (rt *router) serve(route route, req *request) (interface{}, error) {
+	if route.auth {
+		auth, err := rt.authenticate(rt.cluster, req.r)
+		if err != nil {
+			return nil, err
+		}
+		req.auth = auth
+	}
+	return route.handler(rt.cluster, req)
+}
+
+// respond writes obj as JSON - or err as a JSON error - to w. Strings are
+// written as plain text, as auth and spp do.
+func respond(w http.ResponseWriter, obj interface{}, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		body := map[string]string{"error": err.Error()}
+		if httpErr, ok := err.(httpError); ok {
+			status = httpErr.status
+			if httpErr.code != "" {
+				body["code"] = httpErr.code
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		js, _ := json.Marshal(body)
+		_, _ = w.Write(js)
+		return
+	}
+	switch obj := obj.(type) {
+	case nil:
+		w.WriteHeader(http.StatusOK)
+	case string:
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(obj))
+	default:
+		js, err := json.Marshal(obj)
+		if err != nil {
+			respond(w, nil, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(js)
+	}
+}
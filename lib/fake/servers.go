@@ -0,0 +1,71 @@
+package fake
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+)
+
+// Handler returns an http.Handler which serves all of the cluster's APIs
+// from one place - auth under /auth, the brain under /brain, bmbilling under
+// /billing and SPP under /spp. Nothing uses the API endpoint yet, so /api
+// just 404s.
+func (c *Cluster) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/auth/", http.StripPrefix("/auth", c.AuthHandler()))
+	mux.Handle("/brain/", http.StripPrefix("/brain", c.BrainHandler()))
+	mux.Handle("/billing/", http.StripPrefix("/billing", c.BillingHandler()))
+	mux.Handle("/spp/", http.StripPrefix("/spp", c.SPPHandler()))
+	mux.Handle("/api/", http.NotFoundHandler())
+	return mux
+}
+
+// URLs returns the EndpointURLs for the cluster's APIs when Handler is
+// being served at base, which should not end in a /.
+func URLs(base string) lib.EndpointURLs {
+	return lib.EndpointURLs{
+		API:     base + "/api",
+		Auth:    base + "/auth",
+		Billing: base + "/billing",
+		Brain:   base + "/brain",
+		SPP:     base + "/spp",
+	}
+}
+
+// Server serves a Cluster over HTTP on a local port, for use in tests.
+type Server struct {
+	*Cluster
+	server *httptest.Server
+}
+
+// NewServer creates a new Cluster and starts serving it. Close must be called
+// once the Server is finished with.
+func NewServer() *Server {
+	c := New()
+	return &Server{
+		Cluster: c,
+		server:  httptest.NewServer(c.Handler()),
+	}
+}
+
+// URLs returns the EndpointURLs to use to talk to the Server
+func (s *Server) URLs() lib.EndpointURLs {
+	return URLs(s.server.URL)
+}
+
+// Client makes a lib.Client which talks to the Server. It still needs to be
+// authenticated before use.
+func (s *Server) Client() (lib.Client, error) {
+	c, err := lib.NewWithURLs(s.URLs())
+	if err != nil {
+		return nil, err
+	}
+	c.AllowInsecureRequests()
+	return c, nil
+}
+
+// Close stops serving the cluster.
+func (s *Server) Close() {
+	s.server.Close()
+}
@@ -0,0 +1,89 @@
+package lib
+
+import (
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+// GetGroupFirewallRules gets the firewall rules attached to the named group,
+// which apply to every server in it.
+func (c *bytemarkClient) GetGroupFirewallRules(groupName pathers.GroupName) (rules brain.FirewallRules, err error) {
+	err = c.EnsureGroupName(&groupName)
+	if err != nil {
+		return
+	}
+	r, err := c.BuildRequest("GET", BrainEndpoint, "/accounts/%s/groups/%s/firewall_rules", string(groupName.Account), groupName.Group)
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, &rules)
+	return
+}
+
+// GetServerFirewallRules gets the firewall rules attached to the named server
+// itself - not including those of its group.
+func (c *bytemarkClient) GetServerFirewallRules(vmName pathers.VirtualMachineName) (rules brain.FirewallRules, err error) {
+	err = c.EnsureVirtualMachineName(&vmName)
+	if err != nil {
+		return
+	}
+	r, err := c.BuildRequest("GET", BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/firewall_rules", string(vmName.Account), vmName.Group, vmName.VirtualMachine)
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, &rules)
+	return
+}
+
+// GetFirewallRule gets the firewall rule with the given ID.
+func (c *bytemarkClient) GetFirewallRule(id int) (rule brain.FirewallRule, err error) {
+	r, err := c.BuildRequest("GET", BrainEndpoint, "/firewall_rules/%s", strconv.Itoa(id))
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, &rule)
+	return
+}
+
+// CreateGroupFirewallRule attaches a firewall rule to the named group, so
+// that it applies to every server in it. The created rule is returned.
+func (c *bytemarkClient) CreateGroupFirewallRule(groupName pathers.GroupName, rule brain.FirewallRule) (created brain.FirewallRule, err error) {
+	err = c.EnsureGroupName(&groupName)
+	if err != nil {
+		return
+	}
+	r, err := c.BuildRequest("POST", BrainEndpoint, "/accounts/%s/groups/%s/firewall_rules", string(groupName.Account), groupName.Group)
+	if err != nil {
+		return
+	}
+	_, _, err = r.MarshalAndRun(rule, &created)
+	return
+}
+
+// CreateServerFirewallRule attaches a firewall rule to the named server. The
+// created rule is returned.
+func (c *bytemarkClient) CreateServerFirewallRule(vmName pathers.VirtualMachineName, rule brain.FirewallRule) (created brain.FirewallRule, err error) {
+	err = c.EnsureVirtualMachineName(&vmName)
+	if err != nil {
+		return
+	}
+	r, err := c.BuildRequest("POST", BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/firewall_rules", string(vmName.Account), vmName.Group, vmName.VirtualMachine)
+	if err != nil {
+		return
+	}
+	_, _, err = r.MarshalAndRun(rule, &created)
+	return
+}
+
+// DeleteFirewallRule deletes the firewall rule with the given ID, whether
+// it's attached to a group or a server.
+func (c *bytemarkClient) DeleteFirewallRule(id int) (err error) {
+	r, err := c.BuildRequest("DELETE", BrainEndpoint, "/firewall_rules/%s", strconv.Itoa(id))
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, nil)
+	return
+}
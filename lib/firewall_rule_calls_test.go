@@ -0,0 +1,135 @@
+package lib_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestGetFirewallRules(t *testing.T) {
+	groupName := pathers.GroupName{Group: "web", Account: "test-account"}
+	vmName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: groupName}
+	expected := brain.FirewallRules{
+		{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24"},
+		{ID: 13, Protocol: "tcp", Ports: "443"},
+	}
+	tests := []struct {
+		name string
+		url  string
+		get  func(client lib.Client) (brain.FirewallRules, error)
+	}{
+		{
+			name: "group",
+			url:  "/accounts/test-account/groups/web/firewall_rules",
+			get: func(client lib.Client) (brain.FirewallRules, error) {
+				return client.GetGroupFirewallRules(groupName)
+			},
+		}, {
+			name: "server",
+			url:  "/accounts/test-account/groups/web/virtual_machines/web1/firewall_rules",
+			get: func(client lib.Client) (brain.FirewallRules, error) {
+				return client.GetServerFirewallRules(vmName)
+			},
+		},
+	}
+	for _, test := range tests {
+		rts := testutil.RequestTestSpec{
+			Method:   "GET",
+			Endpoint: lib.BrainEndpoint,
+			URL:      test.url,
+			Response: expected,
+		}
+		rts.Run(t, test.name, true, func(client lib.Client) {
+			rules, err := test.get(client)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			assert.Equal(t, test.name, expected, rules)
+		})
+	}
+}
+
+func TestGetFirewallRule(t *testing.T) {
+	expected := brain.FirewallRule{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", GroupID: 4}
+	rts := testutil.RequestTestSpec{
+		Method:   "GET",
+		Endpoint: lib.BrainEndpoint,
+		URL:      "/firewall_rules/12",
+		Response: expected,
+	}
+	rts.Run(t, "", true, func(client lib.Client) {
+		rule, err := client.GetFirewallRule(12)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		assert.Equal(t, "rule", expected, rule)
+	})
+}
+
+func TestCreateFirewallRule(t *testing.T) {
+	groupName := pathers.GroupName{Group: "web", Account: "test-account"}
+	vmName := pathers.VirtualMachineName{VirtualMachine: "web1", GroupName: groupName}
+	rule := brain.FirewallRule{Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", Label: "office"}
+	tests := []struct {
+		name     string
+		url      string
+		expected brain.FirewallRule
+		create   func(client lib.Client) (brain.FirewallRule, error)
+	}{
+		{
+			name:     "group",
+			url:      "/accounts/test-account/groups/web/firewall_rules",
+			expected: brain.FirewallRule{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", Label: "office", GroupID: 4},
+			create: func(client lib.Client) (brain.FirewallRule, error) {
+				return client.CreateGroupFirewallRule(groupName, rule)
+			},
+		}, {
+			name:     "server",
+			url:      "/accounts/test-account/groups/web/virtual_machines/web1/firewall_rules",
+			expected: brain.FirewallRule{ID: 12, Protocol: "tcp", Ports: "22", Source: "192.0.2.0/24", Label: "office", VirtualMachineID: 5},
+			create: func(client lib.Client) (brain.FirewallRule, error) {
+				return client.CreateServerFirewallRule(vmName, rule)
+			},
+		},
+	}
+	for _, test := range tests {
+		rts := testutil.RequestTestSpec{
+			Method:   "POST",
+			Endpoint: lib.BrainEndpoint,
+			URL:      test.url,
+			AssertRequest: assert.BodyUnmarshalEqual(map[string]interface{}{
+				"protocol": "tcp",
+				"ports":    "22",
+				"source":   "192.0.2.0/24",
+				"label":    "office",
+			}),
+			Response: test.expected,
+		}
+		rts.Run(t, test.name, true, func(client lib.Client) {
+			created, err := test.create(client)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			assert.Equal(t, test.name, test.expected, created)
+		})
+	}
+}
+
+func TestDeleteFirewallRule(t *testing.T) {
+	testName := testutil.Name(0)
+	rts := testutil.RequestTestSpec{
+		Method:   "DELETE",
+		Endpoint: lib.BrainEndpoint,
+		URL:      "/firewall_rules/12",
+	}
+	rts.Run(t, testName, true, func(client lib.Client) {
+		err := client.DeleteFirewallRule(12)
+		if err != nil {
+			t.Fatalf("%s err %s", testName, err)
+		}
+	})
+}
@@ -104,6 +104,18 @@ type Client interface {
 	ResizeDisc(vm pathers.VirtualMachineName, idOrLabel string, size int) error
 	SetDiscIopsLimit(vm pathers.VirtualMachineName, idOrLabel string, iopsLimit int) error
 
+	//
+	// FIREWALL RULES
+	//
+
+	GetGroupFirewallRules(group pathers.GroupName) (brain.FirewallRules, error)
+	GetServerFirewallRules(vm pathers.VirtualMachineName) (brain.FirewallRules, error)
+	GetFirewallRule(id int) (brain.FirewallRule, error)
+	CreateGroupFirewallRule(group pathers.GroupName, rule brain.FirewallRule) (brain.FirewallRule, error)
+	CreateServerFirewallRule(vm pathers.VirtualMachineName, rule brain.FirewallRule) (brain.FirewallRule, error)
+	// DeleteFirewallRule deletes the firewall rule with the given ID, whether it's attached to a group or a server.
+	DeleteFirewallRule(id int) error
+
 	//
 	// GROUPS
 	//
@@ -0,0 +1,164 @@
+package manifest
+
+import (
+	"fmt"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+func createGroup(name pathers.GroupName) Action {
+	return Action{
+		Type:   "create group",
+		Target: name.String(),
+		apply: func(client lib.Client) error {
+			return client.CreateGroup(name)
+		},
+	}
+}
+
+// createServer returns the actions needed to make a server which doesn't
+// exist yet - creating it and granting privileges on it.
+func createServer(name pathers.VirtualMachineName, s Server) (plan Plan) {
+	spec := s.VirtualMachineSpec
+	detail := fmt.Sprintf("%d cores, %dMiB memory, %d discs", spec.VirtualMachine.Cores, spec.VirtualMachine.Memory, len(spec.Discs))
+	if spec.Reimage != nil && spec.Reimage.Distribution != "" {
+		detail += ", imaged with " + spec.Reimage.Distribution
+	}
+	plan = append(plan, Action{
+		Type:   "create server",
+		Target: name.String(),
+		Detail: detail,
+		apply: func(client lib.Client) error {
+			_, err := client.CreateVirtualMachine(name.GroupName, spec)
+			return err
+		},
+	})
+	for _, p := range s.Privileges {
+		p.VirtualMachineName = name.String()
+		plan = append(plan, grantServerPrivilege(name, p))
+	}
+	return
+}
+
+func setCores(name pathers.VirtualMachineName, from, to int) Action {
+	return Action{
+		Type:   "set cores",
+		Target: name.String(),
+		Detail: fmt.Sprintf("%d -> %d", from, to),
+		apply: func(client lib.Client) error {
+			return client.SetVirtualMachineCores(name, to)
+		},
+	}
+}
+
+func setMemory(name pathers.VirtualMachineName, from, to int) Action {
+	return Action{
+		Type:   "set memory",
+		Target: name.String(),
+		Detail: fmt.Sprintf("%dMiB -> %dMiB", from, to),
+		apply: func(client lib.Client) error {
+			return client.SetVirtualMachineMemory(name, to)
+		},
+	}
+}
+
+func setHardwareProfile(name pathers.VirtualMachineName, profile string, locked bool) Action {
+	detail := profile
+	if locked {
+		detail += ", locked"
+	}
+	return Action{
+		Type:   "set hardware profile",
+		Target: name.String(),
+		Detail: detail,
+		apply: func(client lib.Client) error {
+			return client.SetVirtualMachineHardwareProfile(name, profile, locked)
+		},
+	}
+}
+
+func createDisc(name pathers.VirtualMachineName, disc brain.Disc) Action {
+	// Validate fills in the default storage grade
+	validated, _ := disc.Validate()
+	disc = *validated
+	return Action{
+		Type:   "create disc",
+		Target: fmt.Sprintf("%s on %s", disc.Label, name),
+		Detail: fmt.Sprintf("%dGiB %s", disc.Size/1024, disc.StorageGrade),
+		apply: func(client lib.Client) error {
+			return client.CreateDisc(name, disc)
+		},
+	}
+}
+
+func resizeDisc(name pathers.VirtualMachineName, label string, from, to int) Action {
+	return Action{
+		Type:   "resize disc",
+		Target: fmt.Sprintf("%s on %s", label, name),
+		Detail: fmt.Sprintf("%dGiB -> %dGiB", from/1024, to/1024),
+		apply: func(client lib.Client) error {
+			return client.ResizeDisc(name, label, to)
+		},
+	}
+}
+
+func createBackupSchedule(name pathers.VirtualMachineName, label string, sched brain.BackupSchedule) Action {
+	return Action{
+		Type:   "create backup schedule",
+		Target: fmt.Sprintf("%s on %s", label, name),
+		Detail: fmt.Sprintf("%s starting %s", sched.IntervalInWords(), sched.StartDate),
+		apply: func(client lib.Client) error {
+			_, err := client.CreateBackupSchedule(name, label, sched.StartDate, sched.Interval)
+			return err
+		},
+	}
+}
+
+// grantGroupPrivilege grants p on the named group. If p's GroupID is not set
+// (because the group doesn't exist yet) it is looked up when the action is
+// applied.
+func grantGroupPrivilege(name pathers.GroupName, p brain.Privilege) Action {
+	return Action{
+		Type:   "grant",
+		Target: p.String(),
+		apply: func(client lib.Client) error {
+			if p.GroupID == 0 {
+				group, err := client.GetGroup(name)
+				if err != nil {
+					return err
+				}
+				p.GroupID = group.ID
+			}
+			return client.GrantPrivilege(withPassword(p))
+		},
+	}
+}
+
+// grantServerPrivilege grants p on the named server. If p's VirtualMachineID
+// is not set (because the server doesn't exist yet) it is looked up when the
+// action is applied.
+func grantServerPrivilege(name pathers.VirtualMachineName, p brain.Privilege) Action {
+	return Action{
+		Type:   "grant",
+		Target: p.String(),
+		apply: func(client lib.Client) error {
+			if p.VirtualMachineID == 0 {
+				vm, err := client.GetVirtualMachine(name)
+				if err != nil {
+					return err
+				}
+				p.VirtualMachineID = vm.ID
+			}
+			return client.GrantPrivilege(withPassword(p))
+		},
+	}
+}
+
+// withPassword makes p require a password unless it's for use with an API
+// key, the same as `bytemark grant` does.
+func withPassword(p brain.Privilege) brain.Privilege {
+	p.PasswordRequired = p.APIKeyID == 0
+	return p
+}
@@ -0,0 +1,218 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Export walks every group in acc and returns a Manifest describing the
+// groups, servers, discs, backup schedules and privileges in it. Deleted
+// servers are skipped. Everything is sorted by name so that exporting the same
+// account twice produces the same manifest.
+func Export(client lib.Client, acc lib.Account) (m Manifest, err error) {
+	m.Account = acc.Name
+	groupNames := make([]string, 0, len(acc.Groups))
+	for _, g := range acc.Groups {
+		groupNames = append(groupNames, g.Name)
+	}
+	sort.Strings(groupNames)
+
+	for _, name := range groupNames {
+		var group Group
+		group, err = exportGroup(client, pathers.GroupName{
+			Group:   name,
+			Account: pathers.AccountName(acc.Name),
+		})
+		if err != nil {
+			return
+		}
+		m.Groups = append(m.Groups, group)
+	}
+	return
+}
+
+func exportGroup(client lib.Client, name pathers.GroupName) (group Group, err error) {
+	brainGroup, err := client.GetGroup(name)
+	if err != nil {
+		return
+	}
+	group.Name = brainGroup.Name
+
+	privs, err := client.GetPrivilegesForGroup(name)
+	if err != nil {
+		return
+	}
+	group.Privileges = exportPrivileges(privs, brain.PrivilegeTargetTypeGroup)
+
+	vmNames := make([]string, 0, len(brainGroup.VirtualMachines))
+	for _, vm := range brainGroup.VirtualMachines {
+		if !vm.Deleted {
+			vmNames = append(vmNames, vm.Name)
+		}
+	}
+	sort.Strings(vmNames)
+
+	for _, vmName := range vmNames {
+		var server Server
+		server, err = exportServer(client, pathers.VirtualMachineName{
+			GroupName:      name,
+			VirtualMachine: vmName,
+		})
+		if err != nil {
+			return
+		}
+		group.Servers = append(group.Servers, server)
+	}
+	return
+}
+
+func exportServer(client lib.Client, name pathers.VirtualMachineName) (server Server, err error) {
+	vm, err := client.GetVirtualMachine(name)
+	if err != nil {
+		return
+	}
+	server.VirtualMachine = brain.VirtualMachine{
+		Name:                  vm.Name,
+		Autoreboot:            vm.Autoreboot,
+		CdromURL:              vm.CdromURL,
+		Cores:                 vm.Cores,
+		Memory:                vm.Memory,
+		HardwareProfile:       vm.HardwareProfile,
+		HardwareProfileLocked: vm.HardwareProfileLocked,
+		ZoneName:              vm.ZoneName,
+	}
+
+	// sort by ID so the boot disc stays first
+	sort.Slice(vm.Discs, func(i, j int) bool {
+		return vm.Discs[i].ID < vm.Discs[j].ID
+	})
+	for _, d := range vm.Discs {
+		disc := brain.Disc{
+			Label:        d.Label,
+			StorageGrade: d.StorageGrade,
+			Size:         d.Size,
+		}
+		for _, sched := range d.BackupSchedules {
+			disc.BackupSchedules = append(disc.BackupSchedules, brain.BackupSchedule{
+				StartDate: sched.StartDate,
+				Interval:  sched.Interval,
+				Capacity:  sched.Capacity,
+			})
+		}
+		server.Discs = append(server.Discs, disc)
+	}
+
+	privs, err := client.GetPrivilegesForVirtualMachine(name)
+	if err != nil {
+		return
+	}
+	server.Privileges = exportPrivileges(privs, brain.PrivilegeTargetTypeVM)
+	return
+}
+
+// exportPrivileges strips IDs and target names from the privileges of the
+// given target type, since the manifest says what they're granted on.
+func exportPrivileges(privs brain.Privileges, targetType string) (exported brain.Privileges) {
+	for _, p := range privs {
+		if p.TargetType() != targetType {
+			continue
+		}
+		exported = append(exported, brain.Privilege{
+			Username:         p.Username,
+			Level:            p.Level,
+			APIKeyID:         p.APIKeyID,
+			PasswordRequired: p.PasswordRequired,
+			YubikeyRequired:  p.YubikeyRequired,
+		})
+	}
+	sort.Slice(exported, func(i, j int) bool {
+		if exported[i].Username == exported[j].Username {
+			return exported[i].Level < exported[j].Level
+		}
+		return exported[i].Username < exported[j].Username
+	})
+	return
+}
+
+// WriteYAML writes m out as a YAML document. Fields are written in the same
+// order as they are in the JSON form of the manifest.
+func WriteYAML(wr io.Writer, m Manifest) error {
+	js, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	ordered, err := readOrdered(dec)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(ordered)
+	if err != nil {
+		return err
+	}
+	_, err = wr.Write(data)
+	return err
+}
+
+// readOrdered reads the next value from dec, turning JSON objects into
+// yaml.MapSlices so that the order of their keys is kept.
+func readOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := yaml.MapSlice{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := readOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj = append(obj, yaml.MapItem{Key: key, Value: value})
+			}
+			// consume the closing }
+			_, err = dec.Token()
+			return obj, err
+		case '[':
+			arr := []interface{}{}
+			for dec.More() {
+				value, err := readOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, value)
+			}
+			// consume the closing ]
+			_, err = dec.Token()
+			return arr, err
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return tok, nil
+}
+
+// WriteJSON writes m out as an indented JSON document.
+func WriteJSON(wr io.Writer, m Manifest) error {
+	enc := json.NewEncoder(wr)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
@@ -0,0 +1,127 @@
+package manifest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/manifest"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+const expectedExportYAML = `account: acc
+groups:
+- name: default
+- name: web
+  privileges:
+  - username: alice
+    level: group_admin
+    password_required: true
+    yubikey_required: false
+  servers:
+  - virtual_machine:
+      cores: 2
+      memory: 2048
+      name: web1
+      hardware_profile: virtio2018
+      zone_name: york
+    discs:
+    - label: root
+      storage_grade: sata
+      size: 25600
+      backup_schedules:
+      - start_at: "2018-01-01 00:00:00"
+        interval_seconds: 86400
+        capacity: 1
+    - label: data
+      storage_grade: archive
+      size: 102400
+`
+
+func TestExport(t *testing.T) {
+	client := &mocks.Client{}
+	webGroup := pathers.GroupName{Group: "web", Account: "acc"}
+	defaultGroup := pathers.GroupName{Group: "default", Account: "acc"}
+	web1 := pathers.VirtualMachineName{GroupName: webGroup, VirtualMachine: "web1"}
+
+	client.When("GetGroup", defaultGroup).Return(brain.Group{Name: "default"}, nil).Times(1)
+	client.When("GetPrivilegesForGroup", defaultGroup).Return(brain.Privileges{}, nil).Times(1)
+	client.When("GetGroup", webGroup).Return(brain.Group{
+		Name: "web",
+		VirtualMachines: []brain.VirtualMachine{
+			{Name: "web1"},
+			{Name: "web0", Deleted: true},
+		},
+	}, nil).Times(1)
+	client.When("GetPrivilegesForGroup", webGroup).Return(brain.Privileges{{
+		ID:               5,
+		Username:         "alice",
+		Level:            brain.GroupAdminPrivilege,
+		GroupID:          3,
+		PasswordRequired: true,
+	}, {
+		// account privileges are returned for groups too, but can't be
+		// written in a manifest.
+		Username: "bob",
+		Level:    brain.AccountAdminPrivilege,
+	}}, nil).Times(1)
+	client.When("GetVirtualMachine", web1).Return(brain.VirtualMachine{
+		ID:              10,
+		Name:            "web1",
+		Hostname:        "web1.web.acc.uk0.bigv.io",
+		Cores:           2,
+		Memory:          2048,
+		HardwareProfile: "virtio2018",
+		ZoneName:        "york",
+		PowerOn:         true,
+		Discs: brain.Discs{{
+			ID:           21,
+			Label:        "data",
+			StorageGrade: "archive",
+			Size:         102400,
+		}, {
+			ID:           20,
+			Label:        "root",
+			StorageGrade: "sata",
+			Size:         25600,
+			BackupSchedules: brain.BackupSchedules{{
+				ID:        4,
+				StartDate: "2018-01-01 00:00:00",
+				Interval:  86400,
+				Capacity:  1,
+			}},
+		}},
+	}, nil).Times(1)
+	client.When("GetPrivilegesForVirtualMachine", web1).Return(brain.Privileges{}, nil).Times(1)
+
+	m, err := manifest.Export(client, lib.Account{
+		Name: "acc",
+		Groups: brain.Groups{
+			{Name: "web"},
+			{Name: "default"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := client.Verify(); !ok {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	err = manifest.WriteYAML(&buf, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "yaml", expectedExportYAML, buf.String())
+
+	// make sure what was exported can be read back in
+	parsed, err := manifest.Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "round trip", m, parsed)
+}
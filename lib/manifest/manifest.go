@@ -0,0 +1,133 @@
+// Package manifest reads, writes and applies declarative descriptions of the
+// groups, servers, discs, backup schedules and privileges on a Bytemark
+// account.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Manifest describes the state that an account should be in.
+type Manifest struct {
+	// Account is the name of the account the groups live in. When blank, the
+	// client's default account is used.
+	Account string  `json:"account,omitempty"`
+	Groups  []Group `json:"groups"`
+}
+
+// Group describes a group, the servers in it and the privileges granted on it.
+type Group struct {
+	Name       string           `json:"name"`
+	Privileges brain.Privileges `json:"privileges,omitempty"`
+	Servers    []Server         `json:"servers,omitempty"`
+}
+
+// Server describes a server using the same spec that is sent to the brain's
+// vm_create endpoint, along with the privileges granted on it.
+type Server struct {
+	brain.VirtualMachineSpec
+	Privileges brain.Privileges `json:"privileges,omitempty"`
+}
+
+// Name returns the name of the server.
+func (s Server) Name() string {
+	return s.VirtualMachine.Name
+}
+
+// Parse reads a manifest from YAML or JSON (which is a subset of YAML) and
+// validates it. Field names are the same as those used by the brain API.
+func Parse(data []byte) (m Manifest, err error) {
+	var raw interface{}
+	err = yaml.Unmarshal(data, &raw)
+	if err != nil {
+		return
+	}
+	// round-trip through JSON so that the json tags on the brain types are
+	// used to read the document.
+	js, err := json.Marshal(jsonify(raw))
+	if err != nil {
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&m)
+	if err != nil {
+		return
+	}
+	err = m.Validate()
+	return
+}
+
+// jsonify converts the map[interface{}]interface{}s that yaml.v2 produces
+// into map[string]interface{}s so that encoding/json can marshal them.
+func jsonify(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, val := range x {
+			m[fmt.Sprint(k)] = jsonify(val)
+		}
+		return m
+	case []interface{}:
+		for i := range x {
+			x[i] = jsonify(x[i])
+		}
+	}
+	return v
+}
+
+// Validate checks that every group and server has a name, that names are not
+// repeated and that privileges are of the right type for what they're
+// granted on.
+func (m Manifest) Validate() error {
+	groups := make(map[string]bool, len(m.Groups))
+	for i, g := range m.Groups {
+		if g.Name == "" {
+			return fmt.Errorf("group %d has no name", i+1)
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("group %s is specified more than once", g.Name)
+		}
+		groups[g.Name] = true
+
+		err := validatePrivileges(g.Privileges, brain.PrivilegeTargetTypeGroup, "group "+g.Name)
+		if err != nil {
+			return err
+		}
+
+		servers := make(map[string]bool, len(g.Servers))
+		for j, s := range g.Servers {
+			if s.Name() == "" {
+				return fmt.Errorf("server %d in group %s has no name", j+1, g.Name)
+			}
+			if servers[s.Name()] {
+				return fmt.Errorf("server %s is specified more than once in group %s", s.Name(), g.Name)
+			}
+			servers[s.Name()] = true
+
+			err = validatePrivileges(s.Privileges, brain.PrivilegeTargetTypeVM, "server "+s.Name())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validatePrivileges(privs brain.Privileges, targetType string, on string) error {
+	for _, p := range privs {
+		if p.Username == "" {
+			return fmt.Errorf("a privilege on %s has no username", on)
+		}
+		if !strings.HasPrefix(string(p.Level), targetType+"_") {
+			return fmt.Errorf("%s cannot be granted on %s", p.Level, on)
+		}
+	}
+	return nil
+}
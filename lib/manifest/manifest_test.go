@@ -0,0 +1,134 @@
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/manifest"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  manifest.Manifest
+		shouldErr bool
+	}{
+		{
+			name: "yaml",
+			input: `account: test-account
+groups:
+- name: web
+  privileges:
+  - username: alice
+    level: group_admin
+  servers:
+  - virtual_machine:
+      name: web1
+      cores: 2
+      memory: 2048
+    discs:
+    - label: root
+      size: 25600
+      backup_schedules:
+      - start_at: "2018-01-01 00:00:00"
+        interval_seconds: 86400
+        capacity: 1
+    privileges:
+    - username: bob
+      level: vm_console
+`,
+			expected: manifest.Manifest{
+				Account: "test-account",
+				Groups: []manifest.Group{{
+					Name: "web",
+					Privileges: brain.Privileges{{
+						Username: "alice",
+						Level:    "group_admin",
+					}},
+					Servers: []manifest.Server{{
+						VirtualMachineSpec: brain.VirtualMachineSpec{
+							VirtualMachine: brain.VirtualMachine{
+								Name:   "web1",
+								Cores:  2,
+								Memory: 2048,
+							},
+							Discs: []brain.Disc{{
+								Label: "root",
+								Size:  25600,
+								BackupSchedules: brain.BackupSchedules{{
+									StartDate: "2018-01-01 00:00:00",
+									Interval:  86400,
+									Capacity:  1,
+								}},
+							}},
+						},
+						Privileges: brain.Privileges{{
+							Username: "bob",
+							Level:    "vm_console",
+						}},
+					}},
+				}},
+			},
+		}, {
+			name:  "json",
+			input: `{"groups": [{"name": "db", "servers": [{"virtual_machine": {"name": "db1"}}]}]}`,
+			expected: manifest.Manifest{
+				Groups: []manifest.Group{{
+					Name: "db",
+					Servers: []manifest.Server{{
+						VirtualMachineSpec: brain.VirtualMachineSpec{
+							VirtualMachine: brain.VirtualMachine{
+								Name: "db1",
+							},
+						},
+					}},
+				}},
+			},
+		}, {
+			name:      "unknown field",
+			input:     "groups:\n- name: web\n  sevrers: []\n",
+			shouldErr: true,
+		}, {
+			name:      "unnamed group",
+			input:     "groups:\n- servers: []\n",
+			shouldErr: true,
+		}, {
+			name:      "unnamed server",
+			input:     "groups:\n- name: web\n  servers:\n  - discs: []\n",
+			shouldErr: true,
+		}, {
+			name:      "duplicate group",
+			input:     "groups:\n- name: web\n- name: web\n",
+			shouldErr: true,
+		}, {
+			name:      "duplicate server",
+			input:     "groups:\n- name: web\n  servers:\n  - virtual_machine: {name: a}\n  - virtual_machine: {name: a}\n",
+			shouldErr: true,
+		}, {
+			name:      "vm privilege on group",
+			input:     "groups:\n- name: web\n  privileges:\n  - username: alice\n    level: vm_admin\n",
+			shouldErr: true,
+		}, {
+			name:      "privilege without username",
+			input:     "groups:\n- name: web\n  privileges:\n  - level: group_admin\n",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := manifest.Parse([]byte(test.input))
+			if test.shouldErr {
+				if err == nil {
+					t.Fatal("expected an error but didn't get one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.name, test.expected, m)
+		})
+	}
+}
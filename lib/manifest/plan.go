@@ -0,0 +1,254 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/output"
+	"github.com/BytemarkHosting/bytemark-client/lib/output/prettyprint"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+// Action is a single change which needs making in order to bring an account
+// in line with a Manifest.
+type Action struct {
+	// Type is a short description of the change, like "create server"
+	Type string `json:"type"`
+	// Target is the name of the object being changed
+	Target string `json:"target"`
+	// Detail gives any extra information about the change
+	Detail string `json:"detail,omitempty"`
+
+	apply func(client lib.Client) error
+}
+
+// Apply makes the change.
+func (a Action) Apply(client lib.Client) error {
+	if a.apply == nil {
+		return fmt.Errorf("cannot %s %s - no way to apply this action", a.Type, a.Target)
+	}
+	return a.apply(client)
+}
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type.
+func (a Action) DefaultFields(f output.Format) string {
+	return "Type, Target, Detail"
+}
+
+// PrettyPrint outputs a single line describing the action to wr. All detail
+// levels are the same.
+func (a Action) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	actionTpl := `{{ define "action_sgl" }}{{ .Type }} {{ .Target }}{{ if .Detail }} ({{ .Detail }}){{ end }}{{ end }}
+{{ define "action_medium" }}{{ template "action_sgl" . }}{{ end }}
+{{ define "action_full" }}{{ template "action_sgl" . }}{{ end }}
+`
+	return prettyprint.Run(wr, actionTpl, "action"+string(detail), a)
+}
+
+// Plan is the list of Actions which need to happen, in order, to bring an
+// account in line with a Manifest.
+type Plan []Action
+
+// DefaultFields returns the list of default fields to feed to github.com/BytemarkHosting/row.From for this type, which is the same as Action.DefaultFields.
+func (p Plan) DefaultFields(f output.Format) string {
+	return (Action{}).DefaultFields(f)
+}
+
+// PrettyPrint outputs the plan to wr. Full and Medium detail print one action
+// per line, SingleLine just counts the changes.
+func (p Plan) PrettyPrint(wr io.Writer, detail prettyprint.DetailLevel) error {
+	planTpl := `{{ define "plan_sgl" }}{{ if . }}{{ len . | pluralize "change" "changes" }} to make{{ else }}No changes to make{{ end }}{{ end }}
+{{ define "plan_medium" }}{{ if . }}{{ range . }}  • {{ prettysprint . "_sgl" }}
+{{ end }}{{ else }}{{ template "plan_sgl" . }}
+{{ end }}{{ end }}
+{{ define "plan_full" }}{{ template "plan_medium" . }}{{ end }}
+`
+	return prettyprint.Run(wr, planTpl, "plan"+string(detail), p)
+}
+
+// Apply applies each action in turn, stopping at the first error. If progress
+// is not nil it is called with each action just before it is applied.
+func (p Plan) Apply(client lib.Client, progress func(Action)) error {
+	for _, a := range p {
+		if progress != nil {
+			progress(a)
+		}
+		if err := a.Apply(client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakePlan compares m against what exists on the brain and works out what
+// needs to be created or changed. Nothing is ever deleted, and changes that
+// cannot be made without destroying data (like shrinking a disc) cause an
+// error.
+func MakePlan(client lib.Client, m Manifest) (plan Plan, err error) {
+	for _, g := range m.Groups {
+		name := pathers.GroupName{
+			Group:   g.Name,
+			Account: pathers.AccountName(m.Account),
+		}
+		err = client.EnsureGroupName(&name)
+		if err != nil {
+			return
+		}
+		var groupPlan Plan
+		groupPlan, err = planGroup(client, name, g)
+		if err != nil {
+			return
+		}
+		plan = append(plan, groupPlan...)
+	}
+	return
+}
+
+func isNotFound(err error) bool {
+	_, ok := err.(lib.NotFoundError)
+	return ok
+}
+
+func planGroup(client lib.Client, name pathers.GroupName, g Group) (plan Plan, err error) {
+	existing := brain.Privileges{}
+	group, err := client.GetGroup(name)
+	newGroup := isNotFound(err)
+	if newGroup {
+		err = nil
+		plan = append(plan, createGroup(name))
+	} else if err != nil {
+		return
+	} else {
+		existing, err = client.GetPrivilegesForGroup(name)
+		if err != nil {
+			return
+		}
+	}
+	for _, p := range g.Privileges {
+		p.GroupName = name.String()
+		p.GroupID = group.ID
+		if !hasPrivilege(existing, p) {
+			plan = append(plan, grantGroupPrivilege(name, p))
+		}
+	}
+
+	for _, s := range g.Servers {
+		vmName := pathers.VirtualMachineName{
+			GroupName:      name,
+			VirtualMachine: s.Name(),
+		}
+		var serverPlan Plan
+		if newGroup {
+			serverPlan = createServer(vmName, s)
+		} else {
+			serverPlan, err = planServer(client, vmName, s)
+			if err != nil {
+				return
+			}
+		}
+		plan = append(plan, serverPlan...)
+	}
+	return
+}
+
+func planServer(client lib.Client, name pathers.VirtualMachineName, s Server) (plan Plan, err error) {
+	vm, err := client.GetVirtualMachine(name)
+	if isNotFound(err) {
+		return createServer(name, s), nil
+	} else if err != nil {
+		return
+	}
+	if vm.Deleted {
+		return nil, fmt.Errorf("%s is deleted - undelete or purge it before applying", name)
+	}
+
+	spec := s.VirtualMachine
+	if spec.Cores != 0 && spec.Cores != vm.Cores {
+		plan = append(plan, setCores(name, vm.Cores, spec.Cores))
+	}
+	if spec.Memory != 0 && spec.Memory != vm.Memory {
+		plan = append(plan, setMemory(name, vm.Memory, spec.Memory))
+	}
+	if spec.HardwareProfile != "" && (spec.HardwareProfile != vm.HardwareProfile || spec.HardwareProfileLocked != vm.HardwareProfileLocked) {
+		plan = append(plan, setHardwareProfile(name, spec.HardwareProfile, spec.HardwareProfileLocked))
+	}
+
+	discPlan, err := planDiscs(name, vm, s.Discs)
+	if err != nil {
+		return
+	}
+	plan = append(plan, discPlan...)
+
+	existing, err := client.GetPrivilegesForVirtualMachine(name)
+	if err != nil {
+		return
+	}
+	for _, p := range s.Privileges {
+		p.VirtualMachineName = name.String()
+		p.VirtualMachineID = vm.ID
+		if !hasPrivilege(existing, p) {
+			plan = append(plan, grantServerPrivilege(name, p))
+		}
+	}
+	return
+}
+
+func planDiscs(name pathers.VirtualMachineName, vm brain.VirtualMachine, discs []brain.Disc) (plan Plan, err error) {
+	for i, d := range discs {
+		if d.Label == "" {
+			// same as the labels the brain gives discs created without them
+			d.Label = fmt.Sprintf("disc-%d", i+1)
+		}
+		current, found := findDisc(vm.Discs, d.Label)
+		if !found {
+			plan = append(plan, createDisc(name, d))
+			continue
+		}
+		if d.StorageGrade != "" && d.StorageGrade != current.StorageGrade {
+			return nil, fmt.Errorf("disc %s on %s is %s grade, not %s - changing the grade of a disc is not supported", d.Label, name, current.StorageGrade, d.StorageGrade)
+		}
+		if d.Size != 0 && d.Size < current.Size {
+			return nil, fmt.Errorf("disc %s on %s is %dGiB, which is larger than %dGiB - discs cannot be shrunk", d.Label, name, current.Size/1024, d.Size/1024)
+		}
+		if d.Size > current.Size {
+			plan = append(plan, resizeDisc(name, d.Label, current.Size, d.Size))
+		}
+		for _, sched := range d.BackupSchedules {
+			if !hasBackupSchedule(current.BackupSchedules, sched) {
+				plan = append(plan, createBackupSchedule(name, d.Label, sched))
+			}
+		}
+	}
+	return
+}
+
+func findDisc(discs []brain.Disc, label string) (brain.Disc, bool) {
+	for _, d := range discs {
+		if d.Label == label {
+			return d, true
+		}
+	}
+	return brain.Disc{}, false
+}
+
+// hasBackupSchedule checks for a schedule with the same interval - start
+// dates are not compared since the brain normalises them.
+func hasBackupSchedule(scheds brain.BackupSchedules, sched brain.BackupSchedule) bool {
+	for _, s := range scheds {
+		if s.Interval == sched.Interval {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrivilege(privs brain.Privileges, priv brain.Privilege) bool {
+	for _, p := range privs {
+		if p.Username == priv.Username && p.Level == priv.Level && p.APIKeyID == priv.APIKeyID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/manifest"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+	"github.com/BytemarkHosting/bytemark-client/mocks"
+)
+
+func webManifest() manifest.Manifest {
+	return manifest.Manifest{
+		Account: "acc",
+		Groups: []manifest.Group{{
+			Name: "web",
+			Privileges: brain.Privileges{{
+				Username: "alice",
+				Level:    brain.GroupAdminPrivilege,
+			}},
+			Servers: []manifest.Server{{
+				VirtualMachineSpec: brain.VirtualMachineSpec{
+					VirtualMachine: brain.VirtualMachine{
+						Name:   "web1",
+						Cores:  2,
+						Memory: 2048,
+					},
+					Discs: []brain.Disc{{
+						Label: "root",
+						Size:  51200,
+						BackupSchedules: brain.BackupSchedules{{
+							StartDate: "2018-01-01 00:00:00",
+							Interval:  86400,
+						}},
+					}, {
+						Size: 10240,
+					}},
+				},
+			}},
+		}},
+	}
+}
+
+// actionTypes is used to compare plans since Actions contain funcs, which
+// can't be compared.
+func actionTypes(plan manifest.Plan) (types []string) {
+	for _, a := range plan {
+		types = append(types, a.Type+" "+a.Target)
+	}
+	return
+}
+
+func TestMakePlanNewGroup(t *testing.T) {
+	client := &mocks.Client{}
+	groupName := pathers.GroupName{Group: "web", Account: "acc"}
+	client.When("GetGroup", groupName).Return(brain.Group{}, lib.NotFoundError{}).Times(1)
+
+	plan, err := manifest.MakePlan(client, webManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "new group", []string{
+		"create group web.acc",
+		"grant group_admin on group web.acc for alice",
+		"create server web1.web.acc",
+	}, actionTypes(plan))
+	if ok, err := client.Verify(); !ok {
+		t.Fatal(err)
+	}
+
+	client.Reset()
+	client.When("CreateGroup", groupName).Return(nil).Times(1)
+	client.When("GetGroup", groupName).Return(brain.Group{ID: 12}, nil).Times(1)
+	client.When("GrantPrivilege", brain.Privilege{
+		Username:         "alice",
+		Level:            brain.GroupAdminPrivilege,
+		GroupID:          12,
+		GroupName:        "web.acc",
+		PasswordRequired: true,
+	}).Return(nil).Times(1)
+	client.When("CreateVirtualMachine", groupName, webManifest().Groups[0].Servers[0].VirtualMachineSpec).Return(brain.VirtualMachine{}, nil).Times(1)
+
+	err = plan.Apply(client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := client.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestMakePlanExistingServer(t *testing.T) {
+	client := &mocks.Client{}
+	groupName := pathers.GroupName{Group: "web", Account: "acc"}
+	vmName := pathers.VirtualMachineName{GroupName: groupName, VirtualMachine: "web1"}
+	client.When("GetGroup", groupName).Return(brain.Group{ID: 12}, nil).Times(1)
+	client.When("GetPrivilegesForGroup", groupName).Return(brain.Privileges{{
+		Username: "alice",
+		Level:    brain.GroupAdminPrivilege,
+		GroupID:  12,
+	}}, nil).Times(1)
+	client.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{
+		ID:     34,
+		Cores:  1,
+		Memory: 2048,
+		Discs: brain.Discs{{
+			Label:        "root",
+			Size:         25600,
+			StorageGrade: "sata",
+		}},
+	}, nil).Times(1)
+	client.When("GetPrivilegesForVirtualMachine", vmName).Return(brain.Privileges{}, nil).Times(1)
+
+	plan, err := manifest.MakePlan(client, webManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "existing server", []string{
+		"set cores web1.web.acc",
+		"resize disc root on web1.web.acc",
+		"create backup schedule root on web1.web.acc",
+		"create disc disc-2 on web1.web.acc",
+	}, actionTypes(plan))
+	if ok, err := client.Verify(); !ok {
+		t.Fatal(err)
+	}
+
+	client.Reset()
+	client.When("SetVirtualMachineCores", vmName, 2).Return(nil).Times(1)
+	client.When("ResizeDisc", vmName, "root", 51200).Return(nil).Times(1)
+	client.When("CreateBackupSchedule", vmName, "root", "2018-01-01 00:00:00", 86400).Return(brain.BackupSchedule{}, nil).Times(1)
+	client.When("CreateDisc", vmName, brain.Disc{Label: "disc-2", Size: 10240, StorageGrade: "sata"}).Return(nil).Times(1)
+
+	applied := manifest.Plan{}
+	err = plan.Apply(client, func(a manifest.Action) {
+		applied = append(applied, a)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "progress", actionTypes(plan), actionTypes(applied))
+	if ok, err := client.Verify(); !ok {
+		t.Fatal(err)
+	}
+}
+
+func TestMakePlanShrinkDisc(t *testing.T) {
+	client := &mocks.Client{}
+	groupName := pathers.GroupName{Group: "web", Account: "acc"}
+	vmName := pathers.VirtualMachineName{GroupName: groupName, VirtualMachine: "web1"}
+	client.When("GetGroup", groupName).Return(brain.Group{ID: 12}, nil)
+	client.When("GetPrivilegesForGroup", groupName).Return(brain.Privileges{}, nil)
+	client.When("GetVirtualMachine", vmName).Return(brain.VirtualMachine{
+		Discs: brain.Discs{{
+			Label:        "root",
+			Size:         102400,
+			StorageGrade: "sata",
+		}},
+	}, nil)
+
+	_, err := manifest.MakePlan(client, webManifest())
+	if err == nil {
+		t.Error("expected an error when shrinking a disc but didn't get one")
+	}
+}
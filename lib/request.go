@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/BytemarkHosting/bytemark-client/util/log"
 )
@@ -58,12 +60,16 @@ func (e InsecureConnectionError) Error() string {
 // internalRequest is the workhorse of the bytemark-client/lib - it builds up a request, then Run can be called to get its results.
 type internalRequest struct {
 	authenticate  bool
+	cassette      *Cassette
 	client        Client
+	ctx           context.Context
 	endpoint      Endpoint
 	url           *url.URL
 	method        string
 	allowInsecure bool
 	hasRun        bool
+	retryPolicy   RetryPolicy
+	timeout       time.Duration
 }
 
 // GetURL returns the URL that the Request is for.
@@ -81,11 +87,15 @@ func (c *bytemarkClient) BuildRequestNoAuth(method string, endpoint Endpoint, pa
 		return
 	}
 	return &internalRequest{
+		cassette:      c.cassette,
 		client:        c,
+		ctx:           c.context(),
 		endpoint:      endpoint,
 		url:           url,
 		method:        method,
 		allowInsecure: c.allowInsecure,
+		retryPolicy:   c.retryPolicy,
+		timeout:       c.timeout,
 	}, nil
 }
 
@@ -97,11 +107,15 @@ func (c *bytemarkClient) BuildRequest(method string, endpoint Endpoint, path str
 	}
 	return &internalRequest{
 		authenticate:  true,
+		cassette:      c.cassette,
 		client:        c,
+		ctx:           c.context(),
 		endpoint:      endpoint,
 		url:           url,
 		method:        method,
 		allowInsecure: c.allowInsecure,
+		retryPolicy:   c.retryPolicy,
+		timeout:       c.timeout,
 	}, nil
 }
 
@@ -111,8 +125,10 @@ func (r *internalRequest) AllowInsecure() {
 }
 
 // mkHTTPClient creates an http.Client for this request. If the staging endpoint is used, InsecureSkipVerify is used because I guess we don't have a good cert for that brain.
+// If the request has a cassette, the client records to or replays from it.
 func (r *internalRequest) mkHTTPClient() (c *http.Client) {
 	c = new(http.Client)
+	c.Timeout = r.timeout
 	if r.url.Host == "staging.bigv.io" {
 		c.Transport = &http.Transport{
 			// disable gas lint for this line (gas looks for insecure TLS settings, among other things)
@@ -122,15 +138,23 @@ func (r *internalRequest) mkHTTPClient() (c *http.Client) {
 			},
 		}
 	}
+	if r.cassette != nil {
+		c.Transport = r.cassette.RoundTripper(c.Transport)
+	}
 	return c
 }
 
 // mkHTTPRequest assembles an http.Request for this Request, adding Authorization headers as needed, setting the Content-Type correctly for whichever endpoint it's talking to.
 func (r *internalRequest) mkHTTPRequest(body io.Reader) (req *http.Request, err error) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	req, err = http.NewRequest(r.method, r.url.String(), body)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	req.Close = true
 	req.Header.Add("User-Agent", "bytemark-client-"+Version)
 
@@ -191,19 +215,37 @@ func (r *internalRequest) Run(body io.Reader, responseObject interface{}) (statu
 
 	cli := r.mkHTTPClient()
 
-	// This isnt actually returning errors if not authenticated. (when Auth or preflight has not been run)
-	// TODO: dig deep and find out why it does not return this error (NilAuthError)
-	req, err := r.mkHTTPRequest(bytes.NewBuffer(rb))
-	if err != nil {
-		return
-	}
-	if len(rb) > 0 {
-		req.Header.Add("Content-Length", fmt.Sprintf("%d", len(rb)))
+	var req *http.Request
+	var res *http.Response
+	for retries := 0; ; retries++ {
+		// This isnt actually returning errors if not authenticated. (when Auth or preflight has not been run)
+		// TODO: dig deep and find out why it does not return this error (NilAuthError)
+		req, err = r.mkHTTPRequest(bytes.NewBuffer(rb))
+		if err != nil {
+			return
+		}
+		if len(rb) > 0 {
+			req.Header.Add("Content-Length", fmt.Sprintf("%d", len(rb)))
+		}
+		res, err = cli.Do(req)
+		if req.Context().Err() != nil || !r.retryPolicy.shouldRetry(r.method, retries, res, err) {
+			break
+		}
+		if err == nil {
+			log.Debugf(log.LvlOutline, "%s %s: %d - retrying\r\n", r.method, req.URL, res.StatusCode)
+			res.Body.Close()
+		} else {
+			log.Debugf(log.LvlOutline, "%s %s: %s - retrying\r\n", r.method, req.URL, err)
+		}
+		err = r.retryPolicy.wait(req.Context(), retries)
+		if err != nil {
+			return
+		}
 	}
-	res, err := cli.Do(req)
 	if err != nil {
 		return
 	}
+	defer res.Body.Close()
 
 	statusCode = res.StatusCode
 
@@ -0,0 +1,22 @@
+package brain
+
+import (
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+// CreateNIC adds a network interface to the named server, attached to the
+// private VLAN of the group with the given ID.
+func CreateNIC(client lib.Client, vmName pathers.VirtualMachineName, vlanGroupID int) (nic brain.NetworkInterface, err error) {
+	err = client.EnsureVirtualMachineName(&vmName)
+	if err != nil {
+		return
+	}
+	r, err := client.BuildRequest("POST", lib.BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/nics", string(vmName.Account), vmName.Group, vmName.VirtualMachine)
+	if err != nil {
+		return
+	}
+	_, _, err = r.MarshalAndRun(map[string]int{"vlan_group_id": vlanGroupID}, &nic)
+	return
+}
@@ -0,0 +1,45 @@
+package brain_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestCreateNIC(t *testing.T) {
+	expected := brain.NetworkInterface{
+		ID:               12,
+		Label:            "eth1",
+		Mac:              "fe:ff:00:00:00:0c",
+		VlanNum:          1042,
+		ExtraIPs:         map[string]net.IP{},
+		VirtualMachineID: 4,
+	}
+	rts := testutil.RequestTestSpec{
+		Method:        "POST",
+		Endpoint:      lib.BrainEndpoint,
+		URL:           "/accounts/test-account/groups/test-group/virtual_machines/db1/nics",
+		AssertRequest: assert.BodyUnmarshalEqual(map[string]interface{}{"vlan_group_id": 33.0}),
+		Response:      expected,
+	}
+	rts.Run(t, "", true, func(client lib.Client) {
+		vmName := pathers.VirtualMachineName{
+			VirtualMachine: "db1",
+			GroupName: pathers.GroupName{
+				Group:   "test-group",
+				Account: "test-account",
+			},
+		}
+		nic, err := brainRequests.CreateNIC(client, vmName, 33)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		assert.Equal(t, "nic", expected, nic)
+	})
+}
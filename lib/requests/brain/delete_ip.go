@@ -0,0 +1,18 @@
+package brain
+
+import (
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+)
+
+// DeleteIP releases the extra IP ip, so that it's no longer routed to any
+// server.
+func DeleteIP(client lib.Client, ip net.IP) (err error) {
+	r, err := client.BuildRequest("DELETE", lib.BrainEndpoint, "/ips/%s", ip.String())
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, nil)
+	return
+}
@@ -0,0 +1,25 @@
+package brain_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+)
+
+func TestDeleteIP(t *testing.T) {
+	testName := testutil.Name(0)
+	rts := testutil.RequestTestSpec{
+		Method:   "DELETE",
+		Endpoint: lib.BrainEndpoint,
+		URL:      "/ips/192.168.1.50",
+	}
+	rts.Run(t, testName, true, func(client lib.Client) {
+		err := brainRequests.DeleteIP(client, net.ParseIP("192.168.1.50"))
+		if err != nil {
+			t.Fatalf("%s err %s", testName, err)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package brain
+
+import (
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+)
+
+// DeleteNIC removes the network interface with the given ID from the named
+// server.
+func DeleteNIC(client lib.Client, vmName pathers.VirtualMachineName, nicID int) (err error) {
+	err = client.EnsureVirtualMachineName(&vmName)
+	if err != nil {
+		return
+	}
+	r, err := client.BuildRequest("DELETE", lib.BrainEndpoint, "/accounts/%s/groups/%s/virtual_machines/%s/nics/%s", string(vmName.Account), vmName.Group, vmName.VirtualMachine, strconv.Itoa(nicID))
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, nil)
+	return
+}
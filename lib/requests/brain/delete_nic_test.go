@@ -0,0 +1,32 @@
+package brain_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/pathers"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+)
+
+func TestDeleteNIC(t *testing.T) {
+	testName := testutil.Name(0)
+	rts := testutil.RequestTestSpec{
+		Method:   "DELETE",
+		Endpoint: lib.BrainEndpoint,
+		URL:      "/accounts/test-account/groups/test-group/virtual_machines/db1/nics/12",
+	}
+	rts.Run(t, testName, true, func(client lib.Client) {
+		vmName := pathers.VirtualMachineName{
+			VirtualMachine: "db1",
+			GroupName: pathers.GroupName{
+				Group:   "test-group",
+				Account: "test-account",
+			},
+		}
+		err := brainRequests.DeleteNIC(client, vmName, 12)
+		if err != nil {
+			t.Fatalf("%s err %s", testName, err)
+		}
+	})
+}
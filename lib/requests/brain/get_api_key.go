@@ -0,0 +1,40 @@
+package brain
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// GetAPIKey gets the API key with the given label or ID, along with its
+// privileges. Labels are only unique per-user, and cluster admins can see
+// every user's API keys, so if more than one API key has the label an error is
+// returned and the ID must be used instead.
+func GetAPIKey(client lib.Client, labelOrID string) (apiKey brain.APIKey, err error) {
+	apiKeys, err := GetAPIKeys(client)
+	if err != nil {
+		return
+	}
+	id, convErr := strconv.Atoi(labelOrID)
+	found := 0
+	for _, candidate := range apiKeys {
+		if convErr == nil && candidate.ID == id {
+			return candidate, nil
+		}
+		if candidate.Label == labelOrID {
+			apiKey = candidate
+			found++
+		}
+	}
+	switch found {
+	case 0:
+		err = fmt.Errorf("Could not find an api key called %q", labelOrID)
+	case 1:
+	default:
+		apiKey = brain.APIKey{}
+		err = fmt.Errorf("There are %d api keys called %q - use the ID of the one you want instead (see `bytemark show api keys`)", found, labelOrID)
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package brain_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestGetAPIKey(t *testing.T) {
+	keys := brain.APIKeys{{
+		ID:    442,
+		Label: "not-jumanji",
+	}, {
+		ID:    229,
+		Label: "jumanji",
+	}, {
+		ID:       301,
+		Username: "alice",
+		Label:    "ci",
+	}, {
+		ID:       302,
+		Username: "bob",
+		Label:    "ci",
+	}}
+	tests := []struct {
+		name      string
+		labelOrID string
+		expected  brain.APIKey
+		shouldErr bool
+	}{
+		{
+			name:      "by label",
+			labelOrID: "jumanji",
+			expected:  keys[1],
+		}, {
+			name:      "by id",
+			labelOrID: "442",
+			expected:  keys[0],
+		}, {
+			name:      "missing",
+			labelOrID: "zathura",
+			shouldErr: true,
+		}, {
+			name:      "ambiguous label",
+			labelOrID: "ci",
+			shouldErr: true,
+		}, {
+			name:      "ambiguous label by id",
+			labelOrID: "302",
+			expected:  keys[3],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:   "GET",
+				Endpoint: lib.BrainEndpoint,
+				URL:      "/api_keys",
+				Response: keys,
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				key, err := brainRequests.GetAPIKey(client, test.labelOrID)
+				if err != nil && !test.shouldErr {
+					t.Errorf("Unexpected error: %v", err)
+				} else if err == nil && test.shouldErr {
+					t.Error("Error expected but not returned")
+				}
+				assert.Equal(t, "api key", test.expected, key)
+			})
+		})
+	}
+}
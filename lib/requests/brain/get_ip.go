@@ -0,0 +1,18 @@
+package brain
+
+import (
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// GetIP gets the IP and its reverse DNS from the brain.
+func GetIP(client lib.Client, ip net.IP) (out brain.IP, err error) {
+	r, err := client.BuildRequest("GET", lib.BrainEndpoint, "/ips/%s", ip.String())
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, &out)
+	return
+}
@@ -0,0 +1,32 @@
+package brain_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestGetIP(t *testing.T) {
+	expected := brain.IP{
+		IP:   net.ParseIP("192.168.1.20"),
+		RDns: "mail.example.com",
+	}
+	rts := testutil.RequestTestSpec{
+		Method:   "GET",
+		Endpoint: lib.BrainEndpoint,
+		URL:      "/ips/192.168.1.20",
+		Response: expected,
+	}
+	rts.Run(t, "", true, func(client lib.Client) {
+		ip, err := brainRequests.GetIP(client, net.ParseIP("192.168.1.20"))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		assert.Equal(t, "ip", expected, ip)
+	})
+}
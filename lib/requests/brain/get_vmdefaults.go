@@ -0,0 +1,49 @@
+package brain
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// GetVMDefaults gets all the VM defaults you can currently see - which are
+// the public ones, and those belonging to accounts you have privileges on.
+func GetVMDefaults(client lib.Client) (vmds brain.VirtualMachineDefaults, err error) {
+	r, err := client.BuildRequest("GET", lib.BrainEndpoint, "/vm_defaults")
+	if err != nil {
+		return
+	}
+	_, _, err = r.Run(nil, &vmds)
+	return
+}
+
+// GetVMDefault gets the VM default with the given name or ID. Names are only
+// unique per-account, so if more than one VM default has the name an error is
+// returned and the ID must be used instead.
+func GetVMDefault(client lib.Client, nameOrID string) (vmd brain.VirtualMachineDefault, err error) {
+	vmds, err := GetVMDefaults(client)
+	if err != nil {
+		return
+	}
+	id, convErr := strconv.Atoi(nameOrID)
+	found := 0
+	for _, candidate := range vmds {
+		if convErr == nil && candidate.ID == id {
+			return candidate, nil
+		}
+		if candidate.Name == nameOrID {
+			vmd = candidate
+			found++
+		}
+	}
+	switch found {
+	case 0:
+		err = fmt.Errorf("Could not find a VM default called %q", nameOrID)
+	case 1:
+	default:
+		err = fmt.Errorf("There are %d VM defaults called %q - use the ID of the one you want instead (see `bytemark show vm defaults`)", found, nameOrID)
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package brain_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestGetVMDefault(t *testing.T) {
+	vmds := brain.VirtualMachineDefaults{{
+		ID:     12,
+		Name:   "web",
+		Public: true,
+	}, {
+		ID:        13,
+		AccountID: 4,
+		Name:      "db",
+	}, {
+		ID:        14,
+		AccountID: 5,
+		Name:      "web",
+	}}
+	tests := []struct {
+		name      string
+		nameOrID  string
+		expected  brain.VirtualMachineDefault
+		shouldErr bool
+	}{
+		{
+			name:     "by name",
+			nameOrID: "db",
+			expected: vmds[1],
+		}, {
+			name:     "by id",
+			nameOrID: "14",
+			expected: vmds[2],
+		}, {
+			name:      "ambiguous name",
+			nameOrID:  "web",
+			shouldErr: true,
+		}, {
+			name:      "missing",
+			nameOrID:  "mail",
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:   "GET",
+				Endpoint: lib.BrainEndpoint,
+				URL:      "/vm_defaults",
+				Response: vmds,
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				vmd, err := brainRequests.GetVMDefault(client, test.nameOrID)
+				if err != nil && !test.shouldErr {
+					t.Errorf("Unexpected error: %v", err)
+				} else if err == nil && test.shouldErr {
+					t.Error("Error expected but not returned")
+				}
+				if !test.shouldErr {
+					assert.Equal(t, "vm default", test.expected, vmd)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package brain
+
+import (
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+)
+
+// RouteExtraIP changes which server IP the extra IP ip is routed to. routeTo
+// can be any IP attached to a server the user can administer, which needn't
+// be the server ip is currently routed to.
+func RouteExtraIP(client lib.Client, ip net.IP, routeTo net.IP) (err error) {
+	r, err := client.BuildRequest("PUT", lib.BrainEndpoint, "/ips/%s", ip.String())
+	if err != nil {
+		return
+	}
+	_, _, err = r.MarshalAndRun(map[string]string{"routed_to": routeTo.String()}, nil)
+	return
+}
@@ -0,0 +1,60 @@
+package brain_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestRouteExtraIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		ip         string
+		routeTo    string
+		url        string
+		statusCode int
+		shouldErr  bool
+	}{
+		{
+			name:    "ipv4",
+			ip:      "192.168.1.50",
+			routeTo: "192.168.1.3",
+			url:     "/ips/192.168.1.50",
+		}, {
+			name:    "ipv6",
+			ip:      "fe80::50",
+			routeTo: "fe80::3",
+			url:     "/ips/fe80::50",
+		}, {
+			name:       "error",
+			ip:         "192.168.1.50",
+			routeTo:    "192.168.1.3",
+			url:        "/ips/192.168.1.50",
+			statusCode: 404,
+			shouldErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:        "PUT",
+				Endpoint:      lib.BrainEndpoint,
+				URL:           test.url,
+				StatusCode:    test.statusCode,
+				AssertRequest: assert.BodyUnmarshalEqual(map[string]interface{}{"routed_to": test.routeTo}),
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				err := brainRequests.RouteExtraIP(client, net.ParseIP(test.ip), net.ParseIP(test.routeTo))
+				if err != nil && !test.shouldErr {
+					t.Errorf("Unexpected error: %v", err)
+				} else if err == nil && test.shouldErr {
+					t.Error("Error expected but not returned")
+				}
+			})
+		})
+	}
+}
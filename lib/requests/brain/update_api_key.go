@@ -0,0 +1,33 @@
+package brain
+
+import (
+	"strconv"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+)
+
+// UpdateAPIKey changes the label and/or expiry of the API key with the given
+// ID.
+func UpdateAPIKey(client lib.Client, id int, modification brain.APIKeyModification) (err error) {
+	r, err := client.BuildRequest("PUT", lib.BrainEndpoint, "/api_keys/%s", strconv.Itoa(id))
+	if err != nil {
+		return
+	}
+
+	spec := map[string]interface{}{}
+	if modification.Label != nil {
+		spec["label"] = *modification.Label
+	}
+	if modification.ExpiresAt != nil {
+		if *modification.ExpiresAt == "" {
+			// null makes the key never expire
+			spec["expires_at"] = nil
+		} else {
+			spec["expires_at"] = *modification.ExpiresAt
+		}
+	}
+
+	_, _, err = r.MarshalAndRun(spec, nil)
+	return
+}
@@ -0,0 +1,66 @@
+package brain_test
+
+import (
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	"github.com/BytemarkHosting/bytemark-client/lib/brain"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestUpdateAPIKey(t *testing.T) {
+	label := "new-label"
+	expiresAt := "2030-01-01T00:00:00+0000"
+	never := ""
+	tests := []struct {
+		name         string
+		modification brain.APIKeyModification
+		expected     map[string]interface{}
+		statusCode   int
+		shouldErr    bool
+	}{
+		{
+			name:         "label",
+			modification: brain.APIKeyModification{Label: &label},
+			expected:     map[string]interface{}{"label": "new-label"},
+		}, {
+			name:         "label and expiry",
+			modification: brain.APIKeyModification{Label: &label, ExpiresAt: &expiresAt},
+			expected: map[string]interface{}{
+				"label":      "new-label",
+				"expires_at": "2030-01-01T00:00:00+0000",
+			},
+		}, {
+			name:         "never expires",
+			modification: brain.APIKeyModification{ExpiresAt: &never},
+			expected:     map[string]interface{}{"expires_at": nil},
+		}, {
+			name:         "error",
+			modification: brain.APIKeyModification{Label: &label},
+			expected:     map[string]interface{}{"label": "new-label"},
+			statusCode:   500,
+			shouldErr:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:        "PUT",
+				Endpoint:      lib.BrainEndpoint,
+				URL:           "/api_keys/12",
+				StatusCode:    test.statusCode,
+				AssertRequest: assert.BodyUnmarshalEqual(test.expected),
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				err := brainRequests.UpdateAPIKey(client, 12, test.modification)
+				if err != nil && !test.shouldErr {
+					t.Errorf("Unexpected error: %v", err)
+				} else if err == nil && test.shouldErr {
+					t.Error("Error expected but not returned")
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package brain
+
+import (
+	"net"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+)
+
+// UpdateIPRDNS sets the reverse DNS (PTR record) of ip to rdns.
+func UpdateIPRDNS(client lib.Client, ip net.IP, rdns string) (err error) {
+	r, err := client.BuildRequest("PUT", lib.BrainEndpoint, "/ips/%s", ip.String())
+	if err != nil {
+		return
+	}
+	_, _, err = r.MarshalAndRun(map[string]string{"rdns": rdns}, nil)
+	return
+}
@@ -0,0 +1,56 @@
+package brain_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/BytemarkHosting/bytemark-client/lib"
+	brainRequests "github.com/BytemarkHosting/bytemark-client/lib/requests/brain"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil"
+	"github.com/BytemarkHosting/bytemark-client/lib/testutil/assert"
+)
+
+func TestUpdateIPRDNS(t *testing.T) {
+	tests := []struct {
+		name       string
+		ip         string
+		url        string
+		statusCode int
+		shouldErr  bool
+	}{
+		{
+			name: "ipv4",
+			ip:   "192.168.1.20",
+			url:  "/ips/192.168.1.20",
+		}, {
+			name: "ipv6",
+			ip:   "fe80::20",
+			url:  "/ips/fe80::20",
+		}, {
+			name:       "error",
+			ip:         "192.168.1.20",
+			url:        "/ips/192.168.1.20",
+			statusCode: 403,
+			shouldErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rts := testutil.RequestTestSpec{
+				Method:        "PUT",
+				Endpoint:      lib.BrainEndpoint,
+				URL:           test.url,
+				StatusCode:    test.statusCode,
+				AssertRequest: assert.BodyUnmarshalEqual(map[string]interface{}{"rdns": "mail.example.com"}),
+			}
+			rts.Run(t, test.name, true, func(client lib.Client) {
+				err := brainRequests.UpdateIPRDNS(client, net.ParseIP(test.ip), "mail.example.com")
+				if err != nil && !test.shouldErr {
+					t.Errorf("Unexpected error: %v", err)
+				} else if err == nil && test.shouldErr {
+					t.Error("Error expected but not returned")
+				}
+			})
+		})
+	}
+}
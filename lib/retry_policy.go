@@ -0,0 +1,77 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy determines whether and how often requests are retried after
+// failing in a way that might be temporary - like a network error or a
+// 502, 503 or 504 response from a proxy in front of the API. Only GET and HEAD
+// requests are retried, since they are safe to repeat.
+type RetryPolicy struct {
+	// Retries is the maximum number of times a request will be retried. 0
+	// means requests are never retried.
+	Retries int
+	// Backoff is how long to wait before the first retry. The wait doubles
+	// after each retry.
+	Backoff time.Duration
+	// MaxBackoff is the longest to ever wait between retries.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy returns the RetryPolicy new clients use - which never
+// retries, but backs off from 1 to at most 30 seconds if Retries is raised.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Retries:    0,
+		Backoff:    time.Second,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// Delay returns how long to wait before making the given retry - the first
+// retry being retry 0.
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	delay := p.Backoff
+	for i := 0; i < retry && (p.MaxBackoff == 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// shouldRetry returns true if a request which has already been retried
+// `retries` times and ended up with res or err should be tried again.
+func (p RetryPolicy) shouldRetry(method string, retries int, res *http.Response, err error) bool {
+	if retries >= p.Retries {
+		return false
+	}
+	if method != "GET" && method != "HEAD" {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// wait sleeps for the delay before the given retry, returning early with
+// ctx's error if ctx is cancelled first.
+func (p RetryPolicy) wait(ctx context.Context, retry int) error {
+	timer := time.NewTimer(p.Delay(retry))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
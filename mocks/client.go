@@ -182,6 +182,35 @@ func (c *Client) GetDiscByID(id int) (disc brain.Disc, err error) {
 	return disc, r.Error(1)
 }
 
+func (c *Client) GetGroupFirewallRules(group pathers.GroupName) (brain.FirewallRules, error) {
+	r := c.Called(group)
+	rules, _ := r.Get(0).(brain.FirewallRules)
+	return rules, r.Error(1)
+}
+func (c *Client) GetServerFirewallRules(vm pathers.VirtualMachineName) (brain.FirewallRules, error) {
+	r := c.Called(vm)
+	rules, _ := r.Get(0).(brain.FirewallRules)
+	return rules, r.Error(1)
+}
+func (c *Client) GetFirewallRule(id int) (brain.FirewallRule, error) {
+	r := c.Called(id)
+	rule, _ := r.Get(0).(brain.FirewallRule)
+	return rule, r.Error(1)
+}
+func (c *Client) CreateGroupFirewallRule(group pathers.GroupName, rule brain.FirewallRule) (brain.FirewallRule, error) {
+	r := c.Called(group, rule)
+	created, _ := r.Get(0).(brain.FirewallRule)
+	return created, r.Error(1)
+}
+func (c *Client) CreateServerFirewallRule(vm pathers.VirtualMachineName, rule brain.FirewallRule) (brain.FirewallRule, error) {
+	r := c.Called(vm, rule)
+	created, _ := r.Get(0).(brain.FirewallRule)
+	return created, r.Error(1)
+}
+func (c *Client) DeleteFirewallRule(id int) error {
+	r := c.Called(id)
+	return r.Error(0)
+}
 func (c *Client) CreateGroup(name pathers.GroupName) error {
 	r := c.Called(name)
 	return r.Error(0)
@@ -66,6 +66,11 @@ func (c *Config) GetAll() (config.Vars, error) {
 	return ret.Get(0).(config.Vars), ret.Error(1)
 }
 
+func (c *Config) GetAllInsensitive() (config.Vars, error) {
+	ret := c.Called()
+	return ret.Get(0).(config.Vars), ret.Error(1)
+}
+
 func (c *Config) PanelURL() string {
 	ret := c.Called()
 	return ret.String(0)
@@ -98,3 +103,29 @@ func (c *Config) ImportFlags(*flag.FlagSet) []string {
 	}
 	return nil
 }
+
+func (c *Config) CurrentProfile() (string, error) {
+	ret := c.Called()
+	return ret.String(0), ret.Error(1)
+}
+
+func (c *Config) Profiles() (config.Profiles, error) {
+	ret := c.Called()
+	return ret.Get(0).(config.Profiles), ret.Error(1)
+}
+
+func (c *Config) UseProfile(name string) error {
+	ret := c.Called(name)
+	return ret.Error(0)
+}
+
+func (c *Config) CopyProfile(from, to string) error {
+	ret := c.Called(from, to)
+	return ret.Error(0)
+}
+
+func (c *Config) MigrateSecrets() ([]string, error) {
+	ret := c.Called()
+	migrated, _ := ret.Get(0).([]string)
+	return migrated, ret.Error(1)
+}